@@ -0,0 +1,152 @@
+package apply
+
+import (
+	"errors"
+	"fcopy/internal/diff"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Change is a file that applying would create or modify
+type Change struct {
+	Path   string // Absolute path on disk
+	Name   string // Path as written in the input
+	Old    string // Current content, empty for a new file
+	New    string
+	Exists bool
+}
+
+// Skipped is a parsed file that won't be written, and why
+type Skipped struct {
+	Name   string
+	Reason string
+}
+
+// Plan works out which of files would change under root. Files given as
+// paths outside root, files whose header notes show fcopy only copied part
+// of them, and bundle files left as they were copied are skipped rather than
+// written.
+func Plan(files []File, root string) ([]Change, []Skipped) {
+	var changes []Change
+	var skipped []Skipped
+	byPath := make(map[string]int)
+	for _, f := range files {
+		if len(f.Notes) > 0 {
+			skipped = append(skipped, Skipped{f.Path, "altered when copied (" + strings.Join(f.Notes, "; ") + ")"})
+			continue
+		}
+		// Writing back an unedited copy would only undo later changes on disk
+		if f.Verbatim {
+			skipped = append(skipped, Skipped{f.Path, "unchanged"})
+			continue
+		}
+		path, err := resolve(root, f.Path)
+		if err != nil {
+			skipped = append(skipped, Skipped{f.Path, err.Error()})
+			continue
+		}
+
+		change := Change{Path: path, Name: f.Path, New: f.Content}
+		data, err := os.ReadFile(path)
+		if err == nil {
+			change.Old, change.Exists = string(data), true
+			// Models tend to drop the final newline; keep the file's
+			if strings.HasSuffix(change.Old, "\n") && !strings.HasSuffix(change.New, "\n") {
+				change.New += "\n"
+			}
+		} else if !errors.Is(err, fs.ErrNotExist) {
+			skipped = append(skipped, Skipped{f.Path, err.Error()})
+			continue
+		}
+		if change.Exists && change.Old == change.New {
+			skipped = append(skipped, Skipped{f.Path, "unchanged"})
+			continue
+		}
+
+		// A later block for the same file replaces an earlier one
+		if i, ok := byPath[path]; ok {
+			changes[i] = change
+			continue
+		}
+		byPath[path] = len(changes)
+		changes = append(changes, change)
+	}
+	return changes, skipped
+}
+
+// resolve turns name into an absolute path, refusing paths outside root,
+// lexically or through symlinks, and symlinks themselves, which writing
+// would follow
+func resolve(root, name string) (string, error) {
+	path := filepath.FromSlash(name)
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(root, path)
+	}
+	path = filepath.Clean(path)
+	if !within(root, path) {
+		return "", fmt.Errorf("outside %s", root)
+	}
+
+	if info, err := os.Lstat(path); err == nil && info.Mode()&fs.ModeSymlink != 0 {
+		return "", errors.New("is a symlink")
+	}
+	realRoot, err := filepath.EvalSymlinks(root)
+	if err != nil {
+		return "", err
+	}
+	// The file and its parents may not exist yet; check the deepest that does
+	existing := path
+	for existing != root {
+		if _, err := os.Lstat(existing); err == nil {
+			break
+		}
+		existing = filepath.Dir(existing)
+	}
+	real, err := filepath.EvalSymlinks(existing)
+	if err != nil {
+		return "", err
+	}
+	if !within(realRoot, real) {
+		return "", fmt.Errorf("outside %s through a symlink", root)
+	}
+	return path, nil
+}
+
+// within reports whether the clean path is dir or inside it
+func within(dir, path string) bool {
+	rel, err := filepath.Rel(dir, path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// Diff returns the change as a unified diff
+func (c Change) Diff() string {
+	if !c.Exists {
+		return diff.Unified("/dev/null", "b/"+c.Name, "", c.New, 3)
+	}
+	return diff.Unified("a/"+c.Name, "b/"+c.Name, c.Old, c.New, 3)
+}
+
+// Write writes every change to disk, creating directories as needed and
+// keeping the permissions of existing files. A file replaced by a symlink
+// since it was planned is refused rather than written through.
+func Write(changes []Change) error {
+	for _, c := range changes {
+		mode := fs.FileMode(0644)
+		if info, err := os.Lstat(c.Path); err == nil {
+			if info.Mode()&fs.ModeSymlink != 0 {
+				return fmt.Errorf("%s is a symlink", c.Path)
+			}
+			mode = info.Mode().Perm()
+		}
+		if err := os.MkdirAll(filepath.Dir(c.Path), 0755); err != nil {
+			return err
+		}
+		if err := os.WriteFile(c.Path, []byte(c.New), mode); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,259 @@
+// Package apply reads files back out of an fcopy bundle or an LLM response,
+// so edited files can be written to disk
+package apply
+
+import (
+	"crypto/sha256"
+	"fcopy/internal/output"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// File is a file parsed back out of text
+type File struct {
+	Path    string
+	Content string
+	Notes   []string // Header notes, present when fcopy altered the content
+
+	// Verbatim is set for bundle files whose content still matches the
+	// checksum recorded when they were copied, i.e. nobody edited them
+	Verbatim bool
+}
+
+// header matches the "-- path --" and "-- path (notes) --" lines of the
+// plain format
+var header = regexp.MustCompile(`^-- (.+?) --$`)
+
+// fence matches the opening line of a Markdown code block and its info string
+var fence = regexp.MustCompile("^\\s*(```+|~~~+)\\s*(.*)$")
+
+// pathLike matches a token that names a file: it has a directory or an
+// extension
+var pathLike = regexp.MustCompile(`^[\w@~.\-/\\]*[\w@~\-]+(/[\w@~.\-]+|\.[\w]+)$`)
+
+// pathComment matches a first line inside a code block naming the file, like
+// "// src/app.ts" or "# file: app.py"
+var pathComment = regexp.MustCompile(`^\s*(?://|#|--|/\*|<!--)\s*(?:(?i:file(?:name)?|path):\s*)?(\S+?)\s*(?:\*/|-->)?\s*$`)
+
+// Parse extracts files from text in the bundle or plain fcopy format, or otherwise
+// from fenced code blocks whose path is given in the info string (```go
+// path=main.go, ```main.go), on the line just above the block, or in a
+// comment on its first line. Blocks without a recognizable path are ignored.
+func Parse(text string) []File {
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+	if strings.Contains(text, output.BundleStart+"\n") {
+		return parseBundle(text)
+	}
+	if files := parseHeaders(text); len(files) > 0 {
+		return files
+	}
+	return parseFences(text)
+}
+
+// parseHeaders splits text at plain format headers. Lists of omitted files
+// and anything before the first header are skipped.
+func parseHeaders(text string) []File {
+	lines := strings.SplitAfter(text, "\n")
+	var files []File
+	var current *File
+	var body strings.Builder
+	fenced := false // The headers are wrapped in a code block
+	finish := func() {
+		if current != nil {
+			current.Content = trimSeparator(body.String())
+			files = append(files, *current)
+		}
+		body.Reset()
+	}
+
+	for i, line := range lines {
+		m := header.FindStringSubmatch(strings.TrimRight(line, "\n"))
+		if m == nil {
+			if current != nil {
+				body.WriteString(line)
+			}
+			continue
+		}
+		if len(files) == 0 && current == nil && i > 0 && fence.MatchString(lines[i-1]) {
+			fenced = true
+		}
+		finish()
+		current = nil
+		if strings.HasPrefix(m[1], "omitted by ") {
+			continue
+		}
+		path, notes := splitHeader(m[1])
+		current = &File{Path: path, Notes: notes}
+	}
+	finish()
+
+	if fenced && len(files) > 0 {
+		last := &files[len(files)-1]
+		content := strings.TrimRight(last.Content, "\n")
+		if i := strings.LastIndex(content, "\n"); strings.HasPrefix(strings.TrimSpace(content[i+1:]), "```") {
+			last.Content = trimSeparator(content[:i+1])
+		}
+	}
+	return files
+}
+
+// bundleFile matches the line starting a file in the bundle format
+var bundleFile = regexp.MustCompile(`^<<<file (.*)>>>$`)
+
+// parseBundle reads the files of a bundle; text around it is ignored
+func parseBundle(text string) []File {
+	lines := strings.SplitAfter(text[strings.Index(text, output.BundleStart+"\n"):], "\n")
+	var files []File
+	for i := 1; i < len(lines); i++ {
+		m := bundleFile.FindStringSubmatch(strings.TrimSuffix(lines[i], "\n"))
+		if m == nil {
+			continue
+		}
+		attrs := parseAttrs(m[1])
+
+		var content strings.Builder
+		for i++; i < len(lines) && strings.TrimSuffix(lines[i], "\n") != output.BundleEnd; i++ {
+			line := lines[i]
+			if output.EscapedLine.MatchString(strings.TrimPrefix(line, `\`)) && strings.HasPrefix(line, `\`) {
+				line = line[1:]
+			}
+			content.WriteString(line)
+		}
+
+		f := File{Path: attrs["path"], Content: content.String()}
+		if attrs["eol"] == "none" {
+			f.Content = strings.TrimSuffix(f.Content, "\n")
+		}
+		if notes := attrs["notes"]; notes != "" {
+			f.Notes = strings.Split(notes, "; ")
+		}
+		if sum := attrs["sha256"]; sum != "" {
+			f.Verbatim = fmt.Sprintf("%x", sha256.Sum256([]byte(f.Content))) == sum
+		}
+		if f.Path != "" {
+			files = append(files, f)
+		}
+	}
+	return files
+}
+
+// parseAttrs parses the key=value pairs of a bundle delimiter line, where
+// values are Go-quoted strings or bare words
+func parseAttrs(s string) map[string]string {
+	attrs := make(map[string]string)
+	for s = strings.TrimSpace(s); s != ""; s = strings.TrimSpace(s) {
+		key, rest, ok := strings.Cut(s, "=")
+		if !ok {
+			break
+		}
+		if quoted, err := strconv.QuotedPrefix(rest); err == nil {
+			attrs[key], _ = strconv.Unquote(quoted)
+			s = rest[len(quoted):]
+		} else {
+			value, after, _ := strings.Cut(rest, " ")
+			attrs[key] = value
+			s = after
+		}
+	}
+	return attrs
+}
+
+// splitHeader separates the path of a header from its parenthesized notes
+func splitHeader(s string) (string, []string) {
+	if !strings.HasSuffix(s, ")") {
+		return s, nil
+	}
+	i := strings.LastIndex(s, " (")
+	if i < 0 {
+		return s, nil
+	}
+	return s[:i], strings.Split(s[i+2:len(s)-1], "; ")
+}
+
+// trimSeparator removes the line break and blank line the plain format
+// writes after each file's content
+func trimSeparator(s string) string {
+	if strings.HasSuffix(s, "\n\n") {
+		return strings.TrimSuffix(s, "\n\n")
+	}
+	return strings.TrimSuffix(s, "\n")
+}
+
+// parseFences extracts the named code blocks of a Markdown text
+func parseFences(text string) []File {
+	lines := strings.Split(text, "\n")
+	var files []File
+	for i := 0; i < len(lines); i++ {
+		m := fence.FindStringSubmatch(lines[i])
+		if m == nil {
+			continue
+		}
+		marker, info := m[1], m[2]
+
+		// Find the closing fence: the same character, at least as long
+		end := i + 1
+		for end < len(lines) {
+			closing := strings.TrimSpace(lines[end])
+			if strings.HasPrefix(closing, marker[:1]) && strings.Trim(closing, marker[:1]) == "" && len(closing) >= len(marker) {
+				break
+			}
+			end++
+		}
+		body := lines[i+1 : min(end, len(lines))]
+
+		path := infoPath(info)
+		if path == "" && i > 0 {
+			path = linePath(lines[i-1])
+			if path == "" && i > 1 && strings.TrimSpace(lines[i-1]) == "" {
+				path = linePath(lines[i-2])
+			}
+		}
+		if path == "" && len(body) > 0 {
+			if c := pathComment.FindStringSubmatch(body[0]); c != nil && pathLike.MatchString(c[1]) {
+				path = c[1]
+				body = body[1:]
+			}
+		}
+		if path != "" {
+			content := strings.Join(body, "\n")
+			if len(body) > 0 {
+				content += "\n"
+			}
+			files = append(files, File{Path: path, Content: content})
+		}
+		i = end
+	}
+	return files
+}
+
+// infoPath finds a path in the info string of a code block, such as
+// "go path=main.go", "go title=\"main.go\"", "go:main.go" or "main.go"
+func infoPath(info string) string {
+	for _, field := range strings.Fields(info) {
+		if _, value, ok := strings.Cut(field, "="); ok {
+			field = value
+		} else if _, value, ok := strings.Cut(field, ":"); ok && value != "" {
+			field = value
+		}
+		field = strings.Trim(field, `"'`)
+		if pathLike.MatchString(field) {
+			return field
+		}
+	}
+	return ""
+}
+
+// linePath finds a path on a line introducing a code block, such as
+// "### src/app.ts", "**File: `app.py`**" or "main.go:"
+func linePath(line string) string {
+	fields := strings.Fields(line)
+	for i := len(fields) - 1; i >= 0; i-- {
+		field := strings.Trim(fields[i], "*`'\"#:()[],")
+		if pathLike.MatchString(field) && !strings.HasSuffix(field, ".") {
+			return field
+		}
+	}
+	return ""
+}
@@ -0,0 +1,127 @@
+package bookmark
+
+import (
+	"encoding/json"
+	"errors"
+	"fcopy/internal/config"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// Bookmarks maps names to the absolute paths saved under them, so "@name"
+// on the command line stands for those paths
+type Bookmarks struct {
+	Paths map[string][]string `json:"paths"`
+
+	path string
+}
+
+// DefaultPath returns where bookmarks are stored: next to the user
+// configuration file
+func DefaultPath() string {
+	file := config.UserConfigPath()
+	if file == "" {
+		return ""
+	}
+	return filepath.Join(filepath.Dir(file), "bookmarks.json")
+}
+
+// Load reads the bookmarks stored at path. A missing file yields no
+// bookmarks.
+func Load(path string) (*Bookmarks, error) {
+	b := &Bookmarks{Paths: make(map[string][]string), path: path}
+	if path == "" {
+		return b, nil
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return b, nil
+	} else if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, b); err != nil {
+		return nil, fmt.Errorf("invalid bookmarks file %s: %v", path, err)
+	}
+	if b.Paths == nil {
+		b.Paths = make(map[string][]string)
+	}
+	return b, nil
+}
+
+// Names returns the names of all bookmarks, sorted
+func (b *Bookmarks) Names() []string {
+	names := make([]string, 0, len(b.Paths))
+	for name := range b.Paths {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// Add saves paths under name, after the ones saved there already. Paths are
+// made absolute so the bookmark works from any directory.
+func (b *Bookmarks) Add(name string, paths ...string) error {
+	if name == "" || strings.ContainsAny(name, `/\@`) {
+		return fmt.Errorf("invalid bookmark name %q: it can't be empty or contain /, \\ or @", name)
+	}
+	for _, path := range paths {
+		abs, err := filepath.Abs(path)
+		if err != nil {
+			return err
+		}
+		if !slices.Contains(b.Paths[name], abs) {
+			b.Paths[name] = append(b.Paths[name], abs)
+		}
+	}
+	return nil
+}
+
+// Remove deletes the bookmark name, reporting whether there was one
+func (b *Bookmarks) Remove(name string) bool {
+	_, ok := b.Paths[name]
+	delete(b.Paths, name)
+	return ok
+}
+
+// Expand returns the paths arg stands for if it starts with "@": "@api" for
+// the paths bookmarked as api, and "@api/users" for users inside each of
+// them. ok is false for arguments that aren't bookmarks.
+func (b *Bookmarks) Expand(arg string) (paths []string, ok bool, err error) {
+	if !strings.HasPrefix(arg, "@") || len(arg) == 1 {
+		return nil, false, nil
+	}
+	name, rest, _ := strings.Cut(filepath.ToSlash(arg[1:]), "/")
+	saved, found := b.Paths[name]
+	if !found {
+		if len(b.Paths) == 0 {
+			return nil, true, fmt.Errorf("no bookmark or set named %q (add a bookmark with: fcopy bookmark add %s <path>)", name, name)
+		}
+		return nil, true, fmt.Errorf("no bookmark or set named %q (bookmarks: %s)", name, strings.Join(b.Names(), ", "))
+	}
+	for _, path := range saved {
+		if rest != "" {
+			path = filepath.Join(path, filepath.FromSlash(rest))
+		}
+		paths = append(paths, path)
+	}
+	return paths, true, nil
+}
+
+// Save writes the bookmarks back to where they were loaded from
+func (b *Bookmarks) Save() error {
+	if b.path == "" {
+		return errors.New("no configuration directory to store bookmarks in")
+	}
+	data, err := json.MarshalIndent(b, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(b.path), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(b.path, append(data, '\n'), 0644)
+}
@@ -0,0 +1,68 @@
+package bookmark
+
+import (
+	"fcopy/internal/ignore"
+	"io/fs"
+	"path/filepath"
+	"strings"
+)
+
+// ExpandSet returns the paths a named set from the config file stands for.
+// Entries without glob characters are returned as they are; globs such as
+// "internal/**" or "cmd/*/main.go" are matched against the files under
+// their longest literal prefix, with "**" matching any number of
+// directories. Paths skip reports are left out, and directories it reports
+// aren't entered.
+func ExpandSet(entries []string, skip func(path string, isDir bool) bool) ([]string, error) {
+	var paths []string
+	for _, entry := range entries {
+		matches, err := glob(entry, skip)
+		if err != nil {
+			return nil, err
+		}
+		paths = append(paths, matches...)
+	}
+	return paths, nil
+}
+
+// glob expands one set entry
+func glob(pattern string, skip func(path string, isDir bool) bool) ([]string, error) {
+	segments := strings.Split(filepath.ToSlash(pattern), "/")
+	literal := 0
+	for literal < len(segments) && !strings.ContainsAny(segments[literal], `*?[`) {
+		literal++
+	}
+	if literal == len(segments) {
+		return []string{pattern}, nil
+	}
+
+	base := strings.Join(segments[:literal], "/")
+	if base == "" && literal > 0 {
+		base = "/"
+	} else if base == "" {
+		base = "."
+	}
+	p, _ := ignore.ParsePattern("/" + strings.Join(segments[literal:], "/"))
+
+	var paths []string
+	err := filepath.WalkDir(filepath.FromSlash(base), func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if path != filepath.FromSlash(base) && skip != nil && skip(path, d.IsDir()) {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if d.IsDir() {
+			return nil
+		}
+		rel, err := filepath.Rel(filepath.FromSlash(base), path)
+		if err == nil && p.Match(filepath.ToSlash(rel), false) {
+			paths = append(paths, path)
+		}
+		return nil
+	})
+	return paths, err
+}
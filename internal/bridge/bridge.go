@@ -0,0 +1,169 @@
+package bridge
+
+import (
+	"context"
+	"fmt"
+	"html/template"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// Chunk splits text into pieces of at most size bytes, breaking at line
+// boundaries. A single line longer than size becomes its own oversized chunk.
+func Chunk(text string, size int) []string {
+	if size <= 0 || len(text) <= size {
+		return []string{text}
+	}
+
+	var chunks []string
+	var current strings.Builder
+	for _, line := range strings.SplitAfter(text, "\n") {
+		if current.Len() > 0 && current.Len()+len(line) > size {
+			chunks = append(chunks, current.String())
+			current.Reset()
+		}
+		current.WriteString(line)
+	}
+	if current.Len() > 0 {
+		chunks = append(chunks, current.String())
+	}
+	return chunks
+}
+
+// page is the data rendered for a single chunk
+type page struct {
+	Index   int
+	Total   int
+	Bytes   int
+	Content string
+	Prev    int
+	Next    int
+}
+
+var pageTemplate = template.Must(template.New("page").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>fcopy - part {{.Index}}/{{.Total}}</title>
+<style>
+body { font-family: system-ui, sans-serif; margin: 1.5em; }
+nav { display: flex; gap: 1em; align-items: center; margin-bottom: 1em; }
+button, a { font-size: 1em; }
+pre { background: #f6f8fa; padding: 1em; overflow: auto; max-height: 75vh; border-radius: 6px; }
+</style>
+</head>
+<body>
+<nav>
+{{if .Prev}}<a href="/?part={{.Prev}}">&larr; Part {{.Prev}}</a>{{end}}
+<strong>Part {{.Index}}/{{.Total}}</strong> ({{.Bytes}} bytes)
+<button id="copy">Copy this part</button>
+{{if .Next}}<a href="/?part={{.Next}}">Part {{.Next}} &rarr;</a>{{end}}
+<form method="post" action="/done"><button>Done</button></form>
+</nav>
+<pre id="content">{{.Content}}</pre>
+<script>
+document.getElementById("copy").onclick = async function () {
+  const text = await (await fetch("/raw?part={{.Index}}")).text();
+  await navigator.clipboard.writeText(text);
+  this.textContent = "Copied!";
+};
+</script>
+</body>
+</html>
+`))
+
+// Serve starts a local HTTP server presenting chunks as pages with copy
+// buttons. It returns once the user presses "Done", the context is cancelled,
+// or no request arrived for idle.
+func Serve(ctx context.Context, addr string, chunks []string, idle time.Duration) error {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var lastRequest atomic.Int64
+	lastRequest.Store(time.Now().UnixNano())
+
+	part := func(r *http.Request) (int, bool) {
+		n, err := strconv.Atoi(r.URL.Query().Get("part"))
+		if r.URL.Query().Get("part") == "" {
+			n, err = 1, nil
+		}
+		return n, err == nil && n >= 1 && n <= len(chunks)
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		n, ok := part(r)
+		if !ok || r.URL.Path != "/" {
+			http.NotFound(w, r)
+			return
+		}
+		p := page{Index: n, Total: len(chunks), Bytes: len(chunks[n-1]), Content: chunks[n-1]}
+		if n > 1 {
+			p.Prev = n - 1
+		}
+		if n < len(chunks) {
+			p.Next = n + 1
+		}
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		pageTemplate.Execute(w, p)
+	})
+	mux.HandleFunc("/raw", func(w http.ResponseWriter, r *http.Request) {
+		n, ok := part(r)
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		w.Write([]byte(chunks[n-1]))
+	})
+	mux.HandleFunc("/done", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		w.Write([]byte("fcopy bridge stopped, you can close this tab.\n"))
+		cancel()
+	})
+
+	server := &http.Server{
+		Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			lastRequest.Store(time.Now().UnixNano())
+			mux.ServeHTTP(w, r)
+		}),
+	}
+
+	fmt.Printf("Serving %d parts at http://%s/ (press Ctrl+C or \"Done\" to stop)\n",
+		len(chunks), listener.Addr())
+
+	// Shut down when cancelled or idle for too long
+	go func() {
+		ticker := time.NewTicker(time.Second)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				server.Shutdown(context.Background())
+				return
+			case <-ticker.C:
+				if idle > 0 && time.Since(time.Unix(0, lastRequest.Load())) > idle {
+					fmt.Println("Bridge idle, shutting down")
+					cancel()
+				}
+			}
+		}
+	}()
+
+	if err := server.Serve(listener); err != http.ErrServerClosed {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,190 @@
+// Package charset detects text files in legacy encodings and transcodes them
+// to UTF-8
+package charset
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/charmap"
+	"golang.org/x/text/encoding/htmlindex"
+	"golang.org/x/text/encoding/japanese"
+	"golang.org/x/text/encoding/unicode"
+)
+
+// Lookup validates an --encoding value: "auto" to detect the encoding,
+// "utf-8" to never transcode, or a name such as shift_jis or utf-16le
+func Lookup(name string) error {
+	switch strings.ToLower(name) {
+	case "", "auto", "utf-8", "utf8":
+		return nil
+	}
+	if _, err := htmlindex.Get(name); err != nil {
+		return fmt.Errorf("unknown encoding %q", name)
+	}
+	return nil
+}
+
+// ToUTF8 transcodes data from the encoding named by name, or the detected one
+// with "auto", to UTF-8. It returns the name of the encoding it converted
+// from, or "" if data was left as it was.
+func ToUTF8(data []byte, name string) ([]byte, string) {
+	var enc encoding.Encoding
+	switch strings.ToLower(name) {
+	case "utf-8", "utf8":
+		return data, ""
+	case "", "auto":
+		enc, name = Detect(data)
+	default:
+		enc, _ = htmlindex.Get(name)
+	}
+	if enc == nil {
+		return data, ""
+	}
+
+	decoded, err := enc.NewDecoder().Bytes(data)
+	if err != nil {
+		return data, ""
+	}
+	return decoded, name
+}
+
+// Detect guesses the encoding of data when it isn't UTF-8, or mostly UTF-8
+// with a few invalid bytes. UTF-16 is
+// recognized by its byte order mark or by the NUL bytes it puts next to
+// ASCII characters; other text is tried as Shift-JIS, whose multi-byte
+// sequences are distinctive, and falls back to Windows-1252, the superset of
+// Latin-1 that legacy Windows files use. Data that doesn't look like text at
+// all gets a nil encoding.
+func Detect(data []byte) (encoding.Encoding, string) {
+	switch {
+	case bytes.HasPrefix(data, []byte{0xFF, 0xFE}):
+		return unicode.UTF16(unicode.LittleEndian, unicode.ExpectBOM), "UTF-16LE"
+	case bytes.HasPrefix(data, []byte{0xFE, 0xFF}):
+		return unicode.UTF16(unicode.BigEndian, unicode.ExpectBOM), "UTF-16BE"
+	}
+
+	if utf8.Valid(data) && bytes.IndexByte(data, 0) < 0 {
+		return nil, ""
+	}
+	// A few stray bytes in UTF-8 text are repaired rather than taken as a
+	// sign of another encoding, which would garble every accent
+	if mostlyUTF8(data) {
+		return nil, ""
+	}
+
+	if enc, name := guessUTF16(data); enc != nil {
+		return enc, name
+	}
+
+	if !looksLikeText(data) {
+		return nil, ""
+	}
+	if isShiftJIS(data) {
+		return japanese.ShiftJIS, "Shift_JIS"
+	}
+	return charmap.Windows1252, "Windows-1252"
+}
+
+// mostlyUTF8 reports whether data holds multi-byte UTF-8 characters and at
+// most a quarter as many invalid sequences. Legacy 8-bit text rarely forms
+// valid multi-byte sequences at all.
+func mostlyUTF8(data []byte) bool {
+	if bytes.IndexByte(data, 0) >= 0 {
+		return false
+	}
+	multi, invalid := 0, 0
+	for i := 0; i < len(data); {
+		r, size := utf8.DecodeRune(data[i:])
+		switch {
+		case r == utf8.RuneError && size == 1:
+			invalid++
+		case size > 1:
+			multi++
+		}
+		i += size
+	}
+	return multi > 0 && invalid*4 <= multi
+}
+
+// guessUTF16 recognizes UTF-16 without a byte order mark, where mostly ASCII
+// text leaves a NUL in every other byte
+func guessUTF16(data []byte) (encoding.Encoding, string) {
+	if len(data) < 4 || len(data)%2 != 0 {
+		return nil, ""
+	}
+	var even, odd int
+	for i := 0; i < len(data); i += 2 {
+		if data[i] == 0 {
+			even++
+		}
+		if data[i+1] == 0 {
+			odd++
+		}
+	}
+	pairs := len(data) / 2
+	switch {
+	case odd > pairs*3/4 && even < pairs/8:
+		return unicode.UTF16(unicode.LittleEndian, unicode.IgnoreBOM), "UTF-16LE"
+	case even > pairs*3/4 && odd < pairs/8:
+		return unicode.UTF16(unicode.BigEndian, unicode.IgnoreBOM), "UTF-16BE"
+	}
+	return nil, ""
+}
+
+// looksLikeText reports whether data is free of NULs and nearly free of
+// other control characters, unlike binary files
+func looksLikeText(data []byte) bool {
+	control := 0
+	for _, c := range data {
+		switch {
+		case c == 0:
+			return false
+		case c < 0x20 && c != '\t' && c != '\n' && c != '\r' && c != '\f' && c != 0x1B:
+			control++
+		}
+	}
+	return control <= len(data)/100
+}
+
+// isShiftJIS reports whether every non-ASCII byte in data is part of a valid
+// Shift-JIS character and the double-byte characters look Japanese. Latin-1
+// text also forms valid pairs, an accented letter followed by an ASCII one,
+// but those have a lead byte of 0xC0 or more and an ASCII trail byte, or
+// decode to nothing; at least half the pairs must have a lead byte below
+// 0xA0, where kana, punctuation and the common kanji are, or a trail byte
+// of 0x80 or more.
+func isShiftJIS(data []byte) bool {
+	decoder := japanese.ShiftJIS.NewDecoder()
+	double, japaneseLooking := 0, 0
+	for i := 0; i < len(data); i++ {
+		c := data[i]
+		switch {
+		case c < 0x80:
+		case c >= 0xA1 && c <= 0xDF:
+			// Half-width katakana
+		case (c >= 0x81 && c <= 0x9F) || (c >= 0xE0 && c <= 0xFC):
+			if i+1 >= len(data) {
+				return false
+			}
+			next := data[i+1]
+			if next < 0x40 || next == 0x7F || next > 0xFC {
+				return false
+			}
+			if char, err := decoder.Bytes(data[i : i+2]); err != nil || bytes.ContainsRune(char, utf8.RuneError) {
+				return false
+			}
+			double++
+			if c <= 0x9F || next >= 0x80 {
+				japaneseLooking++
+			}
+			i++
+		default:
+			return false
+		}
+	}
+	return double > 0 && japaneseLooking*2 >= double
+}
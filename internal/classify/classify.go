@@ -0,0 +1,149 @@
+package classify
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Category is a broad content type of a file
+type Category string
+
+const (
+	Code    Category = "code"
+	Config  Category = "config"
+	Docs    Category = "docs"
+	Data    Category = "data"
+	Unknown Category = "unknown"
+)
+
+// Categories lists the categories that can be selected with --type
+var Categories = []Category{Code, Config, Docs, Data}
+
+var codeExts = map[string]bool{
+	".go": true, ".ts": true, ".tsx": true, ".js": true, ".jsx": true, ".mjs": true, ".cjs": true,
+	".py": true, ".rb": true, ".rs": true, ".java": true, ".kt": true, ".kts": true, ".scala": true,
+	".c": true, ".h": true, ".cc": true, ".cpp": true, ".hpp": true, ".cs": true, ".fs": true,
+	".swift": true, ".m": true, ".php": true, ".pl": true, ".lua": true, ".dart": true,
+	".ex": true, ".exs": true, ".erl": true, ".hs": true, ".clj": true, ".elm": true,
+	".sh": true, ".bash": true, ".zsh": true, ".fish": true, ".ps1": true,
+	".sql": true, ".vue": true, ".svelte": true, ".html": true, ".css": true, ".scss": true,
+	".proto": true, ".graphql": true, ".tf": true,
+}
+
+var configExts = map[string]bool{
+	".yaml": true, ".yml": true, ".toml": true, ".ini": true, ".cfg": true, ".conf": true,
+	".env": true, ".properties": true, ".editorconfig": true, ".plist": true,
+}
+
+var docsExts = map[string]bool{
+	".md": true, ".markdown": true, ".rst": true, ".txt": true, ".adoc": true,
+	".org": true, ".tex": true, ".rtf": true,
+}
+
+var dataExts = map[string]bool{
+	".csv": true, ".tsv": true, ".jsonl": true, ".ndjson": true, ".parquet": true,
+	".xml": true, ".sqlite": true, ".db": true,
+}
+
+// configNames are well-known configuration files identified by name
+var configNames = map[string]bool{
+	"dockerfile": true, "makefile": true, "go.mod": true, "go.sum": true,
+	"package.json": true, "tsconfig.json": true, "jsconfig.json": true, "composer.json": true,
+	"cargo.toml": true, "pyproject.toml": true, "pom.xml": true, "build.gradle": true,
+	"docker-compose.yml": true, "docker-compose.yaml": true, ".gitignore": true,
+	".gitattributes": true, ".dockerignore": true, ".npmrc": true, ".nvmrc": true,
+	"procfile": true, "gemfile": true, "requirements.txt": true,
+}
+
+// docsPrefixes are name prefixes of documentation files without extensions
+var docsPrefixes = []string{"readme", "changelog", "contributing", "license", "copying", "authors", "notice"}
+
+// Parse validates a list of category names
+func Parse(names []string) ([]Category, error) {
+	var categories []Category
+	for _, name := range names {
+		c := Category(strings.ToLower(strings.TrimSpace(name)))
+		valid := false
+		for _, known := range Categories {
+			if c == known {
+				valid = true
+			}
+		}
+		if !valid {
+			return nil, fmt.Errorf("unknown type %q (expected code, config, docs or data)", name)
+		}
+		categories = append(categories, c)
+	}
+	return categories, nil
+}
+
+// Classify determines the category of the file at path from its name and
+// extension, peeking at the content when those are ambiguous
+func Classify(path string) Category {
+	name := strings.ToLower(filepath.Base(path))
+	ext := filepath.Ext(name)
+
+	switch {
+	case configNames[name]:
+		return Config
+	case strings.HasPrefix(name, ".") && strings.HasSuffix(name, "rc"),
+		strings.Contains(name, ".config."), strings.HasPrefix(name, ".env"):
+		return Config
+	case codeExts[ext]:
+		return Code
+	case configExts[ext]:
+		return Config
+	case docsExts[ext]:
+		return Docs
+	case dataExts[ext]:
+		return Data
+	}
+
+	for _, prefix := range docsPrefixes {
+		if strings.HasPrefix(name, prefix) {
+			return Docs
+		}
+	}
+
+	head := readHead(path, 512)
+	switch {
+	case ext == ".json":
+		// JSON holding records is data; small objects are usually settings
+		trimmed := bytes.TrimSpace(head)
+		if bytes.HasPrefix(trimmed, []byte("[")) {
+			return Data
+		}
+		return Config
+	case bytes.HasPrefix(head, []byte("#!")):
+		return Code
+	}
+	return Unknown
+}
+
+// Matches reports whether the file at path belongs to one of the named
+// categories, validated beforehand with Parse
+func Matches(path string, types []string) bool {
+	c := Classify(path)
+	for _, name := range types {
+		if strings.EqualFold(string(c), strings.TrimSpace(name)) {
+			return true
+		}
+	}
+	return false
+}
+
+// readHead returns up to n bytes from the start of the file at path
+func readHead(path string, n int) []byte {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil
+	}
+	defer file.Close()
+
+	buf := make([]byte, n)
+	read, _ := file.Read(buf)
+	return buf[:read]
+}
@@ -0,0 +1,34 @@
+package classify
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+// languages maps file extensions to the language they are written in
+var languages = map[string]string{
+	".go": "go", ".ts": "typescript", ".tsx": "typescript", ".js": "javascript", ".jsx": "javascript",
+	".mjs": "javascript", ".cjs": "javascript", ".py": "python", ".rb": "ruby", ".rs": "rust",
+	".java": "java", ".kt": "kotlin", ".kts": "kotlin", ".scala": "scala", ".c": "c", ".h": "c",
+	".cc": "cpp", ".cpp": "cpp", ".hpp": "cpp", ".cs": "csharp", ".fs": "fsharp", ".swift": "swift",
+	".m": "objective-c", ".php": "php", ".pl": "perl", ".lua": "lua", ".dart": "dart",
+	".ex": "elixir", ".exs": "elixir", ".erl": "erlang", ".hs": "haskell", ".clj": "clojure",
+	".elm": "elm", ".sh": "shell", ".bash": "shell", ".zsh": "shell", ".fish": "shell",
+	".ps1": "powershell", ".sql": "sql", ".vue": "vue", ".svelte": "svelte", ".html": "html",
+	".css": "css", ".scss": "scss", ".proto": "protobuf", ".graphql": "graphql", ".tf": "terraform",
+	".md": "markdown", ".markdown": "markdown", ".rst": "restructuredtext", ".json": "json",
+	".yaml": "yaml", ".yml": "yaml", ".toml": "toml", ".xml": "xml", ".ini": "ini",
+}
+
+// Language returns the language of the file at path judged by its
+// extension or well-known name, such as "go" or "python", or "" if unknown
+func Language(path string) string {
+	name := strings.ToLower(filepath.Base(path))
+	switch name {
+	case "dockerfile":
+		return "dockerfile"
+	case "makefile":
+		return "make"
+	}
+	return languages[filepath.Ext(name)]
+}
@@ -0,0 +1,196 @@
+package cli
+
+import (
+	"bufio"
+	"fcopy/internal/apply"
+	"fcopy/internal/config"
+	"fcopy/internal/utils"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"golang.design/x/clipboard"
+)
+
+// runApply implements "fcopy apply": it parses files out of the clipboard,
+// stdin ("-") or a file, previews the changes as diffs and writes them once
+// confirmed
+func runApply(cfg *config.Config, args []string) {
+	text, fromStdin, err := readApplyInput(args)
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+
+	files := apply.Parse(text)
+	if len(files) == 0 {
+		fmt.Println("No files found; expected -- path -- headers or code blocks naming their file")
+		os.Exit(1)
+	}
+
+	root := "."
+	if cfg.Cwd != "" {
+		root = utils.ExpandPath(cfg.Cwd, "")
+	}
+	root, err = filepath.Abs(root)
+	if err != nil {
+		fmt.Printf("Error resolving %s: %v\n", root, err)
+		os.Exit(1)
+	}
+
+	changes, skipped := apply.Plan(files, root)
+	for _, s := range skipped {
+		fmt.Printf("Skipping %s: %s\n", s.Name, s.Reason)
+	}
+	if len(changes) == 0 {
+		fmt.Println("Nothing to apply.")
+		return
+	}
+	for _, c := range changes {
+		fmt.Print(colorDiff(c.Diff()))
+	}
+
+	if !cfg.Yes {
+		if fromStdin {
+			fmt.Println("Input was read from stdin, which is needed for confirmation; rerun with --yes to apply")
+			os.Exit(1)
+		}
+		fmt.Printf("Write %d files? [y/N] ", len(changes))
+		answer, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+		if a := strings.ToLower(strings.TrimSpace(answer)); a != "y" && a != "yes" {
+			fmt.Println("Aborted, nothing was written.")
+			os.Exit(1)
+		}
+	}
+
+	if err := apply.Write(changes); err != nil {
+		fmt.Printf("Error writing files: %v\n", err)
+		os.Exit(1)
+	}
+	created := 0
+	for _, c := range changes {
+		if !c.Exists {
+			created++
+		}
+	}
+	fmt.Printf("Wrote %d files (%d new)\n", len(changes), created)
+}
+
+// runDiff implements "fcopy diff": it parses files out of the clipboard,
+// stdin ("-") or a file and shows how they differ from the files on disk
+func runDiff(cfg *config.Config, args []string) {
+	text, _, err := readApplyInput(args)
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+
+	files := apply.Parse(text)
+	if len(files) == 0 {
+		fmt.Println("No files found; expected -- path -- headers or code blocks naming their file")
+		os.Exit(1)
+	}
+
+	root := "."
+	if cfg.Cwd != "" {
+		root = utils.ExpandPath(cfg.Cwd, "")
+	}
+	root, err = filepath.Abs(root)
+	if err != nil {
+		fmt.Printf("Error resolving %s: %v\n", root, err)
+		os.Exit(1)
+	}
+
+	changes, skipped := apply.Plan(files, root)
+	unchanged := 0
+	for _, s := range skipped {
+		if s.Reason == "unchanged" {
+			unchanged++
+		} else {
+			fmt.Printf("Skipping %s: %s\n", s.Name, s.Reason)
+		}
+	}
+	created := 0
+	for _, c := range changes {
+		fmt.Print(colorDiff(c.Diff()))
+		if !c.Exists {
+			created++
+		}
+	}
+	fmt.Printf("%d files differ (%d new), %d unchanged\n", len(changes), created, unchanged)
+}
+
+// ANSI colors of diff lines
+const (
+	colorRed   = "\x1b[31m"
+	colorGreen = "\x1b[32m"
+	colorCyan  = "\x1b[36m"
+	colorBold  = "\x1b[1m"
+	colorReset = "\x1b[0m"
+)
+
+// colorDiff colors a unified diff like git does when stdout is a terminal
+// that supports it and NO_COLOR isn't set
+func colorDiff(patch string) string {
+	if !colorOutput() {
+		return patch
+	}
+	lines := strings.SplitAfter(patch, "\n")
+	for i, line := range lines {
+		text := strings.TrimSuffix(line, "\n")
+		if text == "" {
+			continue
+		}
+		color := ""
+		switch {
+		case strings.HasPrefix(text, "--- ") || strings.HasPrefix(text, "+++ "):
+			color = colorBold
+		case strings.HasPrefix(text, "@@"):
+			color = colorCyan
+		case text[0] == '-':
+			color = colorRed
+		case text[0] == '+':
+			color = colorGreen
+		}
+		if color != "" {
+			lines[i] = color + text + colorReset + line[len(text):]
+		}
+	}
+	return strings.Join(lines, "")
+}
+
+// colorOutput reports whether stdout is a terminal that understands colors
+func colorOutput() bool {
+	if _, ok := os.LookupEnv("NO_COLOR"); ok || dumbTerminal() {
+		return false
+	}
+	info, err := os.Stdout.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// readApplyInput reads the text to apply from the clipboard, stdin or a file
+func readApplyInput(args []string) (text string, fromStdin bool, err error) {
+	switch {
+	case len(args) > 1:
+		return "", false, fmt.Errorf("Usage: fcopy apply [options] [- | file]")
+	case len(args) == 1 && args[0] == "-":
+		data, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			return "", true, fmt.Errorf("Error reading stdin: %v", err)
+		}
+		return string(data), true, nil
+	case len(args) == 1:
+		data, err := os.ReadFile(args[0])
+		if err != nil {
+			return "", false, fmt.Errorf("Error reading %s: %v", args[0], err)
+		}
+		return string(data), false, nil
+	}
+
+	if err := initClipboard(); err != nil {
+		return "", false, fmt.Errorf("Failed to initialize clipboard: %v", err)
+	}
+	return string(clipboard.Read(clipboard.FmtText)), false, nil
+}
@@ -0,0 +1,75 @@
+package cli
+
+import (
+	"fcopy/internal/bookmark"
+	"fcopy/internal/config"
+	"fcopy/internal/utils"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// runBookmark implements "fcopy bookmark": it adds, removes and lists the
+// bookmarks "@name" arguments expand to
+func runBookmark(cfg *config.Config, args []string) {
+	usage := func() {
+		fmt.Println("Usage: fcopy bookmark add <name> <path> ...   save paths as @name")
+		fmt.Println("       fcopy bookmark rm <name>               forget @name")
+		fmt.Println("       fcopy bookmark list                    show all bookmarks")
+		os.Exit(1)
+	}
+	if len(args) == 0 {
+		usage()
+	}
+
+	marks, err := bookmark.Load(bookmark.DefaultPath())
+	if err != nil {
+		fmt.Printf("Error loading bookmarks: %v\n", err)
+		os.Exit(1)
+	}
+
+	switch args[0] {
+	case "add":
+		if len(args) < 3 {
+			usage()
+		}
+		name := strings.TrimPrefix(args[1], "@")
+		paths := make([]string, 0, len(args)-2)
+		for _, arg := range args[2:] {
+			path := utils.ExpandPath(arg, cfg.Cwd)
+			if _, err := os.Stat(path); err != nil {
+				fmt.Printf("Cannot bookmark %s: %v\n", path, err)
+				os.Exit(1)
+			}
+			paths = append(paths, path)
+		}
+		if err := marks.Add(name, paths...); err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		if err := marks.Save(); err != nil {
+			fmt.Printf("Error saving bookmarks: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("@%s: %s\n", name, strings.Join(marks.Paths[name], " "))
+	case "rm", "remove":
+		if len(args) != 2 {
+			usage()
+		}
+		name := strings.TrimPrefix(args[1], "@")
+		if !marks.Remove(name) {
+			fmt.Printf("No bookmark named %q\n", name)
+			os.Exit(1)
+		}
+		if err := marks.Save(); err != nil {
+			fmt.Printf("Error saving bookmarks: %v\n", err)
+			os.Exit(1)
+		}
+	case "list", "ls":
+		for _, name := range marks.Names() {
+			fmt.Printf("@%s: %s\n", name, strings.Join(marks.Paths[name], " "))
+		}
+	default:
+		usage()
+	}
+}
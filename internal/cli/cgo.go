@@ -0,0 +1,7 @@
+//go:build cgo
+
+package cli
+
+// cgoEnabled reports whether fcopy was built with cgo, which the clipboard
+// needs everywhere but on Windows
+const cgoEnabled = true
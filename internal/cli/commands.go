@@ -0,0 +1,156 @@
+package cli
+
+import (
+	"fcopy/internal/config"
+	"flag"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+)
+
+// command is a subcommand of fcopy. Flags are shared by all of them and
+// follow the subcommand's name.
+type command struct {
+	name    string
+	aliases []string
+	args    string // What follows the name in the usage line
+	summary string
+	words   []string // Arguments that only make sense after the name, such as "path" after config
+	run     func(cfg *config.Config, file *config.File, args []string)
+}
+
+// commands lists the subcommands in the order the usage shows them. The
+// first, copy, runs when no subcommand is named.
+var commands = []command{
+	{
+		name: "copy", args: "[options] <file1.ts> <folder/> ...", summary: "copy files and folders to the clipboard (the default)",
+		run: func(cfg *config.Config, file *config.File, args []string) { runCopy(cfg, file, args, "copy") },
+	},
+	{
+		name: "find", args: "[--json | --paths] [options] <query>", summary: "print fuzzy candidates without copying",
+		run: func(cfg *config.Config, _ *config.File, args []string) { runFind(cfg, args) },
+	},
+	{
+		name: "tree", args: "[options] <paths> ...", summary: "print a tree of the files a copy would include",
+		run: func(cfg *config.Config, file *config.File, args []string) { runCopy(cfg, file, args, "tree") },
+	},
+	{
+		name: "tokens", args: "[options] <paths> ...", summary: "count the tokens of the files a copy would include",
+		run: func(cfg *config.Config, file *config.File, args []string) { runCopy(cfg, file, args, "tokens") },
+	},
+	{
+		name: "serve", aliases: []string{"bridge"}, args: "[options] <paths> ...", summary: "serve the output in chunks over local HTTP",
+		run: func(cfg *config.Config, file *config.File, args []string) { runCopy(cfg, file, args, "serve") },
+	},
+	{
+		name: "apply", args: "[options] [- | file]", summary: "write files from the clipboard back to disk",
+		run: func(cfg *config.Config, _ *config.File, args []string) { runApply(cfg, args) },
+	},
+	{
+		name: "diff", args: "[options] [- | file]", summary: "diff files in the clipboard against disk",
+		run: func(cfg *config.Config, _ *config.File, args []string) { runDiff(cfg, args) },
+	},
+	{
+		name: "index", args: "[options] [dir] ...", summary: "build or refresh the fuzzy search index",
+		run: func(cfg *config.Config, _ *config.File, args []string) { runIndex(cfg, args) },
+	},
+	{
+		name: "daemon", args: "[options] [dir]", summary: "keep the fuzzy search index warm in memory",
+		run: func(cfg *config.Config, _ *config.File, args []string) { runDaemon(cfg, args) },
+	},
+	{
+		name: "bookmark", args: "add|rm|list ...", summary: "manage the paths @name arguments stand for", words: []string{"add", "rm", "list"},
+		run: func(cfg *config.Config, _ *config.File, args []string) { runBookmark(cfg, args) },
+	},
+	{
+		name: "config", args: "[path]", summary: "show the config file and the value of every flag", words: []string{"path"},
+		run: runConfig,
+	},
+	{
+		name: "doctor", args: "[options]", summary: "check the clipboard, config files, index and git, and how to fix them",
+		run: func(cfg *config.Config, _ *config.File, args []string) { runDoctor(cfg, args) },
+	},
+	{
+		name: "version", args: "", summary: "print the version, commit, build date and clipboard backend",
+		run: runVersion,
+	},
+}
+
+// lookupCommand returns the subcommand args start with, and the arguments
+// after its name. Without one it is copy, so "fcopy main.go" keeps working.
+// A name that is also a file or directory on disk is copied, as it was
+// before the subcommand existed, unless a flag, "--" or one of the
+// subcommand's words follows it; shadowed is then the name, so the caller
+// can point out the subcommand. "fcopy copy find" always copies.
+func lookupCommand(args []string) (cmd command, rest []string, shadowed string) {
+	if len(args) == 0 {
+		return commands[0], args, ""
+	}
+	for _, c := range commands {
+		if args[0] != c.name && !slices.Contains(c.aliases, args[0]) {
+			continue
+		}
+		rest = args[1:]
+		if _, err := os.Lstat(args[0]); err != nil {
+			return c, rest, ""
+		}
+		if len(rest) > 0 && (strings.HasPrefix(rest[0], "-") || slices.Contains(c.words, rest[0])) {
+			return c, rest, ""
+		}
+		return commands[0], args, args[0]
+	}
+	return commands[0], args, ""
+}
+
+// usage prints how to run fcopy and its subcommands, and the flags
+func usage() {
+	w := flag.CommandLine.Output()
+	fmt.Fprintf(w, "Usage: fcopy %s\n", commands[0].args)
+	for _, c := range commands {
+		fmt.Fprintf(w, "       %-48s %s\n", "fcopy "+c.name+" "+c.args, c.summary)
+	}
+	fmt.Fprintln(w, "\nOptions:")
+	flag.PrintDefaults()
+}
+
+// runConfig implements "fcopy config": it prints where the config files are
+// and the value of every flag once presets from it are applied, or just the
+// path with "fcopy config path"
+func runConfig(cfg *config.Config, file *config.File, args []string) {
+	path := config.UserConfigPath()
+	if len(args) == 1 && args[0] == "path" {
+		if path == "" {
+			fmt.Println("No configuration directory")
+			os.Exit(1)
+		}
+		fmt.Println(path)
+		return
+	} else if len(args) > 0 {
+		fmt.Println("Usage: fcopy config [path]")
+		os.Exit(1)
+	}
+
+	if _, err := os.Stat(path); path != "" && err == nil {
+		fmt.Printf("# Config file: %s\n", path)
+	} else {
+		fmt.Printf("# Config file: %s (not found)\n", path)
+	}
+	if file.Project != "" {
+		fmt.Printf("# Project config file: %s\n", file.Project)
+	}
+	if cfg.Preset != "" {
+		fmt.Printf("# Preset: %s\n", cfg.Preset)
+	}
+	if len(file.Sets) > 0 {
+		names := make([]string, 0, len(file.Sets))
+		for name := range file.Sets {
+			names = append(names, "@"+name)
+		}
+		slices.Sort(names)
+		fmt.Printf("# Sets: %s\n", strings.Join(names, ", "))
+	}
+	flag.VisitAll(func(f *flag.Flag) {
+		fmt.Printf("--%s=%s\n", f.Name, f.Value.String())
+	})
+}
@@ -0,0 +1,36 @@
+package cli
+
+import (
+	"context"
+	"fcopy/internal/config"
+	"fcopy/internal/finder"
+	"fcopy/internal/utils"
+	"fmt"
+	"os"
+	"os/signal"
+)
+
+// runDaemon implements "fcopy daemon": it keeps the fuzzy search index of the
+// project around the given directory, or the current one, warm in memory
+// until interrupted
+func runDaemon(cfg *config.Config, args []string) {
+	dir := "."
+	if len(args) > 1 {
+		fmt.Println("Usage: fcopy daemon [options] [dir]")
+		os.Exit(1)
+	} else if len(args) == 1 {
+		dir = args[0]
+	}
+	dir = utils.ExpandPath(dir, cfg.Cwd)
+	if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+		fmt.Printf("Cannot watch %s: not a directory\n", dir)
+		os.Exit(1)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	if err := finder.ServeDaemon(ctx, dir, cfg); err != nil {
+		fmt.Printf("Daemon failed: %v\n", err)
+		os.Exit(1)
+	}
+}
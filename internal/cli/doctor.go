@@ -0,0 +1,217 @@
+package cli
+
+import (
+	"encoding/json"
+	"errors"
+	"fcopy/internal/config"
+	"fcopy/internal/entrypoints"
+	"fcopy/internal/gitutil"
+	"fcopy/internal/index"
+	"fcopy/internal/utils"
+	"flag"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// check is the outcome of one of the checks of fcopy doctor
+type check struct {
+	name   string
+	status string // ok, warn or fail
+	detail string
+	fix    string // What to do about a warning or failure
+}
+
+// runDoctor implements "fcopy doctor": it checks the clipboard, the config
+// files, the fuzzy search index and git, printing how to fix what's wrong,
+// and exits with status 1 if anything failed
+func runDoctor(cfg *config.Config, args []string) {
+	if len(args) > 0 {
+		fmt.Println("Usage: fcopy doctor")
+		os.Exit(1)
+	}
+	root := entrypoints.ProjectRoot(utils.ExpandPath(cfg.Cwd, ""))
+
+	checks := []check{checkClipboard()}
+	checks = append(checks, checkConfig(root)...)
+	checks = append(checks, checkIndex(root), checkGit(root))
+
+	failed := false
+	for _, c := range checks {
+		fmt.Printf("[%-4s] %s: %s\n", c.status, c.name, c.detail)
+		if c.fix != "" {
+			fmt.Printf("       fix: %s\n", c.fix)
+		}
+		failed = failed || c.status == "fail"
+	}
+	if failed {
+		os.Exit(1)
+	}
+}
+
+// checkClipboard checks that the clipboard can be used, explaining why not
+// in the usual places it can't: builds without cgo, SSH sessions, Wayland
+// without XWayland and WSL
+func checkClipboard() check {
+	c := check{name: "clipboard"}
+	backend, ok := clipboardBackend()
+	if !ok {
+		c.status, c.detail = "fail", backend
+		c.fix = "build with CGO_ENABLED=1, or use --output, --send or fcopy serve"
+		return c
+	}
+	c.detail = backend
+
+	unix := runtime.GOOS != "windows" && runtime.GOOS != "darwin"
+	if unix && os.Getenv("DISPLAY") == "" {
+		c.status = "fail"
+		switch {
+		case os.Getenv("SSH_CONNECTION") != "" || os.Getenv("SSH_TTY") != "":
+			c.detail += "; this is an SSH session without X forwarding"
+			c.fix = "connect with ssh -X (or -Y), or write with --quiet --output /dev/stdout and copy locally, or use fcopy serve"
+		case os.Getenv("WAYLAND_DISPLAY") != "":
+			c.detail += "; this is a Wayland session without XWayland"
+			c.fix = "enable XWayland, or pipe --quiet --output /dev/stdout into wl-copy"
+		case isWSL():
+			c.detail += "; this is WSL without WSLg"
+			c.fix = "update WSL for WSLg (wsl --update), or pipe --quiet --output /dev/stdout into clip.exe"
+		default:
+			c.detail += "; DISPLAY isn't set"
+			c.fix = "run inside an X session, or start Xvfb and export DISPLAY, or use --output"
+		}
+		return c
+	}
+
+	if err := initClipboard(); err != nil {
+		c.status = "fail"
+		c.detail += "; " + strings.SplitN(err.Error(), "\n", 2)[0]
+		c.fix = "install libx11 (apt install libx11-6), or use --output"
+		if isWSL() {
+			c.fix = "update WSL for WSLg (wsl --update), or pipe --quiet --output /dev/stdout into clip.exe"
+		}
+		return c
+	}
+	c.status = "ok"
+	return c
+}
+
+// isWSL reports whether fcopy runs under the Windows Subsystem for Linux
+func isWSL() bool {
+	if os.Getenv("WSL_DISTRO_NAME") != "" {
+		return true
+	}
+	data, err := os.ReadFile("/proc/version")
+	return err == nil && strings.Contains(strings.ToLower(string(data)), "microsoft")
+}
+
+// checkConfig checks that the user config file and the config file of the
+// project at root parse, and set only flags that exist to values they accept
+func checkConfig(root string) []check {
+	fix := "correct the file; fcopy --help lists the flags"
+	user := check{name: "config", status: "ok", detail: config.UserConfigPath()}
+	if user.detail == "" {
+		user.detail = "no configuration directory; defaults apply"
+	} else if _, err := os.Stat(user.detail); errors.Is(err, fs.ErrNotExist) {
+		user.detail += " (not found; defaults apply)"
+	}
+	file, err := config.LoadFile(config.UserConfigPath())
+	if err != nil {
+		user.status, user.detail, user.fix = "fail", err.Error(), fix
+		return []check{user}
+	}
+	checks := []check{user}
+
+	project := filepath.Join(root, config.ProjectConfigName)
+	if err := file.MergeProject(project); err != nil {
+		return append(checks, check{name: "project config", status: "fail", detail: err.Error(), fix: fix})
+	} else if file.Project != "" {
+		checks = append(checks, check{name: "project config", status: "ok", detail: project})
+	}
+
+	// Options are checked by setting them on flags of their own
+	flags := func() *flag.FlagSet {
+		fs := flag.NewFlagSet("fcopy", flag.ContinueOnError)
+		config.RegisterFlags(fs)
+		return fs
+	}
+	c := check{name: "config options", status: "ok", detail: "defaults and presets are valid"}
+	if err := file.ApplyDefaults(flags()); err != nil {
+		c.status, c.detail = "fail", err.Error()
+	}
+	for _, name := range file.PresetNames() {
+		if c.status != "ok" {
+			break
+		}
+		if err := file.ApplyPreset(flags(), name); err != nil {
+			c.status, c.detail = "fail", err.Error()
+		}
+	}
+	if c.status == "fail" {
+		c.fix = fix
+	}
+	return append(checks, c)
+}
+
+// checkIndex checks that the fuzzy search index of the project at root can
+// be read and written
+func checkIndex(root string) check {
+	c := check{name: "index"}
+	path, err := index.CacheFile(root, ".json")
+	if err != nil {
+		c.status, c.detail = "warn", fmt.Sprintf("no cache directory: %v", err)
+		c.fix = "set XDG_CACHE_HOME, or pass --no-index"
+		return c
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		c.status, c.detail = "warn", fmt.Sprintf("cache directory isn't writable: %v", err)
+		c.fix = "fix the permissions of " + filepath.Dir(path) + ", or pass --no-index"
+		return c
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		c.status, c.detail = "ok", "none yet for "+root+"; it is built by the first fuzzy search"
+		return c
+	} else if err != nil {
+		c.status, c.detail = "warn", err.Error()
+		c.fix = "delete " + path
+		return c
+	}
+	var x index.Index
+	if err := json.Unmarshal(data, &x); err != nil || x.Root != root {
+		c.status, c.detail = "warn", path+" is corrupt or belongs to another project, so every search rescans"
+		c.fix = "run fcopy index to rebuild it"
+		return c
+	}
+	c.status, c.detail = "ok", fmt.Sprintf("%d directories of %s indexed in %s", len(x.Dirs), root, path)
+	return c
+}
+
+// checkGit checks that git is installed, which --changed, --git-only and
+// the tie-breaking of fuzzy matches need, and whether root is a repository
+func checkGit(root string) check {
+	c := check{name: "git"}
+	path, err := exec.LookPath("git")
+	if err != nil {
+		c.status, c.detail = "warn", "not installed; --changed, --git-only and --format diff won't work"
+		c.fix = "install git"
+		return c
+	}
+	out, err := exec.Command(path, "--version").Output()
+	if err != nil {
+		c.status, c.detail = "warn", fmt.Sprintf("%s doesn't run: %v", path, err)
+		c.fix = "reinstall git"
+		return c
+	}
+	c.status, c.detail = "ok", strings.TrimSpace(string(out))
+	if repo, err := gitutil.RepoRoot(root); err == nil {
+		c.detail += "; repository at " + repo
+	} else {
+		c.detail += "; " + root + " isn't in a repository"
+	}
+	return c
+}
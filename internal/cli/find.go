@@ -0,0 +1,84 @@
+package cli
+
+import (
+	"encoding/json"
+	"fcopy/internal/config"
+	"fcopy/internal/finder"
+	"fcopy/internal/utils"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// candidate is one fuzzy match as printed by "fcopy find --json"
+type candidate struct {
+	Path  string `json:"path"`
+	Score int    `json:"score"`
+	Type  string `json:"type"` // "file" or "dir"
+	Depth int    `json:"depth"`
+	Match string `json:"match"` // How the query matched, such as "exact" or "subsequence"
+}
+
+// runFind implements "fcopy find": it prints the fuzzy candidates for a
+// query, best first, without copying anything, so editor plugins and
+// scripts can offer their own choice among them. --first and --select
+// narrow them to the one the copy would resolve to.
+func runFind(cfg *config.Config, args []string) {
+	if len(args) == 0 {
+		fmt.Println("Usage: fcopy find [--json | --paths] [--first | --select N] [options] <query>")
+		os.Exit(1)
+	}
+	if cfg.JSON && cfg.PathsOnly {
+		fmt.Println("Use only one of --json and --paths")
+		os.Exit(1)
+	}
+	if cfg.PickFirst && cfg.Select != 0 {
+		fmt.Println("Use only one of --first and --select")
+		os.Exit(1)
+	}
+	query := utils.ExpandPath(strings.Join(args, " "), cfg.Cwd)
+
+	matches := finder.NewSearcher(cfg).Candidates(query)
+	switch {
+	case cfg.PickFirst:
+		matches = matches[:min(len(matches), 1)]
+	case cfg.Select > 0:
+		if cfg.Select > len(matches) {
+			fmt.Fprintf(os.Stderr, "--select %d is out of range for '%s': only %d candidates\n", cfg.Select, query, len(matches))
+			os.Exit(1)
+		}
+		matches = matches[cfg.Select-1 : cfg.Select]
+	case len(matches) > cfg.MaxMatches:
+		matches = matches[:cfg.MaxMatches]
+	}
+
+	candidates := make([]candidate, len(matches))
+	for i, match := range matches {
+		candidates[i] = candidate{Path: match.Path, Score: match.Score, Type: "file", Depth: match.Depth, Match: match.MatchType}
+		if match.IsDir {
+			candidates[i].Type = "dir"
+		}
+	}
+
+	if cfg.JSON {
+		data, err := json.MarshalIndent(candidates, "", "  ")
+		if err != nil {
+			fmt.Printf("Error encoding candidates: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Println(string(data))
+	} else if cfg.PathsOnly {
+		for _, c := range candidates {
+			fmt.Println(c.Path)
+		}
+	} else {
+		for _, c := range candidates {
+			fmt.Printf("%s\t%d\t%s\n", c.Type, c.Score, c.Path)
+		}
+	}
+
+	// Like grep, finding nothing is a failure scripts can test for
+	if len(candidates) == 0 {
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,32 @@
+package cli
+
+import (
+	"fcopy/internal/config"
+	"fcopy/internal/finder"
+	"fcopy/internal/utils"
+	"fmt"
+	"os"
+)
+
+// runIndex implements "fcopy index": it builds or refreshes the fuzzy search
+// index of the project around each given directory, or the current one
+func runIndex(cfg *config.Config, args []string) {
+	if len(args) == 0 {
+		args = []string{"."}
+	}
+	for _, arg := range args {
+		dir := utils.ExpandPath(arg, cfg.Cwd)
+		if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+			fmt.Printf("Cannot index %s: not a directory\n", dir)
+			os.Exit(1)
+		}
+
+		x, count, err := finder.BuildIndex(dir, cfg)
+		if err != nil {
+			fmt.Printf("Error indexing %s: %v\n", dir, err)
+			os.Exit(1)
+		}
+		fmt.Printf("Indexed %d files and directories under %s (%d of %d directories read)\n",
+			count, x.Root, x.Reads(), len(x.Dirs))
+	}
+}
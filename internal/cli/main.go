@@ -0,0 +1,977 @@
+package cli
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fcopy/internal/bookmark"
+	"fcopy/internal/bridge"
+	"fcopy/internal/charset"
+	"fcopy/internal/classify"
+	"fcopy/internal/collector"
+	"fcopy/internal/config"
+	"fcopy/internal/deps"
+	"fcopy/internal/entrypoints"
+	"fcopy/internal/finder"
+	"fcopy/internal/gitutil"
+	"fcopy/internal/hooks"
+	"fcopy/internal/manifest"
+	"fcopy/internal/output"
+	"fcopy/internal/processor"
+	"fcopy/internal/review"
+	"fcopy/internal/selection"
+	"fcopy/internal/send"
+	"fcopy/internal/split"
+	"fcopy/internal/tokens"
+	"fcopy/internal/utils"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"golang.design/x/clipboard"
+)
+
+// Exit codes of copying, for scripts and hooks. Other failures, such as bad
+// flags, exit with exitError too.
+const (
+	exitError     = 1 // Files couldn't be read or paths resolved
+	exitNothing   = 2 // Nothing was copied
+	exitClipboard = 3 // The clipboard couldn't be used
+	exitAmbiguous = 4 // A fuzzy match was ambiguous with --non-interactive
+)
+
+// Main runs the fcopy command with the arguments in os.Args and exits
+// with its status
+func Main() {
+	// Load configuration
+	cfg := config.LoadConfig()
+
+	// Parse flags, which follow the subcommand if there is one, after those in
+	// FCOPY_OPTS so the command line wins
+	cmd, args, shadowed := lookupCommand(os.Args[1:])
+	flag.Usage = usage
+	if opts := os.Getenv(config.EnvOpts); opts != "" {
+		optsArgs, err := config.OptsArgs(opts)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		flag.CommandLine.Parse(optsArgs)
+		if flag.NArg() > 0 {
+			fmt.Printf("%s may only hold flags, not %q\n", config.EnvOpts, flag.Arg(0))
+			os.Exit(1)
+		}
+	}
+	flag.CommandLine.Parse(args)
+	if cfg.Version {
+		runVersion(cfg, nil, nil)
+		return
+	}
+
+	// Flags that weren't given come from FCOPY_* variables, then the preset,
+	// then the defaults of the project and user config files
+	if err := config.ApplyEnv(flag.CommandLine); err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+	// fcopy doctor diagnoses the config files, so it loads them itself
+	if cmd.name == "doctor" {
+		cmd.run(cfg, nil, flag.Args())
+		return
+	}
+	file, err := config.LoadFile(config.UserConfigPath())
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+	root := entrypoints.ProjectRoot(utils.ExpandPath(cfg.Cwd, ""))
+	if err := file.MergeProject(filepath.Join(root, config.ProjectConfigName)); err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+	if cfg.Preset != "" {
+		if err := file.ApplyPreset(flag.CommandLine, cfg.Preset); err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+	}
+	if err := file.ApplyDefaults(flag.CommandLine); err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+	file.AddIgnores()
+	cfg.Scoring = &file.Fuzzy
+	if cfg.SummaryJSON {
+		cfg.Quiet = true
+	}
+	if cfg.Quiet {
+		cfg.Verbose = false
+	}
+	if shadowed != "" {
+		notef(cfg, "Copying %s, which is also a subcommand; run \"fcopy %s --\" for the subcommand\n", shadowed, shadowed)
+	}
+
+	if err := cfg.OpenLog(); err != nil {
+		fmt.Printf("Warning: Could not create debug log file: %v\n", err)
+	}
+	if cfg.LogFile != nil {
+		defer cfg.LogFile.Close()
+	}
+
+	checkSearchFlags(cfg)
+	cmd.run(cfg, file, flag.Args())
+}
+
+// checkSearchFlags validates the flags of fuzzy matching, which every
+// subcommand resolving paths shares
+func checkSearchFlags(cfg *config.Config) {
+	if cfg.Cwd != "" {
+		if info, err := os.Stat(utils.ExpandPath(cfg.Cwd, "")); err != nil || !info.IsDir() {
+			fmt.Printf("Invalid --cwd %s: not a directory\n", cfg.Cwd)
+			os.Exit(1)
+		}
+	}
+
+	if !slices.Contains(finder.Pickers, cfg.Picker) {
+		fmt.Printf("Unknown --picker %q (expected one of: %s)\n", cfg.Picker, strings.Join(finder.Pickers, ", "))
+		os.Exit(1)
+	}
+	if !slices.Contains(finder.CaseModes, cfg.Case) {
+		fmt.Printf("Unknown --case %q (expected one of: %s)\n", cfg.Case, strings.Join(finder.CaseModes, ", "))
+		os.Exit(1)
+	}
+
+	if cfg.OnlyFiles && cfg.OnlyDirs {
+		fmt.Println("Use only one of --only-files and --only-dirs")
+		os.Exit(1)
+	}
+
+	// Scripts, hooks and editors can't answer the prompt
+	policies := 0
+	for _, set := range []bool{cfg.PickFirst, cfg.Select != 0, cfg.Strict} {
+		if set {
+			policies++
+		}
+	}
+	if policies > 1 {
+		fmt.Println("Use only one of --first, --select and --strict")
+		os.Exit(1)
+	}
+	if cfg.Select < 0 {
+		fmt.Printf("Invalid --select %d: candidates are numbered from 1\n", cfg.Select)
+		os.Exit(1)
+	}
+	if policies > 0 {
+		cfg.NonInteractive = true
+	}
+}
+
+// runCopy implements "fcopy copy", the default: it resolves paths, collects
+// the files under them and copies them to the clipboard. mode "serve" serves
+// them over local HTTP instead, "tree" only prints a tree of them and
+// "tokens" only counts their tokens.
+func runCopy(cfg *config.Config, file *config.File, paths []string, mode string) {
+	start := time.Now()
+	var err error
+
+	// Editors can hand over their selection through the environment
+	var selected []selection.Entry
+	if cfg.FromEnv {
+		selected, err = selection.FromEnv()
+		if err != nil {
+			fmt.Printf("Error reading selection: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	// Paths can also be piped in from tools like fd, rg -l or git diff --name-only
+	var listed []string
+	if cfg.FilesFrom != "" {
+		listed, err = utils.ReadFileList(cfg.FilesFrom, cfg.NullSeparated)
+		if err != nil {
+			fmt.Printf("Error reading file list %s: %v\n", cfg.FilesFrom, err)
+			os.Exit(1)
+		}
+	}
+
+	if len(paths) == 0 && len(selected) == 0 && len(listed) == 0 && cfg.Changed == "" && !cfg.Entrypoints {
+		flag.Usage()
+		os.Exit(1)
+	}
+
+	if cfg.Truncate != "" {
+		if _, err := processor.ParseTruncation(cfg.Truncate); err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+	}
+
+	if !slices.Contains(processor.BinaryModes, cfg.Binary) {
+		fmt.Printf("Unknown --binary mode %q (expected one of: %s)\n", cfg.Binary, strings.Join(processor.BinaryModes, ", "))
+		os.Exit(1)
+	}
+
+	// --fit sizes the token budget to a model's context window
+	if cfg.Fit != "" {
+		window, ok := tokens.ContextWindows[strings.ToLower(cfg.Fit)]
+		if !ok {
+			fmt.Printf("Unknown model %q for --fit (expected one of: %s)\n", cfg.Fit, strings.Join(tokens.ModelNames(), ", "))
+			os.Exit(1)
+		}
+		if cfg.Model == "" {
+			cfg.Model = cfg.Fit
+		}
+		if cfg.MaxTokens == 0 {
+			cfg.MaxTokens = window
+		}
+	}
+
+	if err := tokens.SetModel(cfg.Model); err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+
+	if err := charset.Lookup(cfg.Encoding); err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+
+	if _, err := classify.Parse(cfg.Types); err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+
+	if err := output.Validate(cfg); err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+
+	if cfg.Send && cfg.SendModel == "" {
+		fmt.Println("--send requires --send-model")
+		os.Exit(1)
+	}
+
+	// Load the prompt template up front so mistakes surface before the walk
+	var prompt *output.Prompt
+	if cfg.PromptTemplate != "" {
+		prompt, err = output.LoadPrompt(utils.ExpandPath(cfg.PromptTemplate, ""), cfg.Vars)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+	} else if len(cfg.Vars) > 0 {
+		fmt.Println("--var requires --prompt-template")
+		os.Exit(1)
+	}
+
+	// Report which rules apply to the given paths instead of copying them
+	if cfg.Explain {
+		for _, path := range paths {
+			explain(utils.ExpandPath(path, cfg.Cwd), cfg)
+		}
+		return
+	}
+
+	// --send only copies the reply, which it can also just print
+	clipboardReady := false
+	if mode == "copy" && !cfg.DryRun && cfg.OutputPath == "" {
+		err = initClipboard()
+		if err != nil && !cfg.Send {
+			fmt.Printf("Failed to initialize clipboard: %v\n", err)
+			os.Exit(exitClipboard)
+		}
+		clipboardReady = err == nil
+	}
+
+	// Pre-copy hooks from the config file can prepare the tree, or veto the copy
+	if mode == "copy" && !cfg.DryRun {
+		for _, command := range file.Hooks.Pre {
+			if err := hooks.RunPre(command, cfg.HookTimeout); err != nil {
+				fmt.Printf("Pre-copy hook %q failed, nothing was copied: %v\n", command, err)
+				os.Exit(exitError)
+			}
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.Timeout)
+	defer cancel()
+
+	resolvedPaths := make([]string, 0, len(paths))
+	origins := make([]processor.Origin, 0, len(paths))
+	// Paths that can't be resolved count as errors like unreadable files
+	tracker := &processor.Tracker{}
+
+	// First, resolve all paths with fuzzy matching if needed; paths searched
+	// under the same directory share one scan of it
+	searcher := finder.NewSearcher(cfg)
+	resolve := func(cleanPath string, origin processor.Origin) {
+		// Check if path exists
+		if _, err := os.Stat(cleanPath); err != nil {
+			if os.IsNotExist(err) {
+				// Path doesn't exist, try fuzzy matching
+				matched, found := searcher.FindPath(cleanPath)
+				if found {
+					for _, resolvedPath := range matched {
+						resolvedPaths = append(resolvedPaths, resolvedPath)
+						origins = append(origins, origin.Then(processor.RuleFuzzy))
+					}
+				} else if cfg.NonInteractive && !cfg.PickFirst && cfg.Select == 0 {
+					fmt.Printf("Error: %s doesn't exist and no unambiguous match was found\n", cleanPath)
+					os.Exit(exitAmbiguous)
+				} else {
+					notef(cfg, "Warning: Skipping %s as no good match was found\n", cleanPath)
+					tracker.Errors.Add(1)
+				}
+			} else {
+				fmt.Printf("Error accessing %s: %v\n", cleanPath, err)
+				tracker.Errors.Add(1)
+			}
+		} else {
+			// Path exists, use it as-is
+			resolvedPaths = append(resolvedPaths, cleanPath)
+			origins = append(origins, origin.Then(processor.RuleExplicit))
+		}
+	}
+	var marks *bookmark.Bookmarks
+	var stdinFiles []processor.FileContent
+	for _, path := range paths {
+		// "-" bundles whatever is piped into fcopy as a pseudo-file
+		if path == "-" {
+			if cfg.FilesFrom == "-" {
+				fmt.Println("Cannot read both content (-) and --files-from - from stdin")
+				os.Exit(1)
+			}
+			data, err := io.ReadAll(os.Stdin)
+			if err != nil {
+				fmt.Printf("Error reading stdin: %v\n", err)
+				os.Exit(1)
+			}
+			data, _ = charset.ToUTF8(data, cfg.Encoding)
+			text, _ := utils.RepairUTF8(data)
+			stdinFiles = append(stdinFiles, processor.FileContent{
+				Path:    cfg.StdinLabel,
+				Content: text,
+				Origin:  processor.Origin{Arg: path, Rule: processor.RuleStdin, Index: len(resolvedPaths)},
+			})
+			continue
+		}
+
+		// "@name" stands for the paths in the set or bookmark called name,
+		// unless there is such a file, as in node_modules/@types
+		if _, err := os.Stat(utils.ExpandPath(path, cfg.Cwd)); strings.HasPrefix(path, "@") && err != nil {
+			// Named sets from the config file come first
+			if set, ok := file.Sets[path[1:]]; ok {
+				entries := make([]string, len(set))
+				for i, entry := range set {
+					entries[i] = utils.ExpandPath(entry, cfg.Cwd)
+				}
+				expanded, err := bookmark.ExpandSet(entries, func(path string, isDir bool) bool {
+					return finder.ShouldIgnore(path, isDir, cfg)
+				})
+				if err != nil {
+					fmt.Printf("Error expanding set %s: %v\n", path, err)
+					os.Exit(1)
+				}
+				if len(expanded) == 0 {
+					notef(cfg, "Warning: Set %s matches no files\n", path)
+				}
+				for _, target := range expanded {
+					resolve(target, processor.Origin{Arg: path, Rule: processor.RuleSet})
+				}
+				continue
+			}
+
+			if marks == nil {
+				if marks, err = bookmark.Load(bookmark.DefaultPath()); err != nil {
+					fmt.Printf("Error loading bookmarks: %v\n", err)
+					os.Exit(1)
+				}
+			}
+			if expanded, ok, err := marks.Expand(path); ok {
+				if err != nil {
+					fmt.Println(err)
+					os.Exit(1)
+				}
+				for _, target := range expanded {
+					resolve(target, processor.Origin{Arg: path, Rule: processor.RuleBookmark})
+				}
+				continue
+			}
+		}
+
+		// Remove quotes and expand ~, $VARS and --cwd
+		cleanPath := utils.ExpandPath(path, cfg.Cwd)
+		resolve(cleanPath, processor.Origin{Arg: path})
+	}
+
+	// Selected paths come from an editor and are used as-is, never fuzzy matched
+	lineRanges := make(map[string][]processor.LineRange)
+	for _, entry := range selected {
+		cleanPath := utils.ExpandPath(entry.Path, cfg.Cwd)
+		if _, err := os.Stat(cleanPath); err != nil {
+			fmt.Printf("Error accessing %s: %v\n", cleanPath, err)
+			tracker.Errors.Add(1)
+			continue
+		}
+		resolvedPaths = append(resolvedPaths, cleanPath)
+		origins = append(origins, processor.Origin{Arg: entry.Path, Rule: processor.RuleSelection})
+		if len(entry.Ranges) > 0 {
+			lineRanges[cleanPath] = append(lineRanges[cleanPath], entry.Ranges...)
+		}
+	}
+
+	// Listed paths come from other tools and are used as-is
+	for _, path := range listed {
+		cleanPath := utils.ExpandPath(path, cfg.Cwd)
+		if _, err := os.Stat(cleanPath); err != nil {
+			fmt.Printf("Error accessing %s: %v\n", cleanPath, err)
+			tracker.Errors.Add(1)
+			continue
+		}
+		resolvedPaths = append(resolvedPaths, cleanPath)
+		origins = append(origins, processor.Origin{Arg: cfg.FilesFrom, Rule: processor.RuleFileList})
+	}
+
+	// Files changed in a git range are used as-is
+	if cfg.Changed != "" {
+		changed, err := gitutil.ChangedFiles(gitDir(cfg), cfg.Changed)
+		if err != nil {
+			fmt.Printf("Error listing changed files: %v\n", err)
+			os.Exit(1)
+		}
+		for _, path := range changed {
+			if cfg.Cwd != "" {
+				path = filepath.Join(utils.ExpandPath(cfg.Cwd, ""), path)
+			}
+			resolvedPaths = append(resolvedPaths, path)
+			origins = append(origins, processor.Origin{Arg: cfg.Changed, Rule: processor.RuleChanged})
+		}
+	}
+
+	// Entry points give a default selection for explaining how a project starts
+	if cfg.Entrypoints {
+		root := gitDir(cfg)
+		for _, match := range entrypoints.Detect(root) {
+			resolvedPaths = append(resolvedPaths, match.Path)
+			origins = append(origins, processor.Origin{Arg: root, Rule: processor.RuleEntry + ": " + match.Kind})
+		}
+	}
+
+	// Local packages imported by the selection come along with it
+	if cfg.FollowImports {
+		for _, match := range deps.Follow(resolvedPaths, cfg.ImportDepth) {
+			resolvedPaths = append(resolvedPaths, match.Path)
+			origins = append(origins, processor.Origin{Arg: match.Import, Rule: fmt.Sprintf("%s (depth %d)", processor.RuleImport, match.Depth)})
+		}
+	}
+
+	// Project metadata goes first, so the reader knows what it is looking at
+	if cfg.WithMeta {
+		root := gitDir(cfg)
+		var metaPaths []string
+		var metaOrigins []processor.Origin
+		for _, match := range entrypoints.Meta(root) {
+			metaPaths = append(metaPaths, match.Path)
+			metaOrigins = append(metaOrigins, processor.Origin{Arg: root, Rule: processor.RuleMeta})
+		}
+		resolvedPaths = append(metaPaths, resolvedPaths...)
+		origins = append(metaOrigins, origins...)
+	}
+
+	if cfg.Review && (len(stdinFiles) > 0 || cfg.FilesFrom == "-") {
+		fmt.Println("Cannot use --review while reading from stdin, which it needs for its prompt")
+		os.Exit(1)
+	}
+
+	if len(resolvedPaths) == 0 && len(stdinFiles) == 0 {
+		fmt.Println("No valid paths to process.")
+		os.Exit(exitNothing)
+	}
+
+	// Show progress periodically. Dumb terminals such as Emacs shells can't
+	// redraw a line, so they get an occasional plain line instead.
+	plainProgress := dumbTerminal()
+	progressDone := make(chan struct{})
+	progressStopped := make(chan struct{})
+	if cfg.Verbose {
+		go func() {
+			defer close(progressStopped)
+			interval := 200 * time.Millisecond
+			if plainProgress {
+				interval = 2 * time.Second
+			}
+			ticker := time.NewTicker(interval)
+			defer ticker.Stop()
+			last := int64(-1)
+			for {
+				select {
+				case <-ticker.C:
+					processed := tracker.Processed.Load()
+					if !plainProgress {
+						fmt.Printf("\rProcessed: %d files", processed)
+					} else if processed != last {
+						fmt.Printf("Processed: %d files\n", processed)
+					}
+					last = processed
+				case <-progressDone:
+					return
+				case <-ctx.Done():
+					return
+				}
+			}
+		}()
+	}
+
+	// Process each resolved path in parallel
+	included := append(stdinFiles, collector.Collect(ctx, resolvedPaths, origins, cfg, tracker)...)
+
+	close(progressDone)
+	if cfg.Verbose {
+		<-progressStopped
+		if !plainProgress {
+			fmt.Println() // New line after progress indicator
+		}
+	}
+
+	// Narrow selected files down to the requested lines
+	for i, result := range included {
+		if ranges, ok := lineRanges[result.Path]; ok {
+			included[i].Content = processor.ExtractLines(result.Content, ranges)
+			included[i].Notes = append(included[i].Notes, "lines "+processor.FormatLineRanges(ranges))
+		}
+	}
+
+	// Replace changed files with their diffs
+	if cfg.Format == "diff" {
+		collector.Diffs(included, gitDir(cfg), cfg.Changed, tracker)
+	}
+
+	included = collector.Finish(included, cfg)
+	if cfg.Symbol != "" && len(included) == 0 {
+		fmt.Printf("No function, method or type named %s was found in the given files\n", cfg.Symbol)
+		os.Exit(1)
+	}
+
+	// Keep the output within --max-total-size, which some clipboards need
+	included, overBudget := collector.Budget(included, cfg.MaxTotalSize, collector.Size)
+	for _, result := range overBudget {
+		if cfg.Logger != nil {
+			cfg.Logger.Printf("Omitted %s: over --max-total-size", result.Path)
+		}
+	}
+
+	// Keep the output within the model's context window
+	included, overTokens := collector.Budget(included, int64(cfg.MaxTokens), collector.Tokens)
+	for _, result := range overTokens {
+		if cfg.Logger != nil {
+			cfg.Logger.Printf("Omitted %s: over --max-tokens", result.Path)
+		}
+		if n := collector.Tokens(result); !result.Origin.Discovered && n > int64(cfg.MaxTokens) {
+			notef(cfg, "WARNING: %s alone is %d tokens, more than the whole --max-tokens budget of %d\n",
+				result.Path, n, cfg.MaxTokens)
+		}
+	}
+
+	// Let the user leave files out before anything is copied
+	if cfg.Review {
+		var confirmed bool
+		included, confirmed = review.Run(included, os.Stdin, os.Stdout)
+		if !confirmed {
+			fmt.Println("Aborted, nothing was copied.")
+			os.Exit(1)
+		}
+	}
+
+	if errors := tracker.Errors.Load(); errors > 0 && cfg.FailOnError {
+		fmt.Printf("Nothing was copied as %d errors occurred (--fail-on-error)\n", errors)
+		os.Exit(exitError)
+	}
+
+	// Output streams into its destination as it is formatted; only serving,
+	// sending and splitting need all of it at once
+	var sink output.Sink
+	var text strings.Builder
+	switch {
+	case cfg.DryRun || mode == "tree" || mode == "tokens":
+		sink = output.WriterSink(io.Discard)
+	case cfg.OutputPath != "":
+		sink = &output.FileSink{Path: cfg.OutputPath}
+	case mode == "serve" || cfg.Send || cfg.Split:
+		sink = output.WriterSink(&text)
+	default:
+		sink = &output.BufferSink{Deliver: func(data []byte) error {
+			clipboard.Write(clipboard.FmtText, data)
+			return nil
+		}}
+	}
+	// Post-copy hooks get the output in a file, which --output already is
+	postCopy := append(slices.Clone(cfg.PostCopy), file.Hooks.Post...)
+	bundlePath := cfg.OutputPath
+	out := io.Writer(sink)
+	var bundleFile *os.File
+	if len(postCopy) > 0 && bundlePath == "" && !cfg.DryRun && mode == "copy" {
+		if bundleFile, err = os.CreateTemp("", "fcopy-bundle-*.txt"); err != nil {
+			fmt.Printf("Error creating the bundle file for hooks: %v\n", err)
+		} else {
+			bundlePath = bundleFile.Name()
+			out = io.MultiWriter(sink, bundleFile)
+		}
+	}
+	meter := &output.Meter{W: out}
+	count := len(included)
+	if err := writeBundle(meter, included, overBudget, overTokens, prompt, cfg); err != nil {
+		fmt.Printf("Error writing output: %v\n", err)
+		os.Exit(1)
+	}
+	if meter.Bytes > 0 {
+		if err := sink.Close(); err != nil {
+			fmt.Printf("Error writing output: %v\n", err)
+			os.Exit(1)
+		}
+	}
+	if bundleFile != nil {
+		bundleFile.Close()
+	}
+	size := formatSize(meter.Bytes, meter.Tokens, cfg)
+
+	// Verify we have content to copy
+	copied := false
+	if meter.Bytes == 0 {
+		notef(cfg, "No content was found to copy!\n")
+	} else if cfg.DryRun {
+		for _, result := range included {
+			fmt.Printf("%s (%d bytes, %s)\n", result.Path, len(result.Content), tokens.Format(tokens.Count(result.Content)))
+		}
+		fmt.Printf("Would copy content from %d files (%s)\n", count, size)
+	} else if cfg.OutputPath != "" {
+		notef(cfg, "Wrote content from %d files to %s (%s)\n", count, cfg.OutputPath, size)
+		copied = true
+	} else if mode == "tree" {
+		tree := make([]string, len(included))
+		for i, result := range included {
+			tree[i] = filepath.ToSlash(result.Path)
+		}
+		fmt.Print(output.Tree(tree))
+	} else if mode == "tokens" {
+		for _, result := range included {
+			fmt.Printf("%8d  %s\n", tokens.Count(result.Content), result.Path)
+		}
+		fmt.Printf("%8d  total (%s)\n", meter.Tokens, size)
+	} else if mode == "serve" {
+		chunks := bridge.Chunk(text.String(), cfg.ChunkSize)
+		notef(cfg, "Collected content from %d files (%d bytes)\n", count, meter.Bytes)
+
+		serveCtx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+		defer stop()
+		if err := bridge.Serve(serveCtx, cfg.BridgeAddr, chunks, cfg.BridgeIdle); err != nil {
+			fmt.Printf("Bridge failed: %v\n", err)
+			os.Exit(1)
+		}
+	} else if cfg.Send {
+		copied = sendPrompt(text.String(), count, cfg, clipboardReady)
+	} else if cfg.Split {
+		copied = copyParts(text.String(), count, cfg)
+	} else {
+		notef(cfg, "Copied content from %d files to clipboard (%s)\n", count, size)
+		copied = true
+	}
+
+	if cfg.ManifestPath != "" {
+		if err := manifest.Build(included).Write(cfg.ManifestPath); err != nil {
+			fmt.Printf("Error writing manifest %s: %v\n", cfg.ManifestPath, err)
+		}
+	}
+
+	if copied && len(postCopy) > 0 {
+		runPostCopyHooks(postCopy, included, meter, bundlePath, cfg)
+	}
+	if bundleFile != nil {
+		os.Remove(bundleFile.Name())
+	}
+
+	if tracker.LimitReached() {
+		notef(cfg, " (stopped at --max-files %d; later files were left out)\n", cfg.MaxFiles)
+	}
+	if len(overBudget) > 0 {
+		notef(cfg, " (%d files left out by --max-total-size %d)\n", len(overBudget), cfg.MaxTotalSize)
+	}
+	if len(overTokens) > 0 {
+		notef(cfg, " (%d files left out by --max-tokens %d)\n", len(overTokens), cfg.MaxTokens)
+	}
+	longLines := 0
+	for _, result := range included {
+		for _, note := range result.Notes {
+			if strings.HasSuffix(note, fmt.Sprintf(" cut at %d bytes", cfg.MaxLineLength)) {
+				longLines++
+			}
+		}
+	}
+	if longLines > 0 {
+		notef(cfg, " (%d files had lines cut by --max-line-length %d)\n", longLines, cfg.MaxLineLength)
+	}
+	if skipped := tracker.Skipped.Load(); skipped > 0 {
+		notef(cfg, " (%d files skipped, use --verbose for details)\n", skipped)
+	}
+	if errors := tracker.Errors.Load(); errors > 0 {
+		fmt.Printf(" (%d errors occurred)\n", errors)
+	}
+
+	if cfg.SummaryJSON {
+		printSummary(runSummary{
+			Files:      count,
+			Bytes:      meter.Bytes,
+			Tokens:     meter.Tokens,
+			Copied:     copied,
+			Skipped:    tracker.Skips(),
+			Omitted:    int(tracker.Omitted.Load()) + len(overBudget) + len(overTokens),
+			Errors:     int(tracker.Errors.Load()),
+			DurationMS: time.Since(start).Milliseconds(),
+		})
+	}
+
+	if meter.Bytes == 0 {
+		os.Exit(exitNothing)
+	} else if tracker.Errors.Load() > 0 {
+		os.Exit(exitError)
+	}
+}
+
+// initClipboard initializes the clipboard, failing rather than panicking in
+// builds without one
+func initClipboard() error {
+	if backend, ok := clipboardBackend(); !ok {
+		return fmt.Errorf("no clipboard support: %s", backend)
+	}
+	return clipboard.Init()
+}
+
+// notef prints an informational message, which --quiet suppresses
+func notef(cfg *config.Config, format string, args ...any) {
+	if !cfg.Quiet {
+		fmt.Printf(format, args...)
+	}
+}
+
+// runSummary is what --summary-json prints at the end of a run
+type runSummary struct {
+	Files      int              `json:"files"`
+	Bytes      int64            `json:"bytes"`
+	Tokens     int              `json:"tokens"`
+	Copied     bool             `json:"copied"`
+	Skipped    []processor.Skip `json:"skipped"`
+	Omitted    int              `json:"omitted"` // Left out by --max-files, --max-total-size and --max-tokens
+	Errors     int              `json:"errors"`
+	DurationMS int64            `json:"duration_ms"`
+}
+
+// printSummary prints summary as one line of JSON
+func printSummary(summary runSummary) {
+	if summary.Skipped == nil {
+		summary.Skipped = []processor.Skip{}
+	}
+	data, err := json.Marshal(summary)
+	if err != nil {
+		fmt.Printf("Error encoding the summary: %v\n", err)
+		return
+	}
+	fmt.Println(string(data))
+}
+
+// writeBundle formats files to w, followed by the lists of files left out by
+// --max-total-size and --max-tokens, all wrapped in the prompt template if
+// there is one
+func writeBundle(w io.Writer, files, overBudget, overTokens []processor.FileContent, prompt *output.Prompt, cfg *config.Config) error {
+	// The template needs the formatted files as one string
+	out := w
+	var bundle strings.Builder
+	if prompt != nil {
+		out = &bundle
+	}
+	if err := output.Write(out, files, cfg); err != nil {
+		return fmt.Errorf("error formatting output: %v", err)
+	}
+	if err := output.WriteOmitted(out, overBudget, "--max-total-size", cfg); err != nil {
+		return fmt.Errorf("error formatting output: %v", err)
+	}
+	if err := output.WriteOmitted(out, overTokens, "--max-tokens", cfg); err != nil {
+		return fmt.Errorf("error formatting output: %v", err)
+	}
+	if prompt == nil || bundle.Len() == 0 {
+		return nil
+	}
+	return prompt.Render(w, bundle.String(), files, time.Now())
+}
+
+// sendPrompt sends text to the --send endpoint, prints the reply and copies
+// it to the clipboard if there is one
+func sendPrompt(text string, files int, cfg *config.Config, copyReply bool) bool {
+	apiKey := os.Getenv("FCOPY_API_KEY")
+	if apiKey == "" {
+		apiKey = os.Getenv("OPENAI_API_KEY")
+	}
+	notef(cfg, "Sending content from %d files (%s) to %s at %s...\n", files, sizeSummary(text, cfg), cfg.SendModel, cfg.SendURL)
+
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.SendTimeout)
+	defer cancel()
+	reply, err := send.Chat(ctx, send.Options{BaseURL: cfg.SendURL, Model: cfg.SendModel, APIKey: apiKey}, text)
+	if err != nil {
+		fmt.Printf("Error sending to %s: %v\n", cfg.SendURL, err)
+		os.Exit(1)
+	}
+
+	fmt.Println(reply)
+	if copyReply {
+		clipboard.Write(clipboard.FmtText, []byte(reply))
+		notef(cfg, "Copied the reply to clipboard (%s)\n", sizeSummary(reply, cfg))
+	}
+	return true
+}
+
+// copyParts splits text into numbered parts of at most --part-tokens tokens,
+// or --chunk-size bytes, overlapping by --overlap lines, and copies them to
+// the clipboard one at a time, waiting for Enter between parts. With --part
+// only that part is copied.
+func copyParts(text string, files int, cfg *config.Config) bool {
+	var parts []string
+	if cfg.PartTokens > 0 {
+		parts = split.Split(text, cfg.PartTokens, tokens.Count)
+	} else {
+		parts = split.Split(text, cfg.ChunkSize, func(s string) int { return len(s) })
+	}
+	parts = split.Overlap(parts, cfg.Overlap, func(line string) bool { return output.IsFileStart(cfg.Format, line) })
+	parts = split.Label(parts)
+
+	if cfg.Part > len(parts) {
+		fmt.Printf("There is no part %d, the output has %d parts\n", cfg.Part, len(parts))
+		return false
+	}
+
+	notef(cfg, "Collected content from %d files (%s) in %d parts\n", files, sizeSummary(text, cfg), len(parts))
+	first := 1
+	if cfg.Part > 0 {
+		first = cfg.Part
+	}
+
+	// Prompting needs a terminal on stdin that hasn't been read for input
+	info, err := os.Stdin.Stat()
+	interactive := cfg.Part == 0 && err == nil && info.Mode()&os.ModeCharDevice != 0
+
+	reader := bufio.NewReader(os.Stdin)
+	for i := first; i <= len(parts); i++ {
+		clipboard.Write(clipboard.FmtText, []byte(parts[i-1]))
+		notef(cfg, "Copied part %d/%d to clipboard (%s)\n", i, len(parts), sizeSummary(parts[i-1], cfg))
+		if i == len(parts) || cfg.Part > 0 {
+			break
+		}
+		if !interactive {
+			notef(cfg, "Run again with --part %d (up to %d) to copy the next parts\n", i+1, len(parts))
+			break
+		}
+		fmt.Printf("Paste it, then press Enter to copy part %d/%d (q to stop): ", i+1, len(parts))
+		input, err := reader.ReadString('\n')
+		if err != nil || strings.TrimSpace(input) == "q" {
+			break
+		}
+	}
+	return true
+}
+
+// explain prints whether path would be copied when given as an argument and
+// when found while walking the working directory, and which rule decides it
+func explain(path string, cfg *config.Config) {
+	direct, walked, err := processor.Explain(path, gitDir(cfg), cfg)
+	if err != nil {
+		fmt.Printf("%s: %v\n", path, err)
+		return
+	}
+	verdict := func(reason string) string {
+		if reason == "" {
+			return "copied"
+		}
+		return "skipped: " + reason
+	}
+
+	fmt.Printf("%s:\n", path)
+	if info, err := os.Stat(path); err == nil && info.IsDir() {
+		fmt.Printf("  given as an argument: walked\n")
+		if walked == "" {
+			fmt.Printf("  inside a walked directory: walked\n")
+		} else {
+			fmt.Printf("  inside a walked directory: skipped: %s\n", walked)
+		}
+		return
+	}
+	fmt.Printf("  given as an argument: %s\n", verdict(direct))
+	fmt.Printf("  found while walking: %s\n", verdict(walked))
+}
+
+// runPostCopyHooks runs the post-copy commands, handing them a manifest of
+// the copied files, the size of the output meter measured and the output in
+// the file at bundlePath. A temporary manifest is written if --manifest
+// wasn't given.
+func runPostCopyHooks(commands []string, files []processor.FileContent, meter *output.Meter, bundlePath string, cfg *config.Config) {
+	manifestPath := cfg.ManifestPath
+	if manifestPath == "" {
+		tmp, err := os.CreateTemp("", "fcopy-manifest-*.json")
+		if err != nil {
+			fmt.Printf("Error creating manifest for hooks: %v\n", err)
+			return
+		}
+		tmp.Close()
+		defer os.Remove(tmp.Name())
+		manifestPath = tmp.Name()
+		if err := manifest.Build(files).Write(manifestPath); err != nil {
+			fmt.Printf("Error writing manifest %s: %v\n", manifestPath, err)
+			return
+		}
+	}
+
+	info := hooks.Info{
+		ManifestPath: manifestPath,
+		Files:        len(files),
+		Bytes:        int(meter.Bytes),
+		Tokens:       meter.Tokens,
+		BundlePath:   bundlePath,
+	}
+	for _, command := range commands {
+		if err := hooks.Run(command, info, cfg.HookTimeout); err != nil {
+			fmt.Printf("Post-copy hook %q failed: %v\n", command, err)
+		}
+	}
+}
+
+// dumbTerminal reports whether the terminal can't handle carriage-return
+// redraws, as with TERM=dumb and editor shells like Emacs' M-x shell
+func dumbTerminal() bool {
+	term := os.Getenv("TERM")
+	return term == "dumb" || term == "emacs" || os.Getenv("INSIDE_EMACS") != ""
+}
+
+// gitDir returns the directory git commands should run in
+func gitDir(cfg *config.Config) string {
+	if cfg.Cwd != "" {
+		return utils.ExpandPath(cfg.Cwd, "")
+	}
+	return "."
+}
+
+// sizeSummary describes the size of the output in bytes and estimated tokens,
+// with the estimated input cost when a price is configured
+func sizeSummary(text string, cfg *config.Config) string {
+	return formatSize(int64(len(text)), tokens.Count(text), cfg)
+}
+
+// formatSize describes output of size bytes and n tokens as sizeSummary does
+func formatSize(size int64, n int, cfg *config.Config) string {
+	summary := fmt.Sprintf("%d bytes, %s", size, tokens.Format(n))
+	if cfg.PricePerMTok > 0 {
+		summary += ", est. " + tokens.FormatCost(tokens.Cost(n, cfg.PricePerMTok))
+	}
+	return summary
+}
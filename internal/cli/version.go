@@ -0,0 +1,92 @@
+package cli
+
+import (
+	"fcopy/internal/config"
+	"fmt"
+	"os"
+	"runtime"
+	"runtime/debug"
+)
+
+// Set at build time with
+// -ldflags "-X fcopy/internal/cli.version=1.2.3 -X fcopy/internal/cli.commit=abc1234 -X fcopy/internal/cli.date=2026-01-02T15:04:05Z";
+// without them they come from the build info the go command records
+var (
+	version string
+	commit  string
+	date    string
+)
+
+// buildVersion returns the version, commit and build date of the binary,
+// marking the commit when the tree it was built from had local changes
+func buildVersion() (v, rev, built string) {
+	v, rev, built = version, commit, date
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return orUnknown(v), orUnknown(rev), orUnknown(built)
+	}
+	if v == "" && info.Main.Version != "" && info.Main.Version != "(devel)" {
+		v = info.Main.Version
+	}
+	modified := false
+	for _, setting := range info.Settings {
+		switch setting.Key {
+		case "vcs.revision":
+			if rev == "" {
+				rev = setting.Value
+			}
+		case "vcs.time":
+			if built == "" {
+				built = setting.Value
+			}
+		case "vcs.modified":
+			modified = setting.Value == "true"
+		}
+	}
+	if v == "" {
+		v = "dev"
+	}
+	if modified && commit == "" && rev != "" {
+		rev += " (modified)"
+	}
+	return v, orUnknown(rev), orUnknown(built)
+}
+
+func orUnknown(s string) string {
+	if s == "" {
+		return "unknown"
+	}
+	return s
+}
+
+// clipboardBackend names the clipboard implementation compiled in, and
+// reports whether there is one at all
+func clipboardBackend() (string, bool) {
+	switch {
+	case runtime.GOOS == "windows":
+		return "Windows clipboard API", true
+	case !cgoEnabled:
+		return "none (built without cgo)", false
+	case runtime.GOOS == "darwin":
+		return "macOS NSPasteboard (cgo)", true
+	case runtime.GOOS == "linux" || runtime.GOOS == "freebsd" || runtime.GOOS == "openbsd" || runtime.GOOS == "netbsd":
+		return "X11 selections (cgo, libX11 loaded at run time)", true
+	}
+	return "none on " + runtime.GOOS, false
+}
+
+// runVersion implements "fcopy version" and --version: it prints what bug
+// reports need to tell builds apart
+func runVersion(_ *config.Config, _ *config.File, args []string) {
+	if len(args) > 0 {
+		fmt.Println("Usage: fcopy version")
+		os.Exit(1)
+	}
+	v, rev, built := buildVersion()
+	backend, _ := clipboardBackend()
+	fmt.Printf("fcopy %s\n", v)
+	fmt.Printf("commit:    %s\n", rev)
+	fmt.Printf("built:     %s\n", built)
+	fmt.Printf("go:        %s %s/%s\n", runtime.Version(), runtime.GOOS, runtime.GOARCH)
+	fmt.Printf("clipboard: %s\n", backend)
+}
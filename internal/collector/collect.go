@@ -0,0 +1,103 @@
+package collector
+
+import (
+	"context"
+	"fcopy/internal/config"
+	"fcopy/internal/gitutil"
+	"fcopy/internal/processor"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// Collect processes paths, each included for the reason in the origin at
+// the same index, in parallel and returns the files found in them. tracker
+// counts them as they are processed, so callers can report progress from
+// another goroutine.
+func Collect(ctx context.Context, paths []string, origins []processor.Origin, cfg *config.Config, tracker *processor.Tracker) []processor.FileContent {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	tracker.LimitFiles(cfg.MaxFiles, len(paths), cancel)
+
+	results := make(chan processor.FileContent, 100)
+	var wg sync.WaitGroup
+	for i, path := range paths {
+		origins[i].Index = i
+		wg.Add(1)
+		go func(p string, idx int) {
+			defer wg.Done()
+			processor.ProcessPath(ctx, p, origins[idx], cfg, results, tracker)
+			tracker.FinishArg(idx)
+		}(path, i)
+	}
+
+	// Close results channel when all processing is done
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	var files []processor.FileContent
+	for result := range results {
+		files = append(files, result)
+	}
+
+	// Workers can emit files past the --max-files cut before it is known, and
+	// files an earlier argument also reached before it claimed them
+	files = slices.DeleteFunc(files, func(result processor.FileContent) bool {
+		if tracker.Superseded(result.Origin) {
+			tracker.Processed.Add(-1)
+			return true
+		}
+		if tracker.Kept(result.Origin) {
+			return false
+		}
+		tracker.Processed.Add(-1)
+		tracker.Omitted.Add(1)
+		return true
+	})
+	for _, result := range files {
+		if cfg.Logger != nil {
+			cfg.Logger.Printf("Included %s via %s", result.Path, result.Origin)
+		}
+	}
+
+	// List hard links that were folded into the file they point to
+	for i, result := range files {
+		if aliases := tracker.Aliases(result.Path); len(aliases) > 0 {
+			files[i].Notes = append(files[i].Notes, "also linked as "+strings.Join(aliases, ", "))
+		}
+	}
+	return files
+}
+
+// List is Collect without reading the files: it walks and filters them on
+// their paths and sizes only, and returns them without content
+func List(ctx context.Context, paths []string, origins []processor.Origin, cfg *config.Config, tracker *processor.Tracker) []processor.FileContent {
+	tracker.ListOnly = true
+	return Collect(ctx, paths, origins, cfg, tracker)
+}
+
+// Diffs replaces the content of the files selected by --changed with their
+// git diff against ref, in the repository at dir. Other files, and changed
+// files without a diff against the working tree, are kept whole; files that
+// can't be diffed are reported and counted as errors in tracker.
+func Diffs(files []processor.FileContent, dir, ref string, tracker *processor.Tracker) {
+	for i, result := range files {
+		if result.Origin.Rule != processor.RuleChanged {
+			continue
+		}
+		patch, err := gitutil.Diff(dir, ref, result.Path)
+		if err != nil {
+			fmt.Printf("Error diffing %s: %v\n", result.Path, err)
+			tracker.Errors.Add(1)
+			continue
+		}
+		if patch == "" {
+			files[i].Notes = append(files[i].Notes, "no diff against "+ref+" in the working tree")
+			continue
+		}
+		files[i].Content, files[i].Patch = patch, true
+	}
+}
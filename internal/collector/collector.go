@@ -0,0 +1,227 @@
+package collector
+
+import (
+	"crypto/sha256"
+	"fcopy/internal/config"
+	"fcopy/internal/diff"
+	"fcopy/internal/outline"
+	"fcopy/internal/processor"
+	"fcopy/internal/relevance"
+	"fcopy/internal/sample"
+	"fcopy/internal/tokens"
+	"fcopy/internal/utils"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// Finish puts collected files into a stable order and applies the
+// content-level options selected in cfg
+func Finish(files []processor.FileContent, cfg *config.Config) []processor.FileContent {
+	Sort(files)
+	if cfg.Symbol != "" {
+		files = Symbols(files, cfg.Symbol)
+	}
+	if cfg.NormalizeEOL || cfg.ExpandTabs > 0 {
+		Normalize(files, cfg.NormalizeEOL, cfg.ExpandTabs)
+	}
+	if cfg.StripLicense {
+		StripLicenses(files)
+	}
+	if cfg.Outline {
+		Outline(files)
+	}
+	if cfg.Sample > 0 || cfg.PerDirQuota > 0 || cfg.PerLanguageQuota > 0 {
+		files = sample.Sample(files, sample.Options{
+			Count:       cfg.Sample,
+			PerDir:      cfg.PerDirQuota,
+			PerLanguage: cfg.PerLanguageQuota,
+			Seed:        cfg.Seed,
+		})
+	}
+	if cfg.RelevantTo != "" {
+		files = relevance.Rank(files, cfg.RelevantTo)
+	}
+	if cfg.DedupeContent {
+		files = DedupeContent(files)
+	}
+	if cfg.DiffSimilar {
+		files = DiffNearDuplicates(files, cfg.Similarity)
+	}
+	return files
+}
+
+// Budget keeps files within a total cost of max, such as bytes or tokens of
+// output. More relevant files are preferred, then files from earlier
+// arguments, and cheaper files among those, so one huge file doesn't crowd
+// out many small ones. Kept files stay in their original order; the rest are
+// returned as omitted.
+func Budget(files []processor.FileContent, max int64, cost func(processor.FileContent) int64) (kept, omitted []processor.FileContent) {
+	if max <= 0 {
+		return files, nil
+	}
+
+	costs := make([]int64, len(files))
+	order := make([]int, len(files))
+	for i := range order {
+		order[i] = i
+		costs[i] = cost(files[i])
+	}
+	sort.SliceStable(order, func(a, b int) bool {
+		fa, fb := files[order[a]], files[order[b]]
+		if fa.Score != fb.Score {
+			return fa.Score > fb.Score
+		}
+		if fa.Origin.Index != fb.Origin.Index {
+			return fa.Origin.Index < fb.Origin.Index
+		}
+		return costs[order[a]] < costs[order[b]]
+	})
+
+	fits := make([]bool, len(files))
+	var total int64
+	for _, i := range order {
+		if total+costs[i] <= max {
+			total += costs[i]
+			fits[i] = true
+		}
+	}
+	for i, f := range files {
+		if fits[i] {
+			kept = append(kept, f)
+		} else {
+			omitted = append(omitted, f)
+		}
+	}
+	return kept, omitted
+}
+
+// Size approximates the number of bytes f adds to the output
+func Size(f processor.FileContent) int64 {
+	return int64(len(f.Header()) + len(f.Content) + 3)
+}
+
+// Tokens counts the tokens f adds to the output
+func Tokens(f processor.FileContent) int64 {
+	return int64(tokens.Count(f.Header() + "\n" + f.Content))
+}
+
+// Normalize rewrites whitespace in every file: with eol, byte order marks are
+// stripped and line endings converted to LF; a positive tabWidth expands tabs
+func Normalize(files []processor.FileContent, eol bool, tabWidth int) {
+	for i := range files {
+		if eol {
+			files[i].Content = utils.NormalizeEOL(files[i].Content)
+		}
+		files[i].Content = utils.ExpandTabs(files[i].Content, tabWidth)
+	}
+}
+
+// StripLicenses removes the license header from the top of every file
+func StripLicenses(files []processor.FileContent) {
+	for i := range files {
+		content, removed := processor.StripLicense(files[i].Content)
+		if removed > 0 {
+			files[i].Content = content
+			files[i].Notes = append(files[i].Notes, fmt.Sprintf("license header stripped, %d lines", removed))
+		}
+	}
+}
+
+// Outline reduces every file in a supported language to its declarations and
+// signatures; other files are kept whole
+func Outline(files []processor.FileContent) {
+	for i := range files {
+		if outlined, ok := outline.Outline(files[i].Path, files[i].Content); ok {
+			files[i].Notes = append(files[i].Notes, fmt.Sprintf("outline, %d of %d lines",
+				strings.Count(outlined, "\n"), strings.Count(files[i].Content, "\n")))
+			files[i].Content = outlined
+		}
+	}
+}
+
+// Symbols replaces every file with the definitions of the function, method
+// or type called name it contains, one entry per definition with its line
+// span noted in the header. Files without one are dropped.
+func Symbols(files []processor.FileContent, name string) []processor.FileContent {
+	var found []processor.FileContent
+	for _, f := range files {
+		defs, _ := outline.Find(f.Path, f.Content, name)
+		for _, def := range defs {
+			lines := strings.Split(f.Content, "\n")
+			symbol := f
+			symbol.Content = strings.Join(lines[def.Start-1:def.End], "\n")
+			symbol.Notes = append(slices.Clone(f.Notes), fmt.Sprintf("%s, lines %d-%d", def.Name, def.Start, def.End))
+			found = append(found, symbol)
+		}
+	}
+	return found
+}
+
+// Sort orders files by the position of the argument that produced them and
+// then by path, so output doesn't depend on which worker finished first
+func Sort(files []processor.FileContent) {
+	sort.SliceStable(files, func(i, j int) bool {
+		if files[i].Origin.Index != files[j].Origin.Index {
+			return files[i].Origin.Index < files[j].Origin.Index
+		}
+		return files[i].Path < files[j].Path
+	})
+}
+
+// DedupeContent keeps the content of byte-identical files only once; later
+// copies are reduced to an "identical to <path>" stub
+func DedupeContent(files []processor.FileContent) []processor.FileContent {
+	firstByHash := make(map[[sha256.Size]byte]string)
+	for i := range files {
+		sum := sha256.Sum256([]byte(files[i].Content))
+		if first, ok := firstByHash[sum]; ok {
+			files[i].Content = ""
+			files[i].Notes = append(files[i].Notes, "identical to "+first)
+			continue
+		}
+		firstByHash[sum] = files[i].Path
+	}
+	return files
+}
+
+// DiffNearDuplicates replaces the content of files that are at least
+// threshold similar to an earlier file with a unified diff against it. The
+// earlier file is kept in full, so nothing is lost but redundant lines.
+func DiffNearDuplicates(files []processor.FileContent, threshold float64) []processor.FileContent {
+	var bases []int
+	for i := range files {
+		best, bestScore := -1, 0.0
+		for _, b := range bases {
+			if diff.MaxSimilarity(files[b].Content, files[i].Content) < threshold {
+				continue
+			}
+			score := diff.Similarity(files[b].Content, files[i].Content)
+			if score >= threshold && score > bestScore {
+				best, bestScore = b, score
+			}
+		}
+
+		if best < 0 {
+			bases = append(bases, i)
+			continue
+		}
+
+		base := files[best]
+		patch := diff.Unified(base.Path, files[i].Path, base.Content, files[i].Content, 3)
+		// A diff only pays off when it is actually smaller than the file
+		if len(patch) >= len(files[i].Content) {
+			bases = append(bases, i)
+			continue
+		}
+		files[i].Content = patch
+		if patch == "" {
+			files[i].Notes = append(files[i].Notes, "identical to "+base.Path)
+		} else {
+			files[i].Notes = append(files[i].Notes,
+				fmt.Sprintf("diff against %s, %.0f%% similar", base.Path, bestScore*100))
+		}
+	}
+	return files
+}
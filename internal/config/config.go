@@ -4,23 +4,146 @@ import (
 	"flag"
 	"log"
 	"os"
+	"path/filepath"
+	"strings"
 	"time"
 )
 
 // Config holds the application configuration
 type Config struct {
-	MaxFileSize  int64
-	Timeout      time.Duration
-	Workers      int
-	Verbose      bool
-	Debug        bool
-	MaxMatches   int
-	SearchDepth  int
-	AutoSelect   bool
-	SearchHidden bool
-	NoIgnore     bool
-	Logger       *log.Logger
-	LogFile      *os.File
+	MaxFileSize      int64
+	MaxFiles         int
+	MaxTotalSize     int64
+	MaxTokens        int
+	Fit              string
+	MaxLineLength    int
+	Truncate         string
+	Timeout          time.Duration
+	Workers          int
+	Verbose          bool
+	Quiet            bool
+	SummaryJSON      bool
+	Version          bool
+	Debug            bool
+	LogPath          string
+	MaxMatches       int
+	SearchDepth      int
+	AutoSelect       bool
+	Picker           string
+	Case             string
+	JSON             bool
+	PathsOnly        bool
+	NonInteractive   bool
+	PickFirst        bool
+	Select           int
+	Strict           bool
+	FailOnError      bool
+	SearchHidden     bool
+	NoIgnore         bool
+	NoIndex          bool
+	NoRoot           bool
+	OnlyFiles        bool
+	OnlyDirs         bool
+	FindExclude      RepeatedFlag
+	NoFrecency       bool
+	NoGitActivity    bool
+	NoQueryCache     bool
+	SearchContent    bool
+	ManifestPath     string
+	Review           bool
+	Yes              bool
+	DryRun           bool
+	Explain          bool
+	PostCopy         RepeatedFlag
+	HookTimeout      time.Duration
+	FollowSymlinks   bool
+	DiffSimilar      bool
+	DedupeContent    bool
+	Similarity       float64
+	Format           string
+	PromptTemplate   string
+	Vars             RepeatedFlag
+	Preset           string
+	Sample           int
+	PerDirQuota      int
+	PerLanguageQuota int
+	Seed             uint64
+	RelevantTo       string
+	Cwd              string
+	IncludeGenerated bool
+	HexdumpBinaries  bool
+	NoTests          bool
+	Types            ListFlag
+	FromEnv          bool
+	GitOnly          bool
+	Changed          string
+	Entrypoints      bool
+	WithMeta         bool
+	FollowImports    bool
+	ImportDepth      int
+	FilesFrom        string
+	StdinLabel       string
+	NullSeparated    bool
+	PricePerMTok     float64
+	Model            string
+	ChunkSize        int
+	Split            bool
+	PartTokens       int
+	Part             int
+	Overlap          int
+	BridgeAddr       string
+	BridgeIdle       time.Duration
+	Send             bool
+	SendURL          string
+	SendModel        string
+	SendTimeout      time.Duration
+	HexdumpLimit     int64
+	Binary           string
+	Encoding         string
+	NormalizeEOL     bool
+	ExpandTabs       int
+	StripLicense     bool
+	Outline          bool
+	Symbol           string
+	NotebookMarkdown bool
+	Base64Limit      int64
+	Separator        string
+	OutputPath       string
+	Scoring          *Scoring // From the config file; nil for DefaultScoring
+	Logger           *log.Logger
+	LogFile          *os.File
+}
+
+// ListFlag is a flag.Value collecting values given as a comma-separated list,
+// by repeating the flag, or both
+type ListFlag []string
+
+func (l *ListFlag) String() string {
+	return strings.Join(*l, ",")
+}
+
+// Set appends the comma-separated values in value
+func (l *ListFlag) Set(value string) error {
+	for _, v := range strings.Split(value, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			*l = append(*l, v)
+		}
+	}
+	return nil
+}
+
+// RepeatedFlag is a flag.Value collecting every value given by repeating the
+// flag, for values such as shell commands that may contain commas
+type RepeatedFlag []string
+
+func (r *RepeatedFlag) String() string {
+	return strings.Join(*r, "; ")
+}
+
+// Set appends value as given
+func (r *RepeatedFlag) Set(value string) error {
+	*r = append(*r, value)
+	return nil
 }
 
 // IgnoreDirs contains directories to skip during search
@@ -53,6 +176,13 @@ var IgnoreDirs = map[string]bool{
 	".parcel-cache":    true,
 }
 
+// ArtifactNames contains files and directories written by fcopy itself,
+// which are never collected so that its output doesn't feed back into itself
+var ArtifactNames = map[string]bool{
+	"fcopy_debug.log": true,
+	".fcopy":          true,
+}
+
 // IgnoreExts contains file extensions to skip during search
 var IgnoreExts = map[string]bool{
 	".log":           true,
@@ -76,29 +206,170 @@ var BinaryExts = map[string]bool{
 	".pdf": true, ".doc": true, ".docx": true, ".xls": true, ".xlsx": true,
 }
 
-// LoadConfig parses command-line flags and sets up configuration
-func LoadConfig() (*Config, error) {
+// TestPatterns contains gitignore-style patterns matching test files and
+// directories by per-language convention, skipped with --no-tests
+var TestPatterns = []string{
+	"*_test.go",
+	"*.spec.ts", "*.spec.tsx", "*.spec.js", "*.spec.jsx",
+	"*.test.ts", "*.test.tsx", "*.test.js", "*.test.jsx",
+	"test_*.py", "*_test.py",
+	"*_spec.rb",
+	"*Test.java", "*Tests.java", "*Test.kt",
+	"*Tests.cs",
+	"__tests__/",
+	"__snapshots__/",
+}
+
+// RegisterFlags defines the command-line flags on fs, bound to the fields of
+// the returned Config, which hold their defaults until fs is parsed
+func RegisterFlags(fs *flag.FlagSet) *Config {
 	cfg := &Config{}
 
-	flag.Int64Var(&cfg.MaxFileSize, "max-size", 1024*1024, "Maximum file size in bytes")
-	flag.DurationVar(&cfg.Timeout, "timeout", 30*time.Second, "Timeout for operation")
-	flag.IntVar(&cfg.Workers, "workers", 10, "Number of concurrent workers")
-	flag.BoolVar(&cfg.Verbose, "verbose", false, "Verbose output")
-	flag.BoolVar(&cfg.Debug, "debug", true, "Enable debug mode")
-	flag.IntVar(&cfg.MaxMatches, "max-matches", 15, "Maximum number of fuzzy matches to display")
-	flag.IntVar(&cfg.SearchDepth, "depth", 5, "Maximum depth to search for fuzzy matches")
-	flag.BoolVar(&cfg.AutoSelect, "auto", false, "Automatically select best match if score is good enough")
-	flag.BoolVar(&cfg.SearchHidden, "hidden", false, "Include hidden files in search")
-	flag.BoolVar(&cfg.NoIgnore, "no-ignore", false, "Don't skip common ignored directories")
-
-	// Setup debug log file
-	var err error
-	cfg.LogFile, err = os.Create("fcopy_debug.log")
+	fs.Int64Var(&cfg.MaxFileSize, "max-size", 1024*1024, "Maximum file size in bytes")
+	fs.StringVar(&cfg.Truncate, "truncate", "", "Include files over --max-size as their first and last lines instead of skipping them (e.g. head:200,tail:50)")
+	fs.IntVar(&cfg.MaxFiles, "max-files", 0, "Maximum number of files to copy (0 for no limit)")
+	fs.Int64Var(&cfg.MaxTotalSize, "max-total-size", 0, "Maximum total output size in bytes; files that don't fit are listed as omitted (0 for no limit)")
+	fs.IntVar(&cfg.MaxTokens, "max-tokens", 0, "Maximum total tokens of output; files that don't fit are listed as omitted (0 for no limit)")
+	fs.StringVar(&cfg.Fit, "fit", "", "Fit the output into the context window of this model (sets --model and --max-tokens)")
+	fs.IntVar(&cfg.MaxLineLength, "max-line-length", 5000, "Skip files found while walking with lines longer than this many bytes, and cut such lines in files given explicitly (0 for no limit)")
+	fs.DurationVar(&cfg.Timeout, "timeout", 30*time.Second, "Timeout for operation")
+	fs.IntVar(&cfg.Workers, "workers", 10, "Number of concurrent workers")
+	fs.BoolVar(&cfg.Verbose, "verbose", false, "Verbose output")
+	fs.BoolVar(&cfg.Quiet, "quiet", false, "Print only errors, and what was asked for like fcopy tree or --dry-run, not progress, warnings or summaries")
+	fs.BoolVar(&cfg.SummaryJSON, "summary-json", false, "Finish with a JSON summary of the run (files, bytes, tokens, skipped files and why, duration) on its own line; implies --quiet")
+	fs.BoolVar(&cfg.Debug, "debug", false, "Write a debug log of which files were included and why (to --log-file)")
+	fs.StringVar(&cfg.LogPath, "log-file", "", "Write the debug log to this file, which enables it (default: fcopy/debug.log in the user cache directory with --debug)")
+	fs.IntVar(&cfg.MaxMatches, "max-matches", 15, "Maximum number of fuzzy matches to display")
+	fs.IntVar(&cfg.SearchDepth, "depth", 5, "Maximum depth to search for fuzzy matches")
+	fs.StringVar(&cfg.Case, "case", "smart", "Case-sensitivity of fuzzy matching: smart (sensitive only when the query has upper case letters), sensitive or insensitive")
+	fs.BoolVar(&cfg.AutoSelect, "auto", false, "Automatically select best match if score is good enough")
+	fs.StringVar(&cfg.Picker, "picker", "prompt", "How to choose among ambiguous fuzzy matches: prompt for a numbered list, tui for a full-screen picker with a preview, or fzf")
+	fs.BoolVar(&cfg.NonInteractive, "non-interactive", false, "Never prompt for ambiguous fuzzy matches; resolve them with --first or --select, or fail (the default, also --strict)")
+	fs.BoolVar(&cfg.PickFirst, "first", false, "Resolve ambiguous fuzzy matches to the best one without prompting (implies --non-interactive)")
+	fs.IntVar(&cfg.Select, "select", 0, "Resolve ambiguous fuzzy matches to the Nth candidate without prompting (implies --non-interactive)")
+	fs.BoolVar(&cfg.Strict, "strict", false, "Fail, listing the candidates on stderr, when a fuzzy match is ambiguous (implies --non-interactive)")
+	fs.BoolVar(&cfg.FailOnError, "fail-on-error", false, "Copy nothing, exiting with status 1, when any file can't be read or path resolved")
+	fs.BoolVar(&cfg.JSON, "json", false, "Print the candidates of fcopy find as JSON")
+	fs.BoolVar(&cfg.PathsOnly, "paths", false, "Print only the paths of the candidates of fcopy find")
+	fs.BoolVar(&cfg.SearchHidden, "hidden", false, "Include hidden files in search")
+	fs.BoolVar(&cfg.NoIgnore, "no-ignore", false, "Don't skip common ignored directories, or paths in .gitignore and .fcopyignore files during fuzzy matching")
+	fs.BoolVar(&cfg.NoIndex, "no-index", false, "Walk the tree for fuzzy matching instead of using the index of directory listings in the cache directory")
+	fs.BoolVar(&cfg.NoRoot, "no-root", false, "Fuzzy match names typed without a directory from the current directory instead of the project root")
+	fs.BoolVar(&cfg.OnlyFiles, "only-files", false, "Only fuzzy match files, not directories")
+	fs.BoolVar(&cfg.OnlyDirs, "only-dirs", false, "Only fuzzy match directories, not files")
+	fs.Var(&cfg.FindExclude, "find-exclude", "Leave paths matching this gitignore-style glob out of fuzzy matching (repeatable)")
+	fs.BoolVar(&cfg.NoFrecency, "no-frecency", false, "Don't favor fuzzy matches picked often and recently, or remember picks")
+	fs.BoolVar(&cfg.NoGitActivity, "no-git-activity", false, "Don't break ties between fuzzy matches by how recently git commits changed them")
+	fs.BoolVar(&cfg.NoQueryCache, "no-query-cache", false, "Fuzzy match again instead of reusing the paths picked for the same query before")
+	fs.BoolVar(&cfg.SearchContent, "search-content", false, "When no file or directory name matches a fuzzy query well, offer the files containing it")
+	fs.StringVar(&cfg.Cwd, "cwd", "", "Resolve relative paths against this directory instead of the current one")
+	fs.BoolVar(&cfg.IncludeGenerated, "include-generated", false, "Include generated files (linguist-generated or \"Code generated ... DO NOT EDIT\" headers)")
+	fs.BoolVar(&cfg.HexdumpBinaries, "hexdump-binaries", false, "Include binary files as a hex dump instead of skipping them")
+	fs.Int64Var(&cfg.HexdumpLimit, "hexdump-limit", 1024, "Maximum number of bytes to hex dump per binary file")
+	fs.StringVar(&cfg.Binary, "binary", "placeholder", "How to include binary files given explicitly: placeholder, base64 (up to --base64-limit bytes) or skip")
+	fs.Int64Var(&cfg.Base64Limit, "base64-limit", 64*1024, "Maximum size in bytes of binary files embedded with --binary base64")
+	fs.StringVar(&cfg.Encoding, "encoding", "auto", "Encoding of files that aren't UTF-8: auto to detect UTF-16, Shift-JIS or Windows-1252, utf-8 to leave them as they are, or a name like shift_jis")
+	fs.BoolVar(&cfg.NormalizeEOL, "normalize-eol", false, "Strip byte order marks and convert CRLF line endings to LF")
+	fs.IntVar(&cfg.ExpandTabs, "expand-tabs", 0, "Expand tabs to spaces with tab stops every N columns (0 to keep tabs)")
+	fs.BoolVar(&cfg.StripLicense, "strip-license", false, "Remove copyright and license comment blocks from the top of files")
+	fs.BoolVar(&cfg.NotebookMarkdown, "notebook-markdown", false, "Include the markdown cells of Jupyter notebooks as comments, not just code cells")
+	fs.StringVar(&cfg.StdinLabel, "stdin-label", "stdin", "Header label for content read from stdin with the - argument")
+	fs.StringVar(&cfg.FilesFrom, "files-from", "", "Read paths to copy from a file, or from stdin with -")
+	fs.BoolVar(&cfg.NullSeparated, "0", false, "Paths read with --files-from are separated by NUL instead of newlines")
+	fs.StringVar(&cfg.Changed, "changed", "", "Copy files changed in a git revision or range (e.g. HEAD~3, main..feature)")
+	fs.BoolVar(&cfg.GitOnly, "git-only", false, "Only copy files tracked by git when processing directories")
+	fs.BoolVar(&cfg.NoTests, "no-tests", false, "Exclude test files (*_test.go, *.spec.ts, test_*.py, __tests__/, ...)")
+	fs.BoolVar(&cfg.FollowSymlinks, "follow-symlinks", false, "Follow symlinked files and directories while walking")
+	fs.BoolVar(&cfg.DedupeContent, "dedupe-content", false, "Include the content of byte-identical files only once")
+	fs.BoolVar(&cfg.DiffSimilar, "diff-similar", false, "Include near-duplicate files as diffs against the first similar file")
+	fs.Float64Var(&cfg.Similarity, "similarity", 0.9, "Minimum similarity (0-1) for --diff-similar to treat files as near-duplicates")
+	fs.StringVar(&cfg.Format, "format", "plain", "Output format: plain, cat, diff (with --changed), jsonl, bundle or repomix")
+	fs.IntVar(&cfg.Sample, "sample", 0, "Copy a weighted random sample of this many files, e.g. for datasets (0 to copy everything)")
+	fs.IntVar(&cfg.PerDirQuota, "per-dir-quota", 0, "Maximum files --sample draws from one directory (0 for no limit)")
+	fs.IntVar(&cfg.PerLanguageQuota, "per-language-quota", 0, "Maximum files --sample draws per language (0 for no limit)")
+	fs.Uint64Var(&cfg.Seed, "seed", 1, "Seed for --sample; the same seed and files give the same sample")
+	fs.StringVar(&cfg.Separator, "separator", `\n`, "Record separator written after each file with --format cat (escapes like \\0 and \\n are allowed)")
+	fs.StringVar(&cfg.PromptTemplate, "prompt-template", "", "Wrap the output in this Go text/template file, with {{.Files}}, {{.Tree}}, {{.Paths}}, {{.Count}}, {{.Date}} and --var values")
+	fs.Var(&cfg.Vars, "var", "Template variable for --prompt-template as key=value (repeatable)")
+	fs.StringVar(&cfg.Preset, "preset", "", "Apply the options of this named preset from the config file ($XDG_CONFIG_HOME/fcopy/config.yaml); flags given explicitly win")
+	fs.StringVar(&cfg.OutputPath, "output", "", "Write the output to this file instead of the clipboard")
+	fs.BoolVar(&cfg.FromEnv, "from-env", false, "Copy the editor selection given in FCOPY_SELECTION or FCOPY_SELECTION_FD")
+	fs.StringVar(&cfg.Model, "model", "", "Count tokens with the tokenizer of this model: gpt-4o, gpt-4.1, o1, gpt-4, gpt-3.5, claude or llama (default: fast estimate)")
+	fs.Float64Var(&cfg.PricePerMTok, "price", 0, "Input price in dollars per million tokens, used to estimate the cost of the output")
+	fs.IntVar(&cfg.ChunkSize, "chunk-size", 30000, "Maximum bytes per part served by fcopy bridge or copied with --split")
+	fs.BoolVar(&cfg.Split, "split", false, "Split large output into numbered parts of --chunk-size bytes or --part-tokens tokens and copy them one at a time")
+	fs.IntVar(&cfg.PartTokens, "part-tokens", 0, "Maximum tokens per part with --split, instead of --chunk-size bytes")
+	fs.IntVar(&cfg.Part, "part", 0, "With --split, copy only this part")
+	fs.IntVar(&cfg.Overlap, "overlap", 0, "With --split, repeat this many lines from the end of a part at the start of the next when a file continues there")
+	fs.StringVar(&cfg.BridgeAddr, "bridge-addr", "127.0.0.1:0", "Address fcopy bridge listens on")
+	fs.DurationVar(&cfg.BridgeIdle, "bridge-idle", 15*time.Minute, "Stop fcopy bridge after this long without requests")
+	fs.BoolVar(&cfg.Send, "send", false, "Send the output to an OpenAI-compatible chat endpoint instead of copying it, then print and copy the reply (API key from FCOPY_API_KEY or OPENAI_API_KEY)")
+	fs.StringVar(&cfg.SendURL, "send-url", "https://api.openai.com/v1", "Base URL of the endpoint for --send, e.g. http://localhost:11434/v1 for ollama")
+	fs.StringVar(&cfg.SendModel, "send-model", "", "Model to ask with --send")
+	fs.DurationVar(&cfg.SendTimeout, "send-timeout", 5*time.Minute, "How long to wait for the reply to --send")
+	fs.StringVar(&cfg.ManifestPath, "manifest", "", "Write a JSON manifest of copied files and why they were included")
+	fs.Var(&cfg.Types, "type", "Only copy files of these categories found while walking: code, config, docs, data (comma-separated)")
+	fs.BoolVar(&cfg.Review, "review", false, "Review the final file list and toggle files off before copying")
+	fs.BoolVar(&cfg.Yes, "yes", false, "With fcopy apply, write the files without asking for confirmation")
+	fs.Var(&cfg.PostCopy, "post-copy", "Shell command to run after a successful copy, with FCOPY_MANIFEST, FCOPY_FILES, FCOPY_BYTES, FCOPY_TOKENS and FCOPY_BUNDLE (a file holding the output, also its stdin) set (repeatable)")
+	fs.DurationVar(&cfg.HookTimeout, "hook-timeout", 10*time.Second, "Kill pre- and post-copy hooks, from --post-copy or the config file, that run longer than this")
+	fs.BoolVar(&cfg.DryRun, "dry-run", false, "Print what would be copied, with sizes and token estimates, without touching the clipboard")
+	fs.BoolVar(&cfg.Explain, "explain", false, "Explain which filter rules would include or skip the given paths instead of copying them")
+	fs.BoolVar(&cfg.Entrypoints, "entrypoints", false, "Copy the project's entry points, routing and config files (main.go, cmd/*, index.ts, app.py, Program.cs, ...)")
+	fs.BoolVar(&cfg.WithMeta, "with-meta", false, "Start the output with the project's go.mod, package.json, pyproject.toml, ... and its README if under 16 KB")
+	fs.BoolVar(&cfg.FollowImports, "follow-imports", false, "Also copy the local files imported by the given files and directories: Go packages of the same module, relative and tsconfig-aliased TS/JS modules")
+	fs.IntVar(&cfg.ImportDepth, "import-depth", 1, "How many levels of imports --follow-imports follows")
+	fs.StringVar(&cfg.RelevantTo, "relevant-to", "", "Rank files by relevance to this query (BM25 over paths and contents), drop files found while walking that don't match, and fill budgets best first")
+	fs.BoolVar(&cfg.Outline, "outline", false, "Copy only the declarations and signatures of Go, TypeScript/JavaScript, Python, Rust and Java files, with function bodies replaced by { ... }")
+	fs.StringVar(&cfg.Symbol, "symbol", "", "Copy only the definition of the function, method or type with this name (e.g. ProcessDirectory or Tracker.Claim) from the given files")
+	fs.BoolVar(&cfg.Version, "version", false, "Print the version, commit, build date and clipboard backend, as fcopy version does")
+
+	return cfg
+}
+
+// Defaults returns the configuration the flags describe when none is given,
+// without a debug log
+func Defaults() *Config {
+	return RegisterFlags(flag.NewFlagSet("fcopy", flag.ContinueOnError))
+}
+
+// LoadConfig defines the command-line flags, bound to the returned Config
+func LoadConfig() *Config {
+	return RegisterFlags(flag.CommandLine)
+}
+
+// DefaultLogPath returns where the debug log goes without --log-file:
+// $XDG_CACHE_HOME/fcopy/debug.log, or the platform's equivalent
+func DefaultLogPath() (string, error) {
+	dir, err := os.UserCacheDir()
 	if err != nil {
-		return cfg, err
+		return "", err
 	}
+	return filepath.Join(dir, "fcopy", "debug.log"), nil
+}
 
-	cfg.Logger = log.New(cfg.LogFile, "", log.LstdFlags)
+// OpenLog sets up the debug log if --debug or --log-file asks for one
+func (c *Config) OpenLog() error {
+	if !c.Debug && c.LogPath == "" {
+		return nil
+	}
+	if c.LogPath == "" {
+		path, err := DefaultLogPath()
+		if err != nil {
+			return err
+		}
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			return err
+		}
+		c.LogPath = path
+	}
 
-	return cfg, nil
+	// Appending never destroys what a mistyped --log-file points at
+	var err error
+	c.LogFile, err = os.OpenFile(c.LogPath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	c.Logger = log.New(c.LogFile, "", log.LstdFlags)
+	return nil
 }
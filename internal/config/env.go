@@ -0,0 +1,102 @@
+package config
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"unicode"
+)
+
+// envReserved are flags whose variable fcopy itself sets for --post-copy
+// commands, so an fcopy run from a hook doesn't pick them up
+var envReserved = map[string]bool{
+	"manifest": true,
+}
+
+// EnvName returns the environment variable setting the named flag, as
+// FCOPY_MAX_SIZE sets --max-size
+func EnvName(flagName string) string {
+	return "FCOPY_" + strings.ToUpper(strings.ReplaceAll(flagName, "-", "_"))
+}
+
+// ApplyEnv sets the flags on fs that weren't given on the command line from
+// their environment variables
+func ApplyEnv(fs *flag.FlagSet) error {
+	explicit := make(map[string]bool)
+	fs.Visit(func(f *flag.Flag) { explicit[f.Name] = true })
+
+	var err error
+	fs.VisitAll(func(f *flag.Flag) {
+		if err != nil || explicit[f.Name] || envReserved[f.Name] {
+			return
+		}
+		name := EnvName(f.Name)
+		if value, ok := os.LookupEnv(name); ok {
+			if setErr := fs.Set(f.Name, value); setErr != nil {
+				err = fmt.Errorf("invalid value %q for %s: %v", value, name, setErr)
+			}
+		}
+	})
+	return err
+}
+
+// EnvOpts is the variable holding flags to parse before the command line's
+const EnvOpts = "FCOPY_OPTS"
+
+// OptsArgs splits the value of FCOPY_OPTS into arguments as a shell would:
+// at white space outside quotes, with single quotes taken literally and a
+// backslash escaping the next character outside them, or a quote or
+// backslash within double quotes
+func OptsArgs(value string) ([]string, error) {
+	var args []string
+	var arg strings.Builder
+	inArg := false
+	var quote rune
+	runes := []rune(value)
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		switch {
+		case quote == '\'':
+			if r == '\'' {
+				quote = 0
+			} else {
+				arg.WriteRune(r)
+			}
+		case r == '\\':
+			if i+1 == len(runes) {
+				return nil, fmt.Errorf("%s ends in a backslash", EnvOpts)
+			}
+			if next := runes[i+1]; quote == 0 || next == '"' || next == '\\' {
+				r = next
+				i++
+			}
+			arg.WriteRune(r)
+			inArg = true
+		case quote == '"':
+			if r == '"' {
+				quote = 0
+			} else {
+				arg.WriteRune(r)
+			}
+		case r == '\'' || r == '"':
+			quote, inArg = r, true
+		case unicode.IsSpace(r):
+			if inArg {
+				args = append(args, arg.String())
+				arg.Reset()
+				inArg = false
+			}
+		default:
+			arg.WriteRune(r)
+			inArg = true
+		}
+	}
+	if quote != 0 {
+		return nil, fmt.Errorf("%s has an unterminated %c quote", EnvOpts, quote)
+	}
+	if inArg {
+		args = append(args, arg.String())
+	}
+	return args, nil
+}
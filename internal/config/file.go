@@ -0,0 +1,293 @@
+package config
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// File is the user configuration file
+type File struct {
+	Path     string              `yaml:"-"`
+	Defaults Options             `yaml:"defaults"` // Flags every run starts from
+	Presets  map[string]Options  `yaml:"presets"`
+	Fuzzy    Scoring             `yaml:"fuzzy"`
+	Sets     map[string][]string `yaml:"sets"` // Paths and globs that "@name" stands for
+	Ignore   Ignore              `yaml:"ignore"`
+	Hooks    Hooks               `yaml:"hooks"`
+
+	Project string `yaml:"-"` // Project config file merged over this one, if any
+}
+
+// Hooks are shell commands run around every copy: pre ones before anything is
+// gathered, which abort the copy by failing, and post ones after a successful
+// copy along with --post-copy
+type Hooks struct {
+	Pre  []string `yaml:"pre"`
+	Post []string `yaml:"post"`
+}
+
+// Ignore lists names the config file adds to the built-in ignore lists
+type Ignore struct {
+	Dirs   []string `yaml:"dirs"`   // Added to IgnoreDirs
+	Exts   []string `yaml:"exts"`   // Added to IgnoreExts
+	Binary []string `yaml:"binary"` // Added to BinaryExts
+}
+
+// Options maps flag names to values, as in "no-tests: true". A list sets a
+// repeatable flag once per element.
+type Options map[string]any
+
+// pathFlags take a path, which is resolved against the config file's
+// directory when relative
+var pathFlags = map[string]bool{
+	"prompt-template": true,
+}
+
+// ProjectConfigName is the project config file, looked for in the project
+// root and merged over the user configuration
+const ProjectConfigName = ".fcopy.yaml"
+
+// projectOptions are the only options a project config file can set: ones
+// that choose, filter, limit and format what is copied. Anything else could
+// run commands, read files outside the project, send the output elsewhere
+// or write files, and a repository shouldn't do that on its own just by
+// being copied from
+var projectOptions = map[string]bool{
+	// Limits
+	"max-size":        true,
+	"truncate":        true,
+	"max-files":       true,
+	"max-total-size":  true,
+	"max-tokens":      true,
+	"fit":             true,
+	"max-line-length": true,
+	"max-matches":     true,
+	"depth":           true,
+	// Matching and filtering
+	"case":              true,
+	"hidden":            true,
+	"no-ignore":         true,
+	"only-files":        true,
+	"only-dirs":         true,
+	"find-exclude":      true,
+	"search-content":    true,
+	"include-generated": true,
+	"git-only":          true,
+	"no-tests":          true,
+	"dedupe-content":    true,
+	"diff-similar":      true,
+	"similarity":        true,
+	"type":              true,
+	// Content and format
+	"hexdump-binaries":  true,
+	"hexdump-limit":     true,
+	"binary":            true,
+	"encoding":          true,
+	"normalize-eol":     true,
+	"expand-tabs":       true,
+	"strip-license":     true,
+	"notebook-markdown": true,
+	"outline":           true,
+	"with-meta":         true,
+	"format":            true,
+	"separator":         true,
+	"model":             true,
+	"chunk-size":        true,
+	"part-tokens":       true,
+	"overlap":           true,
+}
+
+// UserConfigPath returns where the user configuration file lives:
+// $XDG_CONFIG_HOME/fcopy/config.yaml, or the platform's equivalent
+func UserConfigPath() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "fcopy", "config.yaml")
+}
+
+// LoadFile reads the configuration file at path. A missing file is not an
+// error and yields an empty configuration.
+func LoadFile(path string) (*File, error) {
+	// Settings missing from the file keep their defaults
+	file := &File{Path: path, Fuzzy: DefaultScoring()}
+	if _, err := file.read(path); err != nil {
+		return nil, err
+	}
+	return file, nil
+}
+
+// read decodes the file at path over f, reporting whether it exists, and
+// resolves the paths its options give
+func (f *File) read(path string) (bool, error) {
+	if path == "" {
+		return false, nil
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+	if err := yaml.Unmarshal(data, f); err != nil {
+		return false, fmt.Errorf("invalid config file %s: %v", path, err)
+	}
+	if err := f.Fuzzy.Validate(); err != nil {
+		return false, fmt.Errorf("invalid config file %s: %v", path, err)
+	}
+	dir := filepath.Dir(path)
+	f.Defaults.resolve(dir)
+	for _, preset := range f.Presets {
+		preset.resolve(dir)
+	}
+	return true, nil
+}
+
+// MergeProject merges the project config file at path over f, if there is
+// one: its defaults, presets and sets win over f's by name, its fuzzy
+// settings over f's, and its ignore lists add to f's.
+func (f *File) MergeProject(path string) error {
+	// Fuzzy settings missing from the project keep the user's
+	project := &File{Fuzzy: f.Fuzzy}
+	if ok, err := project.read(path); err != nil || !ok {
+		return err
+	}
+	if len(project.Hooks.Pre) > 0 || len(project.Hooks.Post) > 0 {
+		return fmt.Errorf("%s can't set hooks; set them in %s instead", path, UserConfigPath())
+	}
+	all := []Options{project.Defaults}
+	for _, preset := range project.Presets {
+		all = append(all, preset)
+	}
+	for _, options := range all {
+		for name := range options {
+			if !projectOptions[name] {
+				return fmt.Errorf("%s can't set %q; a project config may only set filter, format and limit options, so set it in %s or on the command line instead", path, name, UserConfigPath())
+			}
+		}
+	}
+
+	f.Project = path
+	f.Fuzzy = project.Fuzzy
+	f.Defaults = merged(f.Defaults, project.Defaults)
+	f.Presets = merged(f.Presets, project.Presets)
+	f.Sets = merged(f.Sets, project.Sets)
+	f.Ignore.Dirs = append(f.Ignore.Dirs, project.Ignore.Dirs...)
+	f.Ignore.Exts = append(f.Ignore.Exts, project.Ignore.Exts...)
+	f.Ignore.Binary = append(f.Ignore.Binary, project.Ignore.Binary...)
+	return nil
+}
+
+// merged returns base with the entries of over added, replacing any of the
+// same name
+func merged[M ~map[string]V, V any](base, over M) M {
+	if len(over) == 0 {
+		return base
+	}
+	out := make(M, len(base)+len(over))
+	maps.Copy(out, base)
+	maps.Copy(out, over)
+	return out
+}
+
+// PresetNames returns the names of the presets defined in the file, sorted
+func (f *File) PresetNames() []string {
+	names := make([]string, 0, len(f.Presets))
+	for name := range f.Presets {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// ApplyPreset sets the flags of the named preset on fs. Flags given on the
+// command line win over the preset.
+func (f *File) ApplyPreset(fs *flag.FlagSet, name string) error {
+	preset, ok := f.Presets[name]
+	if !ok {
+		if len(f.Presets) == 0 {
+			return fmt.Errorf("unknown preset %q (no presets defined in %s)", name, f.Path)
+		}
+		return fmt.Errorf("unknown preset %q (expected one of: %s)", name, strings.Join(f.PresetNames(), ", "))
+	}
+	if err := preset.apply(fs); err != nil {
+		return fmt.Errorf("preset %q: %v", name, err)
+	}
+	return nil
+}
+
+// ApplyDefaults sets the flags of the file's defaults section on fs that
+// weren't set otherwise, whether on the command line, from the environment
+// or by a preset
+func (f *File) ApplyDefaults(fs *flag.FlagSet) error {
+	if err := f.Defaults.apply(fs); err != nil {
+		return fmt.Errorf("config defaults: %v", err)
+	}
+	return nil
+}
+
+// AddIgnores extends the built-in ignore lists with the file's
+func (f *File) AddIgnores() {
+	for _, name := range f.Ignore.Dirs {
+		IgnoreDirs[name] = true
+	}
+	for _, ext := range f.Ignore.Exts {
+		IgnoreExts[ext] = true
+	}
+	for _, ext := range f.Ignore.Binary {
+		BinaryExts[ext] = true
+	}
+}
+
+// resolve makes the relative paths of path options absolute against dir,
+// the directory of the config file they come from
+func (o Options) resolve(dir string) {
+	for name, value := range o {
+		if s, ok := value.(string); ok && pathFlags[name] && !filepath.IsAbs(s) && !strings.HasPrefix(s, "~") {
+			o[name] = filepath.Join(dir, s)
+		}
+	}
+}
+
+// apply sets every option on fs that wasn't given explicitly
+func (o Options) apply(fs *flag.FlagSet) error {
+	explicit := make(map[string]bool)
+	fs.Visit(func(f *flag.Flag) { explicit[f.Name] = true })
+
+	names := make([]string, 0, len(o))
+	for name := range o {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	for _, name := range names {
+		if name == "preset" || fs.Lookup(name) == nil {
+			return fmt.Errorf("unknown option %q", name)
+		}
+		if explicit[name] {
+			continue
+		}
+
+		values, ok := o[name].([]any)
+		if !ok {
+			values = []any{o[name]}
+		}
+		for _, value := range values {
+			s := fmt.Sprint(value)
+			if err := fs.Set(name, s); err != nil {
+				return fmt.Errorf("invalid value %q for %s: %v", s, name, err)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,91 @@
+package config
+
+import (
+	"fmt"
+	"math"
+)
+
+// Scoring tunes how fuzzy matches are scored, from the "fuzzy" section of
+// the config file. Scores are lower for better matches.
+type Scoring struct {
+	// A best match scoring at most ratio times the length of the query, but
+	// at least min and at most max (0 for no limit, and max wins over min),
+	// is taken without asking
+	AutoSelectRatio float64 `yaml:"auto-select-ratio"`
+	AutoSelectMin   int     `yaml:"auto-select-min"`
+	AutoSelectMax   int     `yaml:"auto-select-max"`
+
+	// Names within this many edits of the query, computed in the same way,
+	// match as typos
+	DistanceRatio float64 `yaml:"distance-ratio"`
+	DistanceMin   int     `yaml:"distance-min"`
+	DistanceMax   int     `yaml:"distance-max"`
+
+	// Base scores of exact, substring, subsequence and typo matches, to which
+	// how far a match is from the query is added
+	Exact       int `yaml:"exact"`
+	Substring   int `yaml:"substring"`
+	Subsequence int `yaml:"subsequence"`
+	Fuzzy       int `yaml:"fuzzy"`
+}
+
+// DefaultScoring returns the scoring used when the config file doesn't
+// change it
+func DefaultScoring() Scoring {
+	return Scoring{
+		AutoSelectRatio: 0.25,
+		AutoSelectMin:   2,
+		DistanceRatio:   2.0 / 3,
+		DistanceMin:     3,
+		Exact:           0,
+		Substring:       1,
+		Subsequence:     2,
+		Fuzzy:           2,
+	}
+}
+
+// FuzzyScoring returns the scoring of fuzzy matches, the default one unless
+// the config file set another
+func (c *Config) FuzzyScoring() Scoring {
+	if c.Scoring == nil {
+		return DefaultScoring()
+	}
+	return *c.Scoring
+}
+
+// AutoSelectThreshold returns the worst score of a best match for a query
+// of n characters that is taken without asking
+func (s Scoring) AutoSelectThreshold(n int) int {
+	return threshold(s.AutoSelectRatio, s.AutoSelectMin, s.AutoSelectMax, n)
+}
+
+// DistanceThreshold returns the most edits a name may be away from a query
+// of n characters to match as a typo
+func (s Scoring) DistanceThreshold(n int) int {
+	return threshold(s.DistanceRatio, s.DistanceMin, s.DistanceMax, n)
+}
+
+// Validate reports settings that can't be meant
+func (s Scoring) Validate() error {
+	if s.AutoSelectRatio < 0 || s.DistanceRatio < 0 {
+		return fmt.Errorf("fuzzy ratios can't be negative")
+	}
+	if s.AutoSelectMin < 0 || s.AutoSelectMax < 0 || s.DistanceMin < 0 || s.DistanceMax < 0 {
+		return fmt.Errorf("fuzzy minimums and maximums can't be negative")
+	}
+	return nil
+}
+
+// threshold scales n by ratio, rounding down, to at least lo and at most hi
+// (0 for no limit)
+func threshold(ratio float64, lo, hi, n int) int {
+	// The epsilon keeps ratios like 2/3 from rounding 9*2/3 down to 5
+	t := int(math.Floor(ratio*float64(n) + 1e-9))
+	if t < lo {
+		t = lo
+	}
+	if hi > 0 && t > hi {
+		t = hi
+	}
+	return t
+}
@@ -0,0 +1,73 @@
+// Package deps follows the imports of selected source files to the local
+// files they depend on, so copying a handler also brings its types and
+// helpers along
+package deps
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// Match is a file pulled in because a selected file imports it
+type Match struct {
+	Path   string // File to include
+	Import string // Import that resolved to it, as written in the source
+	Depth  int    // 1 for a direct import of a selected file, 2 for theirs, ...
+}
+
+// Follow resolves the local imports of the files in paths, and of the files
+// in directories given there, up to depth levels deep. Each file is
+// returned once, at the shallowest depth it was found, excluding paths
+// themselves.
+func Follow(paths []string, depth int) []Match {
+	if depth <= 0 {
+		return nil
+	}
+	seen := make(map[string]bool)
+	for _, path := range paths {
+		seen[filepath.Clean(path)] = true
+	}
+
+	var goFiles, scriptFiles []string
+	for _, path := range paths {
+		goFiles = append(goFiles, sourceFiles(path, isGo)...)
+		scriptFiles = append(scriptFiles, sourceFiles(path, isScript)...)
+	}
+
+	var result []Match
+	for _, m := range append(followGo(goFiles, depth), followScripts(scriptFiles, depth)...) {
+		if !seen[m.Path] {
+			seen[m.Path] = true
+			result = append(result, m)
+		}
+	}
+	return result
+}
+
+// sourceFiles returns path if it is a file accepted by want, or the files
+// directly inside it accepted by want if it is a directory
+func sourceFiles(path string, want func(string) bool) []string {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil
+	}
+	if !info.IsDir() {
+		if want(path) {
+			return []string{filepath.Clean(path)}
+		}
+		return nil
+	}
+
+	entries, err := os.ReadDir(path)
+	if err != nil {
+		return nil
+	}
+	var files []string
+	for _, entry := range entries {
+		file := filepath.Join(path, entry.Name())
+		if entry.Type().IsRegular() && want(file) {
+			files = append(files, file)
+		}
+	}
+	return files
+}
@@ -0,0 +1,109 @@
+package deps
+
+import (
+	"bufio"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// isGo accepts Go source files other than tests
+func isGo(path string) bool {
+	return strings.HasSuffix(path, ".go") && !strings.HasSuffix(path, "_test.go")
+}
+
+// module is a Go module on disk
+type module struct {
+	root string // Directory holding go.mod
+	path string // Module path declared in go.mod
+}
+
+// followGo resolves imports of packages in the same module as the importing
+// file to the package's source files, breadth first up to depth
+func followGo(files []string, depth int) []Match {
+	modules := make(map[string]*module)
+	visited := make(map[string]bool)
+	var matches []Match
+
+	for level := 1; level <= depth && len(files) > 0; level++ {
+		var next []string
+		for _, file := range files {
+			mod := findModule(filepath.Dir(file), modules)
+			if mod == nil {
+				continue
+			}
+			for _, imp := range goImports(file) {
+				if imp != mod.path && !strings.HasPrefix(imp, mod.path+"/") {
+					continue
+				}
+				dir := filepath.Join(mod.root, filepath.FromSlash(strings.TrimPrefix(imp, mod.path)))
+				if visited[dir] {
+					continue
+				}
+				visited[dir] = true
+				for _, dep := range sourceFiles(dir, isGo) {
+					matches = append(matches, Match{Path: dep, Import: imp, Depth: level})
+					next = append(next, dep)
+				}
+			}
+		}
+		files = next
+	}
+	return matches
+}
+
+// goImports returns the import paths of a Go file
+func goImports(path string) []string {
+	file, err := parser.ParseFile(token.NewFileSet(), path, nil, parser.ImportsOnly)
+	if err != nil {
+		return nil
+	}
+	var imports []string
+	for _, spec := range file.Imports {
+		if imp, err := strconv.Unquote(spec.Path.Value); err == nil {
+			imports = append(imports, imp)
+		}
+	}
+	return imports
+}
+
+// findModule returns the module containing dir, caching lookups per
+// directory
+func findModule(dir string, cache map[string]*module) *module {
+	if mod, ok := cache[dir]; ok {
+		return mod
+	}
+	var mod *module
+	if path := modulePath(filepath.Join(dir, "go.mod")); path != "" {
+		mod = &module{root: dir, path: path}
+	} else if parent := filepath.Dir(dir); parent != dir {
+		mod = findModule(parent, cache)
+	}
+	cache[dir] = mod
+	return mod
+}
+
+// modulePath reads the module directive of a go.mod file
+func modulePath(gomod string) string {
+	file, err := os.Open(gomod)
+	if err != nil {
+		return ""
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if rest, ok := strings.CutPrefix(line, "module"); ok && (rest == "" || rest[0] == ' ' || rest[0] == '\t') {
+			path := strings.TrimSpace(rest)
+			if unquoted, err := strconv.Unquote(path); err == nil {
+				path = unquoted
+			}
+			return path
+		}
+	}
+	return ""
+}
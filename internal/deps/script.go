@@ -0,0 +1,255 @@
+package deps
+
+import (
+	"encoding/json"
+	"fcopy/internal/entrypoints"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// scriptExts are the TypeScript and JavaScript extensions tried, in order,
+// when an import leaves the extension out
+var scriptExts = []string{".ts", ".tsx", ".d.ts", ".js", ".jsx", ".mjs", ".cjs"}
+
+// isScript accepts TypeScript and JavaScript source files
+func isScript(path string) bool {
+	for _, ext := range scriptExts {
+		if strings.HasSuffix(path, ext) {
+			return true
+		}
+	}
+	return strings.HasSuffix(path, ".mts") || strings.HasSuffix(path, ".cts")
+}
+
+// scriptImport matches the module specifiers of import and export ... from
+// statements, side-effect imports, require() and dynamic import()
+var scriptImport = regexp.MustCompile(`(?m)(?:^\s*(?:import|export)\b[^'";]*?\bfrom\s*|^\s*import\s*|\brequire\s*\(\s*|\bimport\s*\(\s*)['"]([^'"\n]+)['"]`)
+
+// tsconfig holds the module resolution settings of a tsconfig.json or
+// jsconfig.json
+type tsconfig struct {
+	dir             string
+	CompilerOptions struct {
+		BaseURL string              `json:"baseUrl"`
+		Paths   map[string][]string `json:"paths"`
+	} `json:"compilerOptions"`
+}
+
+// followScripts resolves relative imports and tsconfig path aliases of
+// TypeScript and JavaScript files to local files, breadth first up to depth.
+// Packages, anything under node_modules and anything outside the project of
+// the importing file are left out.
+func followScripts(files []string, depth int) []Match {
+	configs := make(map[string]*tsconfig)
+	visited := make(map[string]bool)
+	for _, file := range files {
+		visited[file] = true
+	}
+	var matches []Match
+
+	for level := 1; level <= depth && len(files) > 0; level++ {
+		var next []string
+		for _, file := range files {
+			data, err := os.ReadFile(file)
+			if err != nil {
+				continue
+			}
+			config := findTSConfig(filepath.Dir(file), configs)
+			root := scriptRoot(filepath.Dir(file), config)
+			for _, m := range scriptImport.FindAllStringSubmatch(string(data), -1) {
+				dep := resolveScript(m[1], filepath.Dir(file), config)
+				if dep == "" || visited[dep] || !inside(root, dep) {
+					continue
+				}
+				visited[dep] = true
+				matches = append(matches, Match{Path: dep, Import: m[1], Depth: level})
+				next = append(next, dep)
+			}
+		}
+		files = next
+	}
+	return matches
+}
+
+// resolveScript finds the file an import specifier refers to, or "" for
+// packages and specifiers that don't resolve to a local file
+func resolveScript(spec, dir string, config *tsconfig) string {
+	var candidates []string
+	if strings.HasPrefix(spec, "./") || strings.HasPrefix(spec, "../") || spec == "." || spec == ".." {
+		candidates = []string{filepath.Join(dir, filepath.FromSlash(spec))}
+	} else if config != nil {
+		base := filepath.Join(config.dir, filepath.FromSlash(config.CompilerOptions.BaseURL))
+		for pattern, targets := range config.CompilerOptions.Paths {
+			rest, ok := matchAlias(pattern, spec)
+			if !ok {
+				continue
+			}
+			for _, target := range targets {
+				target = strings.Replace(target, "*", rest, 1)
+				candidates = append(candidates, filepath.Join(base, filepath.FromSlash(target)))
+			}
+		}
+		if config.CompilerOptions.BaseURL != "" {
+			candidates = append(candidates, filepath.Join(base, filepath.FromSlash(spec)))
+		}
+	}
+
+	for _, candidate := range candidates {
+		if file := resolveScriptFile(candidate); file != "" && !strings.Contains(filepath.ToSlash(file), "/node_modules/") {
+			return file
+		}
+	}
+	return ""
+}
+
+// scriptRoot returns the directory imports of a file in dir must stay
+// within: its project root, or the directory of its tsconfig.json when that
+// is a project root itself above it, as in a monorepo
+func scriptRoot(dir string, config *tsconfig) string {
+	root := entrypoints.ProjectRoot(dir)
+	if config != nil && inside(config.dir, root) && entrypoints.ProjectRoot(config.dir) == config.dir {
+		root = config.dir
+	}
+	return root
+}
+
+// inside reports whether path is root or below it, once symlinks in both
+// are resolved
+func inside(root, path string) bool {
+	root, err := filepath.EvalSymlinks(root)
+	if err != nil {
+		return false
+	}
+	if path, err = filepath.EvalSymlinks(path); err != nil {
+		return false
+	}
+	rel, err := filepath.Rel(root, path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// matchAlias matches spec against a tsconfig paths pattern with at most one
+// "*", returning what the star stands for
+func matchAlias(pattern, spec string) (string, bool) {
+	prefix, suffix, wildcard := strings.Cut(pattern, "*")
+	if !wildcard {
+		return "", pattern == spec
+	}
+	if len(spec) < len(prefix)+len(suffix) || !strings.HasPrefix(spec, prefix) || !strings.HasSuffix(spec, suffix) {
+		return "", false
+	}
+	return spec[len(prefix) : len(spec)-len(suffix)], true
+}
+
+// resolveScriptFile applies node's resolution to a path: the file itself,
+// the file with a source extension added, or the index file of a directory.
+// ES module imports naming the compiled .js file also find the .ts source.
+// Only script and JSON files are found; imports of other assets, like CSS,
+// are left out.
+func resolveScriptFile(path string) string {
+	if isFile(path) && (isScript(path) || strings.HasSuffix(path, ".json")) {
+		return path
+	}
+	for _, ext := range scriptExts {
+		if isFile(path + ext) {
+			return path + ext
+		}
+	}
+	if js := strings.TrimSuffix(path, ".js"); js != path {
+		for _, ext := range []string{".ts", ".tsx"} {
+			if isFile(js + ext) {
+				return js + ext
+			}
+		}
+	}
+	for _, ext := range scriptExts {
+		if index := filepath.Join(path, "index"+ext); isFile(index) {
+			return index
+		}
+	}
+	return ""
+}
+
+// findTSConfig returns the nearest tsconfig.json or jsconfig.json at or
+// above dir, caching lookups per directory
+func findTSConfig(dir string, cache map[string]*tsconfig) *tsconfig {
+	if config, ok := cache[dir]; ok {
+		return config
+	}
+	var config *tsconfig
+	for _, name := range []string{"tsconfig.json", "jsconfig.json"} {
+		if config = readTSConfig(filepath.Join(dir, name)); config != nil {
+			break
+		}
+	}
+	if config == nil {
+		if parent := filepath.Dir(dir); parent != dir {
+			config = findTSConfig(parent, cache)
+		}
+	}
+	cache[dir] = config
+	return config
+}
+
+// trailingComma matches commas before a closing bracket, allowed in
+// tsconfig.json but not in JSON
+var trailingComma = regexp.MustCompile(`,(\s*[}\]])`)
+
+// readTSConfig parses a tsconfig.json, which may contain comments and
+// trailing commas
+func readTSConfig(path string) *tsconfig {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+	config := &tsconfig{dir: filepath.Dir(path)}
+	clean := trailingComma.ReplaceAllString(stripJSONComments(string(data)), "$1")
+	if err := json.Unmarshal([]byte(clean), config); err != nil {
+		// An unreadable config still marks the project root
+		return &tsconfig{dir: filepath.Dir(path)}
+	}
+	return config
+}
+
+// stripJSONComments removes // and /* */ comments outside of strings
+func stripJSONComments(s string) string {
+	var b strings.Builder
+	inString := false
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case inString:
+			b.WriteByte(c)
+			if c == '\\' && i+1 < len(s) {
+				i++
+				b.WriteByte(s[i])
+			} else if c == '"' {
+				inString = false
+			}
+		case c == '"':
+			inString = true
+			b.WriteByte(c)
+		case strings.HasPrefix(s[i:], "//"):
+			for i < len(s) && s[i] != '\n' {
+				i++
+			}
+			b.WriteByte('\n')
+		case strings.HasPrefix(s[i:], "/*"):
+			end := strings.Index(s[i+2:], "*/")
+			if end < 0 {
+				return b.String()
+			}
+			i += end + 3
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return b.String()
+}
+
+// isFile reports whether path is an existing regular file
+func isFile(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && info.Mode().IsRegular()
+}
@@ -0,0 +1,234 @@
+package diff
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Op is the kind of change an edit applies to a line
+type Op int
+
+const (
+	Equal Op = iota
+	Delete
+	Insert
+)
+
+// Edit is a single line of an edit script
+type Edit struct {
+	Op   Op
+	Line string
+}
+
+// SplitLines splits text into lines without their trailing newlines
+func SplitLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(s, "\n"), "\n")
+}
+
+// Lines returns the shortest edit script turning a into b, computed with
+// Myers' O(ND) difference algorithm
+func Lines(a, b []string) []Edit {
+	n, m := len(a), len(b)
+	max := n + m
+	if max == 0 {
+		return nil
+	}
+
+	// v[k+max] holds the furthest x reached on diagonal k. Before every round
+	// the relevant part of v is saved so that the path can be traced back.
+	v := make([]int, 2*max+2)
+	var trace [][]int
+	found := false
+	for d := 0; d <= max && !found; d++ {
+		snapshot := make([]int, 2*d+1)
+		copy(snapshot, v[max-d:max+d+1])
+		trace = append(trace, snapshot)
+
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && v[max+k-1] < v[max+k+1]) {
+				x = v[max+k+1]
+			} else {
+				x = v[max+k-1] + 1
+			}
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+			v[max+k] = x
+			if x >= n && y >= m {
+				found = true
+				break
+			}
+		}
+	}
+
+	// Walk the trace backwards to recover the edits
+	edits := make([]Edit, 0, max)
+	x, y := n, m
+	for d := len(trace) - 1; d > 0; d-- {
+		snapshot := trace[d]
+		at := func(k int) int { return snapshot[k+d] }
+		k := x - y
+
+		var prevK int
+		if k == -d || (k != d && at(k-1) < at(k+1)) {
+			prevK = k + 1
+		} else {
+			prevK = k - 1
+		}
+		prevX := at(prevK)
+		prevY := prevX - prevK
+
+		for x > prevX && y > prevY {
+			edits = append(edits, Edit{Op: Equal, Line: a[x-1]})
+			x--
+			y--
+		}
+		if x == prevX {
+			edits = append(edits, Edit{Op: Insert, Line: b[y-1]})
+			y--
+		} else {
+			edits = append(edits, Edit{Op: Delete, Line: a[x-1]})
+			x--
+		}
+	}
+	for x > 0 && y > 0 {
+		edits = append(edits, Edit{Op: Equal, Line: a[x-1]})
+		x--
+		y--
+	}
+
+	// Reverse into forward order
+	for i, j := 0, len(edits)-1; i < j; i, j = i+1, j-1 {
+		edits[i], edits[j] = edits[j], edits[i]
+	}
+	return edits
+}
+
+// Similarity returns the fraction of lines shared by a and b, from 0 (nothing
+// in common) to 1 (identical)
+func Similarity(a, b string) float64 {
+	aLines, bLines := SplitLines(a), SplitLines(b)
+	total := len(aLines) + len(bLines)
+	if total == 0 {
+		return 1
+	}
+
+	equal := 0
+	for _, e := range Lines(aLines, bLines) {
+		if e.Op == Equal {
+			equal++
+		}
+	}
+	return float64(2*equal) / float64(total)
+}
+
+// MaxSimilarity returns a cheap upper bound of Similarity(a, b) based on the
+// multiset of lines, useful to skip computing full diffs
+func MaxSimilarity(a, b string) float64 {
+	aLines, bLines := SplitLines(a), SplitLines(b)
+	total := len(aLines) + len(bLines)
+	if total == 0 {
+		return 1
+	}
+
+	counts := make(map[string]int, len(aLines))
+	for _, line := range aLines {
+		counts[line]++
+	}
+	common := 0
+	for _, line := range bLines {
+		if counts[line] > 0 {
+			counts[line]--
+			common++
+		}
+	}
+	return float64(2*common) / float64(total)
+}
+
+// Unified returns a unified diff turning a into b with the given number of
+// context lines around each change. An empty string means no differences.
+func Unified(aName, bName, a, b string, context int) string {
+	edits := Lines(SplitLines(a), SplitLines(b))
+
+	// Group changes whose context would overlap into hunks
+	type hunk struct{ start, end int }
+	var hunks []hunk
+	for i, e := range edits {
+		if e.Op == Equal {
+			continue
+		}
+		start, end := i-context, i+context+1
+		if start < 0 {
+			start = 0
+		}
+		if end > len(edits) {
+			end = len(edits)
+		}
+		if len(hunks) > 0 && start <= hunks[len(hunks)-1].end {
+			hunks[len(hunks)-1].end = end
+		} else {
+			hunks = append(hunks, hunk{start, end})
+		}
+	}
+	if len(hunks) == 0 {
+		return ""
+	}
+
+	var out strings.Builder
+	fmt.Fprintf(&out, "--- %s\n+++ %s\n", aName, bName)
+
+	aLine, bLine, pos := 0, 0, 0
+	for _, h := range hunks {
+		// Advance line counters to the start of the hunk
+		for ; pos < h.start; pos++ {
+			if edits[pos].Op != Insert {
+				aLine++
+			}
+			if edits[pos].Op != Delete {
+				bLine++
+			}
+		}
+
+		var body strings.Builder
+		aLen, bLen := 0, 0
+		for ; pos < h.end; pos++ {
+			e := edits[pos]
+			switch e.Op {
+			case Equal:
+				body.WriteString(" " + e.Line + "\n")
+				aLen++
+				bLen++
+			case Delete:
+				body.WriteString("-" + e.Line + "\n")
+				aLen++
+			case Insert:
+				body.WriteString("+" + e.Line + "\n")
+				bLen++
+			}
+		}
+
+		fmt.Fprintf(&out, "@@ -%s +%s @@\n", hunkRange(aLine, aLen), hunkRange(bLine, bLen))
+		out.WriteString(body.String())
+		aLine += aLen
+		bLine += bLen
+	}
+	return out.String()
+}
+
+// hunkRange formats the start,length pair of a hunk header
+func hunkRange(before, length int) string {
+	start := before + 1
+	if length == 0 {
+		start = before
+	}
+	if length == 1 {
+		return fmt.Sprintf("%d", start)
+	}
+	return fmt.Sprintf("%d,%d", start, length)
+}
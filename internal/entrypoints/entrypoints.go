@@ -0,0 +1,84 @@
+// Package entrypoints finds the files that show how a project starts: its
+// entry points, routing and the build or runtime configuration around them
+package entrypoints
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// Kinds of files Detect looks for, in the order they are returned
+const (
+	KindEntry   = "entry point"
+	KindRouting = "routing"
+	KindConfig  = "project config"
+)
+
+// Match is a file found by Detect
+type Match struct {
+	Path string
+	Kind string
+}
+
+// patterns lists glob patterns, relative to the project root, for each kind
+// of file. Within a kind, earlier patterns are more likely to matter.
+var patterns = []struct {
+	kind  string
+	globs []string
+}{
+	{KindEntry, []string{
+		// Go
+		"main.go", "cmd/*/main.go",
+		// JavaScript and TypeScript
+		"index.ts", "index.js", "src/index.ts", "src/index.tsx", "src/index.js", "src/index.jsx",
+		"src/main.ts", "src/main.tsx", "src/main.js", "src/App.tsx", "src/App.jsx",
+		"server.ts", "server.js", "app.ts", "app.js",
+		"app/layout.tsx", "pages/_app.tsx", "pages/_app.js",
+		// Python
+		"main.py", "app.py", "manage.py", "__main__.py", "*/__main__.py", "wsgi.py", "asgi.py", "*/wsgi.py", "*/asgi.py",
+		// Rust
+		"src/main.rs", "src/lib.rs", "src/bin/*.rs",
+		// C#
+		"Program.cs", "*/Program.cs", "Startup.cs", "*/Startup.cs",
+		// Java and Kotlin
+		"src/main/java/*/*Application.java", "src/main/java/*/*/*Application.java", "src/main/java/*/*/*/*Application.java",
+		"src/main/kotlin/*/*/*Application.kt",
+		// Ruby
+		"config.ru", "bin/rails",
+	}},
+	{KindRouting, []string{
+		"routes.go", "*/routes.go", "router.go", "*/router.go",
+		"src/routes.ts", "src/routes.tsx", "src/router.ts", "src/router.tsx", "src/routes/index.ts", "routes/index.js",
+		"urls.py", "*/urls.py",
+		"config/routes.rb",
+	}},
+	{KindConfig, []string{
+		"go.mod", "package.json", "tsconfig.json", "pyproject.toml", "setup.py", "requirements.txt",
+		"Cargo.toml", "*.csproj", "*.sln", "pom.xml", "build.gradle", "build.gradle.kts", "Gemfile",
+		"next.config.js", "next.config.mjs", "next.config.ts", "vite.config.ts", "vite.config.js",
+		"Dockerfile", "docker-compose.yml", "docker-compose.yaml", "compose.yaml", "Makefile", "Procfile",
+	}},
+}
+
+// Detect returns the entry points, routing and config files under root,
+// entry points first
+func Detect(root string) []Match {
+	var matches []Match
+	seen := make(map[string]bool)
+	for _, group := range patterns {
+		for _, glob := range group.globs {
+			paths, _ := filepath.Glob(filepath.Join(root, glob))
+			for _, path := range paths {
+				if seen[path] {
+					continue
+				}
+				if info, err := os.Stat(path); err != nil || info.IsDir() {
+					continue
+				}
+				seen[path] = true
+				matches = append(matches, Match{Path: path, Kind: group.kind})
+			}
+		}
+	}
+	return matches
+}
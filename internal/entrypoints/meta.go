@@ -0,0 +1,70 @@
+package entrypoints
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// KindMeta marks the project metadata returned by Meta
+const KindMeta = "project metadata"
+
+// metaFiles name the manifests declaring a project's name and dependencies
+var metaFiles = []string{
+	"go.mod", "package.json", "pyproject.toml", "Cargo.toml", "composer.json",
+	"pom.xml", "build.gradle.kts", "build.gradle", "Gemfile", "mix.exs",
+}
+
+// readmeFiles name the top-level README, first match wins
+var readmeFiles = []string{"README.md", "README.rst", "README.txt", "README", "readme.md"}
+
+// MaxReadmeSize is the largest README Meta includes; a long one would cost
+// more context than knowing the project's intent is worth
+const MaxReadmeSize = 16 * 1024
+
+// Meta returns the manifests and the README, if small enough, of the project
+// around dir: the nearest directory at or above it holding a manifest or a
+// .git directory
+func Meta(dir string) []Match {
+	root := ProjectRoot(dir)
+	var matches []Match
+	for _, name := range metaFiles {
+		path := filepath.Join(root, name)
+		if info, err := os.Stat(path); err == nil && info.Mode().IsRegular() {
+			matches = append(matches, Match{Path: path, Kind: KindMeta})
+		}
+	}
+	for _, name := range readmeFiles {
+		path := filepath.Join(root, name)
+		if info, err := os.Stat(path); err == nil && info.Mode().IsRegular() {
+			if info.Size() <= MaxReadmeSize {
+				matches = append(matches, Match{Path: path, Kind: KindMeta})
+			}
+			break
+		}
+	}
+	return matches
+}
+
+// ProjectRoot returns the nearest directory at or above dir that holds a
+// project manifest or a .git directory, or dir itself if there is none
+func ProjectRoot(dir string) string {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return dir
+	}
+	for current := dir; ; {
+		if _, err := os.Stat(filepath.Join(current, ".git")); err == nil {
+			return current
+		}
+		for _, name := range metaFiles {
+			if _, err := os.Stat(filepath.Join(current, name)); err == nil {
+				return current
+			}
+		}
+		parent := filepath.Dir(current)
+		if parent == current {
+			return dir
+		}
+		current = parent
+	}
+}
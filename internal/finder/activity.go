@@ -0,0 +1,37 @@
+package finder
+
+import (
+	"fcopy/internal/gitutil"
+	"fmt"
+)
+
+// breakTies orders the matches among the first --max-matches that score the
+// same by how recently a commit changed them, so the routes file being
+// worked on comes before a legacy copy nobody touched in years
+func (s *Searcher) breakTies(dir string, matches []FuzzyMatch) {
+	if s.cfg.NoGitActivity || len(matches) < 2 {
+		return
+	}
+	shown := min(len(matches), max(s.cfg.MaxMatches, 1))
+	var tied []string
+	for i := range shown {
+		if (i > 0 && matches[i-1].Score == matches[i].Score) || (i+1 < len(matches) && matches[i+1].Score == matches[i].Score) {
+			tied = append(tied, matches[i].Path)
+		}
+	}
+	if len(tied) == 0 {
+		return
+	}
+
+	dates, err := gitutil.LastCommitted(dir, tied)
+	if err != nil {
+		if s.cfg.Verbose {
+			fmt.Printf("Not breaking ties between fuzzy matches by git activity: %v\n", err)
+		}
+		return
+	}
+	for i := range matches[:shown] {
+		matches[i].Committed = dates[matches[i].Path]
+	}
+	sortMatches(matches)
+}
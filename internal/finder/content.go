@@ -0,0 +1,92 @@
+package finder
+
+import (
+	"bytes"
+	"fcopy/internal/config"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+// definitionKeywords introduce declarations in common languages, so the file
+// defining a name ranks above the files that merely use it
+const definitionKeywords = `class|const|def|enum|fn|func|function|interface|let|record|struct|trait|type|var`
+
+// contentMatches looks for targetName in the contents of the files among
+// entries, as ripgrep would, with the case-sensitivity of --case. Files declaring it come before the ones merely
+// mentioning it. Their scores are just above the auto-select
+// threshold, so they are offered but never picked without asking.
+func contentMatches(entries []Entry, targetName string, cfg *config.Config) []FuzzyMatch {
+	if utf8.RuneCountInString(targetName) < 3 || strings.ContainsAny(targetName, `/\`) {
+		return nil
+	}
+	flags := ""
+	if !caseSensitive(targetName, cfg.Case) {
+		flags = "(?i)"
+	}
+	literal := regexp.QuoteMeta(targetName)
+	mention := regexp.MustCompile(flags + literal)
+	definition := regexp.MustCompile(flags + `\b(?:` + definitionKeywords + `)\s+(?:\([^)]*\)\s*)?` + literal + `\b`)
+	base := cfg.FuzzyScoring().AutoSelectThreshold(utf8.RuneCountInString(targetName)) + 1
+
+	var (
+		mu      sync.Mutex
+		matches []FuzzyMatch
+		wg      sync.WaitGroup
+	)
+	files := make(chan Entry)
+	for range max(cfg.Workers, 1) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for entry := range files {
+				data, ok := readText(entry.Path, cfg.MaxFileSize)
+				if !ok {
+					continue
+				}
+				if !mention.Match(data) {
+					continue
+				}
+				score := base + 1
+				if definition.Match(data) {
+					score = base
+				}
+				mu.Lock()
+				matches = append(matches, FuzzyMatch{
+					Path:      entry.Path,
+					Name:      entry.Name,
+					Score:     score,
+					Depth:     entry.Depth,
+					MatchType: "content",
+				})
+				mu.Unlock()
+			}
+		}()
+	}
+	for _, entry := range entries {
+		if !entry.IsDir {
+			files <- entry
+		}
+	}
+	close(files)
+	wg.Wait()
+
+	sortMatches(matches)
+	return matches
+}
+
+// readText reads the file at path if it is at most limit bytes (0 for no
+// limit) and not binary
+func readText(path string, limit int64) ([]byte, bool) {
+	info, err := os.Stat(path)
+	if err != nil || !info.Mode().IsRegular() || (limit > 0 && info.Size() > limit) {
+		return nil, false
+	}
+	data, err := os.ReadFile(path)
+	if err != nil || bytes.IndexByte(data[:min(len(data), 8000)], 0) >= 0 {
+		return nil, false
+	}
+	return data, true
+}
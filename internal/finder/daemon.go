@@ -0,0 +1,283 @@
+package finder
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fcopy/internal/config"
+	"fcopy/internal/entrypoints"
+	"fcopy/internal/index"
+	"fmt"
+	"io/fs"
+	"math"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// daemonRequest asks a daemon for the entries Scan would return for Dir
+// with the given search options
+type daemonRequest struct {
+	Dir      string
+	Depth    int
+	Hidden   bool
+	NoIgnore bool
+}
+
+// daemonResponse carries the entries of a daemonRequest, or why there are
+// none
+type daemonResponse struct {
+	Entries []Entry
+	Error   string
+}
+
+// daemon keeps the index of a project in memory and up to date with
+// filesystem notifications, so listings of watched directories are served
+// without touching the disk
+type daemon struct {
+	index   *index.Index
+	watcher *fsnotify.Watcher
+	cfg     *config.Config
+
+	mu    sync.Mutex
+	fresh map[string]bool // watched directories whose listing is current
+
+	scans *Scheduler
+}
+
+// Scheduler bounds how many scans a daemon runs at once. Interactive scans,
+// the fuzzy searches someone is waiting on, start before any waiting
+// background scan, and background scans leave a slot free for them, so a
+// search stays fast while a deep scan of the whole project is running.
+type Scheduler struct {
+	mu         sync.Mutex
+	cond       *sync.Cond
+	slots      int
+	running    int
+	background int // Background scans running
+	waiting    int // Interactive scans waiting for a slot
+}
+
+// NewScheduler returns a Scheduler running at most slots scans at once, and
+// at least two so background scans can run at all
+func NewScheduler(slots int) *Scheduler {
+	s := &Scheduler{slots: max(slots, 2)}
+	s.cond = sync.NewCond(&s.mu)
+	return s
+}
+
+// Acquire waits for a slot for a scan
+func (s *Scheduler) Acquire(interactive bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if interactive {
+		s.waiting++
+		for s.running >= s.slots {
+			s.cond.Wait()
+		}
+		s.waiting--
+	} else {
+		for s.waiting > 0 || s.running >= s.slots || s.background >= s.slots-1 {
+			s.cond.Wait()
+		}
+		s.background++
+	}
+	s.running++
+}
+
+// Release gives back the slot of a scan started with Acquire
+func (s *Scheduler) Release(interactive bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.running--
+	if !interactive {
+		s.background--
+	}
+	s.cond.Broadcast()
+}
+
+// ServeDaemon implements "fcopy daemon": it indexes the project around dir,
+// watches its directories and answers fuzzy searches of fcopy runs in the
+// same project over a unix socket until ctx is done
+func ServeDaemon(ctx context.Context, dir string, cfg *config.Config) error {
+	x, err := index.Open(entrypoints.ProjectRoot(dir))
+	if err != nil {
+		return err
+	}
+	socket, err := index.SocketPath(x.Root)
+	if err != nil {
+		return err
+	}
+	if conn, err := net.Dial("unix", socket); err == nil {
+		conn.Close()
+		return fmt.Errorf("a daemon is already running for %s", x.Root)
+	}
+	os.MkdirAll(filepath.Dir(socket), 0755)
+	os.Remove(socket)
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+	defer watcher.Close()
+	d := &daemon{index: x, watcher: watcher, cfg: cfg, fresh: make(map[string]bool), scans: NewScheduler(cfg.Workers)}
+	go d.watch()
+
+	// Warm up with the whole project, as "fcopy index" would
+	unlimited := *cfg
+	unlimited.SearchDepth = math.MaxInt
+	count := len(scan(x.Root, 0, &unlimited, d.readDir))
+	if err := x.Save(); err != nil {
+		fmt.Printf("Error saving the fuzzy search index: %v\n", err)
+	}
+
+	listener, err := net.Listen("unix", socket)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(socket)
+	go func() {
+		<-ctx.Done()
+		listener.Close()
+	}()
+
+	d.mu.Lock()
+	watched := len(d.fresh)
+	d.mu.Unlock()
+	fmt.Printf("Watching %d directories (%d files and directories) under %s\n", watched, count, x.Root)
+	fmt.Println("Fuzzy searches in this project now use the daemon; press Ctrl+C to stop it")
+
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			if ctx.Err() != nil {
+				return x.Save()
+			}
+			return err
+		}
+		go d.serve(conn)
+	}
+}
+
+// serve answers one request on conn
+func (d *daemon) serve(conn net.Conn) {
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(time.Minute))
+
+	var req daemonRequest
+	if err := json.NewDecoder(conn).Decode(&req); err != nil {
+		json.NewEncoder(conn).Encode(daemonResponse{Error: err.Error()})
+		return
+	}
+
+	// Scans deeper than the daemon's own --depth are background work, such
+	// as listing the whole project, rather than a search someone waits on
+	interactive := req.Depth <= d.cfg.SearchDepth
+	d.scans.Acquire(interactive)
+	defer d.scans.Release(interactive)
+
+	cfg := *d.cfg
+	cfg.SearchDepth, cfg.SearchHidden, cfg.NoIgnore = req.Depth, req.Hidden, req.NoIgnore
+	json.NewEncoder(conn).Encode(daemonResponse{Entries: scan(req.Dir, 0, &cfg, d.readDir)})
+}
+
+// readDir lists dir from memory while it is watched and nothing changed in
+// it, and otherwise through the index, starting to watch it
+func (d *daemon) readDir(dir string) ([]fs.DirEntry, error) {
+	d.mu.Lock()
+	fresh := d.fresh[dir]
+	d.mu.Unlock()
+	if fresh {
+		if list, ok := d.index.Cached(dir); ok {
+			return list, nil
+		}
+	}
+
+	// Watch before reading, so changes made while reading aren't missed
+	if !fresh {
+		if err := d.watcher.Add(dir); err != nil {
+			if d.cfg.Verbose {
+				fmt.Printf("Not watching %s: %v\n", dir, err)
+			}
+			return d.index.ReadDir(dir)
+		}
+	}
+	d.mu.Lock()
+	d.fresh[dir] = true
+	d.mu.Unlock()
+	return d.index.ReadDir(dir)
+}
+
+// watch marks directories whose entries change as stale until they are read
+// again
+func (d *daemon) watch() {
+	for {
+		select {
+		case event, ok := <-d.watcher.Events:
+			if !ok {
+				return
+			}
+			if event.Has(fsnotify.Write) || event.Has(fsnotify.Chmod) {
+				continue
+			}
+			d.mu.Lock()
+			delete(d.fresh, filepath.Dir(event.Name))
+			if event.Has(fsnotify.Remove) || event.Has(fsnotify.Rename) {
+				delete(d.fresh, event.Name)
+			}
+			d.mu.Unlock()
+		case err, ok := <-d.watcher.Errors:
+			if !ok {
+				return
+			}
+			// Events may have been dropped, so nothing can be trusted
+			if d.cfg.Verbose {
+				fmt.Printf("Watching failed: %v\n", err)
+			}
+			d.mu.Lock()
+			clear(d.fresh)
+			d.mu.Unlock()
+		}
+	}
+}
+
+// queryDaemon asks the daemon of the project at root, if one is running, for
+// the entries under dir
+func queryDaemon(root, dir string, cfg *config.Config) ([]Entry, error) {
+	socket, err := index.SocketPath(root)
+	if err != nil {
+		return nil, err
+	}
+	conn, err := net.DialTimeout("unix", socket, 100*time.Millisecond)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(time.Minute))
+
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+	req := daemonRequest{Dir: abs, Depth: cfg.SearchDepth, Hidden: cfg.SearchHidden, NoIgnore: cfg.NoIgnore}
+	if err := json.NewEncoder(conn).Encode(req); err != nil {
+		return nil, err
+	}
+	var resp daemonResponse
+	if err := json.NewDecoder(conn).Decode(&resp); err != nil {
+		return nil, err
+	}
+	if resp.Error != "" {
+		return nil, errors.New(resp.Error)
+	}
+
+	// Paths were made absolute for the daemon; report them as given
+	for i := range resp.Entries {
+		resp.Entries[i].Path = filepath.Join(dir, filepath.FromSlash(resp.Entries[i].Rel))
+	}
+	return resp.Entries, nil
+}
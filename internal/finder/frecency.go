@@ -0,0 +1,59 @@
+package finder
+
+import (
+	"fcopy/internal/entrypoints"
+	"fcopy/internal/frecency"
+	"fmt"
+	"time"
+)
+
+// maxFrecencyBoost is the most a history of picks can lower a match's score:
+// enough for a habitual pick to beat an exact match, not to make a poor
+// match win
+const maxFrecencyBoost = 5
+
+// boostFrecent lowers the scores of matches picked before in proportion to
+// their frecency and re-sorts them
+func boostFrecent(matches []FuzzyMatch, history *frecency.History, now time.Time) {
+	for i := range matches {
+		matches[i].Score -= min(maxFrecencyBoost, int(history.Score(matches[i].Path, now)))
+	}
+	sortMatches(matches)
+}
+
+// history returns the picks made in the project around dir, or nil when
+// frecency is turned off or the history can't be located
+func (s *Searcher) history(dir string) *frecency.History {
+	if s.cfg.NoFrecency {
+		return nil
+	}
+	root := entrypoints.ProjectRoot(dir)
+	if h, ok := s.histories[root]; ok {
+		return h
+	}
+	h, err := frecency.Open(root)
+	if err != nil {
+		if s.cfg.Verbose {
+			fmt.Printf("Not ranking fuzzy matches by frecency: %v\n", err)
+		}
+		return nil
+	}
+	s.histories[root] = h
+	return h
+}
+
+// remember records path as picked in history, if there is one
+func (s *Searcher) remember(history *frecency.History, path string) {
+	if history == nil {
+		return
+	}
+	history.Record(path, time.Now())
+	s.save(history)
+}
+
+// save writes history back to the cache directory
+func (s *Searcher) save(history *frecency.History) {
+	if err := history.Save(); err != nil && s.cfg.Verbose {
+		fmt.Printf("Error saving fuzzy match history: %v\n", err)
+	}
+}
@@ -2,13 +2,29 @@ package finder
 
 import (
 	"bufio"
+	"errors"
 	"fcopy/internal/config"
+	"fcopy/internal/entrypoints"
+	"fcopy/internal/frecency"
+	"fcopy/internal/ignore"
+	"fcopy/internal/picker"
 	"fcopy/internal/utils"
 	"fmt"
+	"io"
 	"os"
+	"path"
 	"path/filepath"
+	"regexp"
+	"slices"
 	"sort"
+	"strconv"
 	"strings"
+	"sync"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/mattn/go-isatty"
 )
 
 // FuzzyMatch represents a potential path match with a similarity score
@@ -17,82 +33,118 @@ type FuzzyMatch struct {
 	Name      string
 	Score     int
 	IsDir     bool
-	Depth     int    // Directory depth from search root
-	MatchType string // Full or partial match type
+	Depth     int       // Directory depth from search root
+	MatchType string    // Full or partial match type
+	Committed time.Time // Last commit changing it, only looked up to break ties
 }
 
 // ShouldIgnore checks if a path should be ignored during fuzzy search
 func ShouldIgnore(path string, isDir bool, cfg *config.Config) bool {
+	return IgnoreReason(path, isDir, cfg) != ""
+}
+
+// IgnoreReason returns the rule that makes ShouldIgnore skip path, or "" if
+// path isn't ignored
+func IgnoreReason(path string, isDir bool, cfg *config.Config) string {
+	// fcopy's own files are skipped even with --no-ignore
+	fileName := filepath.Base(path)
+	if config.ArtifactNames[fileName] {
+		return "fcopy's own artifact"
+	}
+
 	// Don't skip anything if --no-ignore flag is set
 	if cfg.NoIgnore {
-		return false
+		return ""
 	}
 
 	// Check if it's a hidden file/directory and we're not including hidden files
-	fileName := filepath.Base(path)
 	if !cfg.SearchHidden && len(fileName) > 1 && fileName[0] == '.' {
-		return true
+		return "hidden file (use --hidden to include it)"
 	}
 
 	// Check if directory should be ignored
 	if isDir {
-		return config.IgnoreDirs[fileName]
+		if config.IgnoreDirs[fileName] {
+			return fmt.Sprintf("ignored directory name %q (use --no-ignore to include it)", fileName)
+		}
+		return ""
 	}
 
 	// Check file extensions to ignore
 	ext := filepath.Ext(fileName)
 	if config.IgnoreExts[ext] {
-		return true
+		return fmt.Sprintf("ignored extension %q (use --no-ignore to include it)", ext)
 	}
 
 	// Check for specific filename patterns
 	for pattern := range config.IgnoreExts {
 		if strings.HasSuffix(fileName, pattern) {
-			return true
+			return fmt.Sprintf("ignored name suffix %q (use --no-ignore to include it)", pattern)
 		}
 	}
 
-	return false
+	return ""
 }
 
-// FuzzyFindPath attempts to find a file or directory based on an approximate name
-func FuzzyFindPath(approximatePath string, cfg *config.Config) (string, bool) {
-	// Get the directory to search in and the target name
-	dir := "."
-	targetName := approximatePath
-
-	// If the path contains a directory separator, split it
-	if strings.Contains(approximatePath, string(os.PathSeparator)) {
-		dir = filepath.Dir(approximatePath)
-		targetName = filepath.Base(approximatePath)
-
-		// Make sure the directory exists
-		if _, err := os.Stat(dir); os.IsNotExist(err) {
-			// If the directory doesn't exist, search for it first
-			resolvedDir, found := FuzzyFindPath(dir, cfg)
-			if !found {
-				fmt.Printf("Cannot find directory: %s\n", dir)
-				return "", false
+// Pickers are the ways FindPath can let the user choose among ambiguous
+// matches
+var Pickers = []string{"prompt", "tui", "fzf"}
+
+// FindPath attempts to find a file or directory based on an approximate
+// name. The search starts in the deepest directory of approximatePath that
+// exists, or the project root for a bare name; when the rest still contains
+// a path separator, such as "proc/proc", it is matched against whole paths
+// relative to that directory, so same-named files in different directories
+// can be told apart. Several matches can be picked at once, such as a file
+// and its test. The paths picked for a query are reused the next time it is
+// given in the same project, unless cfg.NoQueryCache is set.
+func (s *Searcher) FindPath(approximatePath string) ([]string, bool) {
+	dir, targetName := s.searchDir(approximatePath)
+	history := s.history(dir)
+	query := filepath.Join(dir, targetName)
+	if !caseSensitive(targetName, s.cfg.Case) {
+		query = filepath.Join(dir, strings.ToLower(targetName))
+	}
+
+	// Earlier picks may not satisfy --only-files and the like
+	if history != nil && !s.cfg.NoQueryCache && !restricted(s.cfg) {
+		if picks, ok := history.Recall(query); ok {
+			// Picks are relative to the project root, which is up from dir
+			abs, _ := filepath.Abs(dir)
+			up, _ := filepath.Rel(abs, history.Root)
+			paths := make([]string, len(picks))
+			for i, pick := range picks {
+				paths[i] = filepath.Join(dir, up, filepath.FromSlash(pick))
+				s.remember(history, paths[i])
+			}
+			if !s.cfg.Quiet {
+				fmt.Printf("Resolved '%s' to %s as before (--no-query-cache to search again)\n", approximatePath, strings.Join(paths, ", "))
 			}
-			dir = resolvedDir
+			return paths, true
 		}
 	}
 
-	// Find potential matches recursively
-	matches := FindRecursiveMatches(dir, targetName, 0, cfg)
-
-	if len(matches) == 0 {
-		fmt.Printf("No matches found for '%s' anywhere in '%s'\n", targetName, dir)
-		return "", false
+	paths, ok := s.find(approximatePath)
+	if ok && history != nil {
+		history.Resolved(query, paths)
+		s.save(history)
 	}
+	return paths, ok
+}
 
-	// Sort matches by score first, then by depth
-	sort.Slice(matches, func(i, j int) bool {
-		if matches[i].Score != matches[j].Score {
-			return matches[i].Score < matches[j].Score // Lower score (more similar) is better
+// find resolves approximatePath for FindPath, asking the user if needed
+func (s *Searcher) find(approximatePath string) ([]string, bool) {
+	cfg := s.cfg
+	dir, targetName := s.searchDir(approximatePath)
+	matches := s.Candidates(approximatePath)
+	history := s.history(dir)
+
+	if len(matches) == 0 {
+		if !s.cfg.Quiet {
+			fmt.Printf("No matches found for '%s' anywhere in '%s'\n", targetName, dir)
 		}
-		return matches[i].Depth < matches[j].Depth // Lower depth (closer to search root) is better
-	})
+		return nil, false
+	}
 
 	// Limit the number of matches to display
 	displayCount := len(matches)
@@ -104,15 +156,44 @@ func FuzzyFindPath(approximatePath string, cfg *config.Config) (string, bool) {
 	if cfg.AutoSelect && len(matches) > 0 {
 		bestMatch := matches[0]
 		// Only auto-select if the score is very good (threshold depends on name length)
-		threshold := len(targetName) / 4
-		if threshold < 2 {
-			threshold = 2
+		if bestMatch.Score <= cfg.FuzzyScoring().AutoSelectThreshold(utf8.RuneCountInString(targetName)) {
+			if !cfg.Quiet {
+				fmt.Printf("Auto-selected best match for '%s': %s\n", approximatePath, bestMatch.Path)
+			}
+			s.remember(history, bestMatch.Path)
+			return []string{bestMatch.Path}, true
+		}
+	}
+
+	if cfg.NonInteractive {
+		return s.resolve(approximatePath, matches, history)
+	}
+
+	// Nobody can answer a prompt on a pipe, so take a good enough best match
+	// as --auto would, and give up otherwise
+	if !isTerminal(os.Stdin) {
+		bestMatch := matches[0]
+		if bestMatch.Score > cfg.FuzzyScoring().AutoSelectThreshold(utf8.RuneCountInString(targetName)) {
+			fmt.Fprintf(os.Stderr, "'%s' is ambiguous and stdin isn't a terminal to ask; candidates:\n", approximatePath)
+			listCandidates(os.Stderr, matches, cfg.MaxMatches)
+			return nil, false
 		}
+		if !cfg.Quiet {
+			fmt.Printf("Auto-selected best match for '%s' as stdin isn't a terminal: %s\n", approximatePath, bestMatch.Path)
+		}
+		if cfg.Logger != nil {
+			cfg.Logger.Printf("Auto-selected %s for %s (score %d) as stdin isn't a terminal", bestMatch.Path, approximatePath, bestMatch.Score)
+		}
+		s.remember(history, bestMatch.Path)
+		return []string{bestMatch.Path}, true
+	}
 
-		if bestMatch.Score <= threshold {
-			fmt.Printf("Auto-selected best match for '%s': %s\n", approximatePath, bestMatch.Path)
-			return bestMatch.Path, true
+	if cfg.Picker != "prompt" {
+		paths, ok, err := s.pick(approximatePath, matches, history)
+		if err == nil {
+			return paths, ok
 		}
+		fmt.Printf("Error running the picker: %v\n", err)
 	}
 
 	// Display matches to user
@@ -131,126 +212,531 @@ func FuzzyFindPath(approximatePath string, cfg *config.Config) (string, bool) {
 	// Get user selection
 	reader := bufio.NewReader(os.Stdin)
 	for {
-		fmt.Print("Enter selection (0-", displayCount, "): ")
+		fmt.Print("Enter selection (0-", displayCount, ", or several like 1,3): ")
 		input, err := reader.ReadString('\n')
 		if err != nil {
 			fmt.Println("Error reading input:", err)
-			return "", false
+			return nil, false
 		}
 
-		input = strings.TrimSpace(input)
-		var selection int
-		_, err = fmt.Sscanf(input, "%d", &selection)
-
-		if err != nil || selection < 0 || selection > displayCount {
+		selections, ok := ParseSelection(input, displayCount)
+		if !ok {
 			fmt.Println("Invalid selection. Please try again.")
 			continue
 		}
 
-		if selection == 0 {
-			return "", false
+		if len(selections) == 0 {
+			return nil, false
 		}
 
-		return matches[selection-1].Path, true
+		paths := make([]string, len(selections))
+		for i, selection := range selections {
+			paths[i] = matches[selection-1].Path
+			s.remember(history, paths[i])
+		}
+		return paths, true
 	}
 }
 
-// FindRecursiveMatches finds all potential matches for targetName in dir and its subdirectories
-func FindRecursiveMatches(dir, targetName string, currentDepth int, cfg *config.Config) []FuzzyMatch {
-	// Check if we've exceeded max search depth
-	if currentDepth > cfg.SearchDepth {
-		return nil
+// Candidates returns the matches FindPath would offer for approximatePath,
+// best first, without asking which one is meant. With cfg.SearchContent,
+// files containing the query are offered too when no name matches well.
+func (s *Searcher) Candidates(approximatePath string) []FuzzyMatch {
+	dir, targetName := s.searchDir(approximatePath)
+
+	// Find potential matches recursively, favoring the ones picked often and
+	// recently
+	matches := s.Matches(dir, targetName)
+	if history := s.history(dir); history != nil {
+		boostFrecent(matches, history, time.Now())
 	}
+	s.breakTies(dir, matches)
+
+	// Names like "RateLimiter" are often found inside a file, such as
+	// limits.go, rather than in its name
+	threshold := s.cfg.FuzzyScoring().AutoSelectThreshold(utf8.RuneCountInString(targetName))
+	if s.cfg.SearchContent && (len(matches) == 0 || matches[0].Score > threshold) {
+		found := make(map[string]bool, len(matches))
+		for _, m := range matches {
+			found[m.Path] = true
+		}
+		for _, m := range contentMatches(restrict(s.scans[filepath.Clean(dir)], s.cfg), targetName, s.cfg) {
+			if !found[m.Path] {
+				matches = append(matches, m)
+			}
+		}
+		sortMatches(matches)
+	}
+	return matches
+}
 
-	var matches []FuzzyMatch
-
-	// Get all entries in the current directory
-	entries, err := os.ReadDir(dir)
-	if err != nil {
-		if cfg.Verbose {
-			fmt.Printf("Error reading directory %s: %v\n", dir, err)
+// resolve picks among matches without asking, as --first, --select or
+// --strict say. Under --strict a match is only taken when it is the only one,
+// or the only exact one; otherwise the candidates are listed on stderr.
+func (s *Searcher) resolve(approximatePath string, matches []FuzzyMatch, history *frecency.History) ([]string, bool) {
+	cfg := s.cfg
+	var match FuzzyMatch
+	switch {
+	case cfg.PickFirst:
+		match = matches[0]
+	case cfg.Select > 0:
+		if cfg.Select > len(matches) {
+			fmt.Fprintf(os.Stderr, "--select %d is out of range for '%s': only %d candidates\n", cfg.Select, approximatePath, len(matches))
+			listCandidates(os.Stderr, matches, cfg.MaxMatches)
+			return nil, false
 		}
-		return nil
+		match = matches[cfg.Select-1]
+	case len(matches) == 1:
+		match = matches[0]
+	default:
+		var exact []FuzzyMatch
+		for _, m := range matches {
+			if m.MatchType == "exact" {
+				exact = append(exact, m)
+			}
+		}
+		if len(exact) == 1 {
+			match = exact[0]
+			break
+		}
+		fmt.Fprintf(os.Stderr, "'%s' is ambiguous; candidates:\n", approximatePath)
+		listCandidates(os.Stderr, matches, cfg.MaxMatches)
+		return nil, false
 	}
 
-	targetLower := strings.ToLower(targetName)
+	if !s.cfg.Quiet {
+		fmt.Printf("Selected match for '%s': %s\n", approximatePath, match.Path)
+	}
+	s.remember(history, match.Path)
+	return []string{match.Path}, true
+}
 
-	// First, check for direct matches in this directory
-	for _, entry := range entries {
-		name := entry.Name()
-		path := filepath.Join(dir, name)
+// listCandidates writes the first limit matches to w, numbered as --select
+// refers to them
+func listCandidates(w io.Writer, matches []FuzzyMatch, limit int) {
+	for i, match := range matches[:min(limit, len(matches))] {
+		fmt.Fprintf(w, "[%d] %s (score: %d)\n", i+1, match.Path, match.Score)
+	}
+	if len(matches) > limit {
+		fmt.Fprintf(w, "... and %d more\n", len(matches)-limit)
+	}
+}
 
-		// Skip if this path should be ignored
-		if ShouldIgnore(path, entry.IsDir(), cfg) {
+// ParseSelection parses the answer to FindPath's prompt: comma-separated
+// numbers from 1 to count, or 0 for none. Each number is returned once, in
+// the order given.
+func ParseSelection(input string, count int) ([]int, bool) {
+	var selections []int
+	for _, field := range strings.Split(input, ",") {
+		field = strings.TrimSpace(field)
+		if field == "" {
 			continue
 		}
+		selection, err := strconv.Atoi(field)
+		if err != nil || selection < 0 || selection > count {
+			return nil, false
+		}
+		if selection != 0 && !slices.Contains(selections, selection) {
+			selections = append(selections, selection)
+		}
+	}
+	return selections, len(selections) > 0 || strings.TrimSpace(input) == "0"
+}
 
-		nameLower := strings.ToLower(name)
-
-		// Exact match is best
-		if nameLower == targetLower {
-			matches = append(matches, FuzzyMatch{
-				Path:      path,
-				Name:      name,
-				Score:     0, // Perfect match
-				IsDir:     entry.IsDir(),
-				Depth:     currentDepth,
-				MatchType: "exact",
-			})
-			continue
+// pick lets the user choose among matches with fzf or the full-screen
+// picker, which list all of them rather than the first cfg.MaxMatches. The
+// full-screen picker is used when fzf isn't installed.
+func (s *Searcher) pick(approximatePath string, matches []FuzzyMatch, history *frecency.History) ([]string, bool, error) {
+	candidates := make([]picker.Candidate, len(matches))
+	for i, match := range matches {
+		candidates[i] = picker.Candidate{
+			Path:  match.Path,
+			IsDir: match.IsDir,
+			Note:  fmt.Sprintf("(score: %d)", match.Score),
 		}
+	}
+	opts := picker.Options{
+		Title:  fmt.Sprintf("'%s' not found ", approximatePath),
+		Multi:  true,
+		Filter: pickerFilter,
+		Out:    os.Stderr,
+	}
 
-		// Check for substring match
-		if strings.Contains(nameLower, targetLower) || strings.Contains(targetLower, nameLower) {
-			// Calculate how close this substring match is
-			scoreFactor := utils.Abs(len(nameLower) - len(targetLower))
-			matches = append(matches, FuzzyMatch{
-				Path:      path,
-				Name:      name,
-				Score:     1 + scoreFactor, // Good match but not exact
-				IsDir:     entry.IsDir(),
-				Depth:     currentDepth,
-				MatchType: "substring",
-			})
-			continue
+	var chosen []int
+	var err error
+	if s.cfg.Picker == "fzf" {
+		chosen, err = picker.Fzf(candidates, opts)
+		if errors.Is(err, picker.ErrNoFzf) {
+			if s.cfg.Verbose {
+				fmt.Println("fzf isn't installed; using the built-in picker")
+			}
+			chosen, err = picker.Pick(candidates, opts)
 		}
+	} else {
+		chosen, err = picker.Pick(candidates, opts)
+	}
+	if err != nil || len(chosen) == 0 {
+		return nil, false, err
+	}
+	paths := make([]string, len(chosen))
+	for i, c := range chosen {
+		paths[i] = matches[c].Path
+		s.remember(history, paths[i])
+	}
+	return paths, true, nil
+}
+
+// pickerFilter narrows the picker's list to the paths the typed query is a
+// subsequence of, best first
+func pickerFilter(query, path string) (int, bool) {
+	score, ok := SubsequenceScore(strings.ToLower(query), filepath.ToSlash(path))
+	return -score, ok
+}
 
-		// Calculate Levenshtein distance for fuzzy match
-		score := utils.CalculateSimilarity(nameLower, targetLower)
+// isTerminal reports whether f is an interactive terminal. Character
+// devices such as /dev/null are not.
+func isTerminal(f *os.File) bool {
+	return isatty.IsTerminal(f.Fd()) || isatty.IsCygwinTerminal(f.Fd())
+}
+
+// searchDir returns the directory to search for approximatePath and the
+// rest of it to look for there. Names typed without a directory are looked
+// for from the project root, unless cfg.NoRoot is set, so they are found
+// alike anywhere in the project.
+func (s *Searcher) searchDir(approximatePath string) (dir, rest string) {
+	dir, rest = existingParent(approximatePath)
+	if s.cfg.NoRoot || (dir != "." && (s.cfg.Cwd == "" || dir != utils.ExpandPath(s.cfg.Cwd, ""))) {
+		return dir, rest
+	}
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return dir, rest
+	}
+	root := entrypoints.ProjectRoot(abs)
+	up, err := filepath.Rel(abs, root)
+	if err != nil {
+		return dir, rest
+	}
+	return filepath.Join(dir, up), rest
+}
 
-		// Add to matches if the similarity score is above a threshold
-		threshold := len(targetName) * 2 / 3
-		if threshold < 3 {
-			threshold = 3
+// existingParent splits path into its deepest existing directory, "." if
+// none, and the rest of it
+func existingParent(path string) (dir, rest string) {
+	dir, rest = filepath.Dir(path), filepath.Base(path)
+	for dir != "." && dir != filepath.Dir(dir) {
+		if info, err := os.Stat(dir); err == nil && info.IsDir() {
+			return dir, rest
 		}
+		rest = filepath.Join(filepath.Base(dir), rest)
+		dir = filepath.Dir(dir)
+	}
+	if info, err := os.Stat(dir); err == nil && info.IsDir() {
+		return dir, rest
+	}
+	return ".", path
+}
+
+// FindRecursiveMatches finds all potential matches for targetName in dir and
+// its subdirectories, best match first. Names are compared with targetName,
+// or paths relative to dir when targetName contains a path separator. Paths
+// excluded by .gitignore or .fcopyignore files aren't candidates unless
+// cfg.NoIgnore is set.
+func FindRecursiveMatches(dir, targetName string, currentDepth int, cfg *config.Config) []FuzzyMatch {
+	return matchEntries(Scan(dir, currentDepth, cfg), targetName, cfg)
+}
 
-		if score <= threshold {
-			matches = append(matches, FuzzyMatch{
-				Path:      path,
-				Name:      name,
-				Score:     score + 2, // Fuzzy match (less weight than substring)
-				IsDir:     entry.IsDir(),
-				Depth:     currentDepth,
-				MatchType: "fuzzy",
-			})
+// matchEntries scores entries against targetName in workers goroutines and
+// returns the ones that match, best first
+func matchEntries(entries []Entry, targetName string, cfg *config.Config) []FuzzyMatch {
+	entries = restrict(entries, cfg)
+	q := parseQuery(targetName, caseSensitive(targetName, cfg.Case))
+	q.weights = cfg.FuzzyScoring()
+
+	chunks := make([][]FuzzyMatch, max(cfg.Workers, 1))
+	size := (len(entries) + len(chunks) - 1) / len(chunks)
+	var wg sync.WaitGroup
+	for i := range chunks {
+		start, end := min(i*size, len(entries)), min((i+1)*size, len(entries))
+		wg.Add(1)
+		go func(i int, entries []Entry) {
+			defer wg.Done()
+			for _, entry := range entries {
+				if m, ok := matchEntry(entry, q); ok {
+					chunks[i] = append(chunks[i], m)
+				}
+			}
+		}(i, entries[start:end])
+	}
+	wg.Wait()
+
+	var matches []FuzzyMatch
+	for _, chunk := range chunks {
+		matches = append(matches, chunk...)
+	}
+	sortMatches(matches)
+	return matches
+}
+
+// restricted reports whether cfg leaves some entries out of fuzzy matching
+func restricted(cfg *config.Config) bool {
+	return cfg.OnlyFiles || cfg.OnlyDirs || len(cfg.FindExclude) > 0
+}
+
+// restrict drops the entries that --only-files, --only-dirs and
+// --find-exclude leave out of fuzzy matching. Exclude globs follow gitignore
+// rules: the last one matching decides, and "!" brings a path back.
+func restrict(entries []Entry, cfg *config.Config) []Entry {
+	if !restricted(cfg) {
+		return entries
+	}
+	var patterns []ignore.Pattern
+	for _, glob := range cfg.FindExclude {
+		if p, ok := ignore.ParsePattern(glob); ok {
+			patterns = append(patterns, p)
 		}
 	}
 
-	// Now recursively check subdirectories
+	var kept []Entry
 	for _, entry := range entries {
-		if entry.IsDir() {
-			subdir := filepath.Join(dir, entry.Name())
+		if (cfg.OnlyFiles && entry.IsDir) || (cfg.OnlyDirs && !entry.IsDir) {
+			continue
+		}
+		excluded := false
+		for _, p := range patterns {
+			if p.MatchPrefix(entry.Rel, entry.IsDir) {
+				excluded = !p.Negate
+			}
+		}
+		if !excluded {
+			kept = append(kept, entry)
+		}
+	}
+	return kept
+}
+
+// query is a parsed fuzzy search
+type query struct {
+	target    string   // Slash-separated, and lowercased unless sensitive
+	byPath    bool     // target contains a path separator
+	terms     []string // Space-separated terms when there are several
+	ext       string   // Lowercased extension, with its dot, that matching files must have
+	sensitive bool     // Names are compared with target as they are
+
+	weights config.Scoring
+}
+
+// CaseModes are the values of --case: smart compares case only when the
+// query has upper case letters, as ripgrep and fzf do
+var CaseModes = []string{"smart", "sensitive", "insensitive"}
+
+// caseSensitive reports whether targetName is matched case-sensitively
+// under mode, one of CaseModes ("" counts as smart)
+func caseSensitive(targetName, mode string) bool {
+	switch mode {
+	case "sensitive":
+		return true
+	case "insensitive":
+		return false
+	}
+	return strings.ContainsFunc(targetName, unicode.IsUpper)
+}
 
-			// Skip ignored directories
-			if ShouldIgnore(subdir, true, cfg) {
-				continue
+// fold lowercases s unless q is case-sensitive
+func (q query) fold(s string) string {
+	if q.sensitive {
+		return s
+	}
+	return strings.ToLower(s)
+}
+
+// extHint matches an extension given as "handler:go"
+var extHint = regexp.MustCompile(`^(.+):([[:alnum:]]+)$`)
+
+// parseQuery parses targetName as typed by the user. A last term starting
+// with a dot, as in "handler .go", or a ":ext" suffix, as in "handler:go",
+// restricts matches to files with that extension, whose case never matters.
+// Unless sensitive, case is ignored in the rest too.
+func parseQuery(targetName string, sensitive bool) query {
+	q := query{target: filepath.ToSlash(targetName), sensitive: sensitive}
+	q.target = q.fold(q.target)
+
+	terms := strings.Fields(q.target)
+	if last := len(terms) - 1; last > 0 && len(terms[last]) > 1 && strings.HasPrefix(terms[last], ".") && !strings.Contains(terms[last], "/") {
+		q.ext = strings.ToLower(terms[last])
+		terms = terms[:last]
+		q.target = strings.Join(terms, " ")
+	} else if m := extHint.FindStringSubmatch(q.target); m != nil {
+		q.ext = "." + strings.ToLower(m[2])
+		q.target = m[1]
+		terms = strings.Fields(q.target)
+	}
+
+	q.byPath = strings.Contains(q.target, "/")
+	if len(terms) > 1 {
+		q.terms = terms
+	}
+	return q
+}
+
+// matchEntry scores one entry against q
+func matchEntry(entry Entry, q query) (FuzzyMatch, bool) {
+	target, w := q.target, q.weights
+	name, rel := entry.Name, entry.Rel
+
+	// With an extension hint only files count, and they are compared without
+	// the extension, so "handler:go" matches handler.go exactly
+	if q.ext != "" {
+		if entry.IsDir || len(name) <= len(q.ext) || strings.ToLower(name[len(name)-len(q.ext):]) != q.ext {
+			return FuzzyMatch{}, false
+		}
+		name, rel = name[:len(name)-len(q.ext)], rel[:len(rel)-len(q.ext)]
+	}
+
+	var score int
+	var matchType string
+	var ok bool
+	if q.terms != nil {
+		score, ok = scoreTerms(q.terms, rel, q)
+		matchType = "terms"
+	} else if q.byPath {
+		score, matchType, ok = scorePath(target, rel, q)
+	} else {
+		score, matchType, ok = scoreName(target, name, q)
+		// Abbreviations spanning directories, such as "icfg" for
+		// "internal/config", only match the whole path. Ones matching within
+		// the parent directory already match that directory, and everything
+		// inside it shouldn't rank above it.
+		if matchType != "exact" && matchType != "substring" {
+			if cost, found := subsequenceCost(target, rel, q.sensitive); found && (!ok || w.Subsequence+cost < score) && !inParent(target, rel, q.sensitive) {
+				score, matchType, ok = w.Subsequence+cost, "path", true
 			}
+		}
+	}
+	return FuzzyMatch{
+		Path:      entry.Path,
+		Name:      entry.Name,
+		Score:     score,
+		IsDir:     entry.IsDir,
+		Depth:     entry.Depth,
+		MatchType: matchType,
+	}, ok
+}
 
-			// Search recursively in this subdirectory
-			subMatches := FindRecursiveMatches(subdir, targetName, currentDepth+1, cfg)
-			matches = append(matches, subMatches...)
+// inParent reports whether target is a subsequence of the directory
+// containing rel
+func inParent(target, rel string, sensitive bool) bool {
+	dir := path.Dir(rel)
+	if dir == "." {
+		return false
+	}
+	_, found := subsequenceCost(target, dir, sensitive)
+	return found
+}
+
+// scoreTerms scores path against several terms, each of which must match
+// one of its segments exactly, as a substring or as a subsequence, in the
+// way of fzf's extended search; a term containing a path separator is
+// matched against the whole path. The score adds up the best match of every
+// term.
+func scoreTerms(terms []string, path string, q query) (int, bool) {
+	segments := strings.Split(path, "/")
+	total := 0
+	for _, term := range terms {
+		best, found := 0, false
+		if strings.Contains(term, "/") {
+			score, matchType, ok := scorePath(term, path, q)
+			best, found = score, ok && matchType != "fuzzy"
+		}
+		for _, segment := range segments {
+			score, matchType, ok := scoreName(term, segment, q)
+			if ok && matchType != "fuzzy" && (!found || score < best) {
+				best, found = score, true
+			}
+		}
+		if !found {
+			return 0, false
 		}
+		total += best
 	}
+	return total, true
+}
 
-	return matches
+// scoreName scores how closely name matches target, folded as q says, with
+// the weights of q; lower is better and ok is false when they aren't similar
+// at all
+func scoreName(target, name string, q query) (score int, matchType string, ok bool) {
+	w := q.weights
+	nameLower := q.fold(name)
+
+	// Exact match is best
+	if nameLower == target {
+		return w.Exact, "exact", true
+	}
+
+	// Check for substring match
+	if strings.Contains(nameLower, target) || strings.Contains(target, nameLower) {
+		// Calculate how close this substring match is
+		scoreFactor := utils.Abs(utf8.RuneCountInString(nameLower) - utf8.RuneCountInString(target))
+		return w.Substring + scoreFactor, "substring", true // Good match but not exact
+	}
+
+	return scoreApprox(target, name, q)
+}
+
+// scorePath scores how closely path, relative to the search root, matches a
+// target containing a path separator, folded as q says
+func scorePath(target, path string, q query) (score int, matchType string, ok bool) {
+	w := q.weights
+	pathLower := q.fold(path)
+	if pathLower == target {
+		return w.Exact, "exact", true
+	}
+	if strings.Contains(pathLower, target) {
+		return w.Substring + utf8.RuneCountInString(pathLower) - utf8.RuneCountInString(target), "substring", true
+	}
+	return scoreApprox(target, path, q)
+}
+
+// scoreApprox scores the matches of target in s that are neither exact nor
+// substrings. s keeps its case, which marks the camelCase humps
+// SubsequenceScore rewards.
+func scoreApprox(target, s string, q query) (score int, matchType string, ok bool) {
+	w := q.weights
+
+	// Abbreviations such as "prcssr" for "processor.go" match as a
+	// subsequence; typos fall back to Levenshtein distance
+	if cost, ok := subsequenceCost(target, s, q.sensitive); ok {
+		return w.Subsequence + cost, "subsequence", true
+	}
+
+	// Calculate Levenshtein distance for fuzzy match
+	if q.sensitive {
+		score = utils.CalculateSimilarity(s, target)
+	} else {
+		score = utils.CalculateSimilarityFold(s, target)
+	}
+
+	// Add to matches if the similarity score is above a threshold
+	if score <= w.DistanceThreshold(utf8.RuneCountInString(target)) {
+		return score + w.Fuzzy, "fuzzy", true // Fuzzy match (less weight than substring)
+	}
+	return 0, "", false
+}
+
+// sortMatches orders matches best first: by score, then by depth, then by
+// path so that ties don't depend on the order of the scan
+func sortMatches(matches []FuzzyMatch) {
+	sort.Slice(matches, func(i, j int) bool {
+		if matches[i].Score != matches[j].Score {
+			return matches[i].Score < matches[j].Score // Lower score (more similar) is better
+		}
+		if !matches[i].Committed.Equal(matches[j].Committed) {
+			return matches[i].Committed.After(matches[j].Committed) // Actively maintained is better
+		}
+		if matches[i].Depth != matches[j].Depth {
+			return matches[i].Depth < matches[j].Depth // Lower depth (closer to search root) is better
+		}
+		return matches[i].Path < matches[j].Path
+	})
 }
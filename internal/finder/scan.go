@@ -0,0 +1,189 @@
+package finder
+
+import (
+	"fcopy/internal/config"
+	"fcopy/internal/entrypoints"
+	"fcopy/internal/frecency"
+	"fcopy/internal/ignore"
+	"fcopy/internal/index"
+	"fmt"
+	"io/fs"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+)
+
+// Entry is a file or directory found by Scan
+type Entry struct {
+	Path  string // Path as it would be opened, inside the scanned root
+	Rel   string // Slash-separated path relative to the scanned root
+	Name  string
+	IsDir bool
+	Depth int // Directory depth from search root
+}
+
+// Scan lists the files and directories under root that fuzzy search
+// considers, down to cfg.SearchDepth levels below depth, the depth of root
+// itself. Directories are read by cfg.Workers goroutines at once; entries are
+// returned sorted by path.
+func Scan(root string, depth int, cfg *config.Config) []Entry {
+	return scan(root, depth, cfg, os.ReadDir)
+}
+
+// scan is Scan with directories listed by readDir
+func scan(root string, depth int, cfg *config.Config, readDir func(string) ([]fs.DirEntry, error)) []Entry {
+	if depth > cfg.SearchDepth {
+		return nil
+	}
+
+	var ignores *ignore.Ignores
+	if !cfg.NoIgnore {
+		ignores = &ignore.Ignores{}
+	}
+
+	var (
+		mu      sync.Mutex
+		entries []Entry
+		wg      sync.WaitGroup
+	)
+	// Only reading directories is limited; goroutines waiting for their turn
+	// are cheap
+	readers := make(chan struct{}, max(cfg.Workers, 1))
+
+	var walk func(dir string, depth int)
+	walk = func(dir string, depth int) {
+		defer wg.Done()
+
+		readers <- struct{}{}
+		list, err := readDir(dir)
+		<-readers
+		if err != nil {
+			if cfg.Verbose {
+				fmt.Printf("Error reading directory %s: %v\n", dir, err)
+			}
+			return
+		}
+
+		var found []Entry
+		for _, e := range list {
+			path := filepath.Join(dir, e.Name())
+
+			// Skip if this path should be ignored
+			if ShouldIgnore(path, e.IsDir(), cfg) || (ignores != nil && ignores.Ignored(path, e.IsDir())) {
+				continue
+			}
+
+			rel, err := filepath.Rel(root, path)
+			if err != nil {
+				continue
+			}
+			found = append(found, Entry{
+				Path:  path,
+				Rel:   filepath.ToSlash(rel),
+				Name:  e.Name(),
+				IsDir: e.IsDir(),
+				Depth: depth,
+			})
+
+			if e.IsDir() && depth < cfg.SearchDepth {
+				wg.Add(1)
+				go walk(path, depth+1)
+			}
+		}
+
+		mu.Lock()
+		entries = append(entries, found...)
+		mu.Unlock()
+	}
+
+	wg.Add(1)
+	walk(root, depth)
+	wg.Wait()
+
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Rel < entries[j].Rel
+	})
+	return entries
+}
+
+// Searcher resolves approximate paths, scanning each search root only once
+// however many paths are resolved under it. Unless cfg.NoIndex is set, the
+// daemon of the project answers if one is running, and otherwise directories
+// are listed through the index of their project, so that only the ones that
+// changed since an earlier run are read again.
+type Searcher struct {
+	cfg       *config.Config
+	scans     map[string][]Entry           // search root -> its entries
+	indexes   map[string]*index.Index      // project root -> its index
+	histories map[string]*frecency.History // project root -> paths picked in it
+}
+
+// NewSearcher creates a Searcher for one invocation with the search options
+// in cfg
+func NewSearcher(cfg *config.Config) *Searcher {
+	return &Searcher{
+		cfg:       cfg,
+		scans:     make(map[string][]Entry),
+		indexes:   make(map[string]*index.Index),
+		histories: make(map[string]*frecency.History),
+	}
+}
+
+// Matches finds all potential matches for targetName under dir, best match
+// first, reusing an earlier scan of dir
+func (s *Searcher) Matches(dir, targetName string) []FuzzyMatch {
+	dir = filepath.Clean(dir)
+	entries, ok := s.scans[dir]
+	if !ok {
+		entries = s.scan(dir)
+		s.scans[dir] = entries
+	}
+	return matchEntries(entries, targetName, s.cfg)
+}
+
+// scan lists dir, through the daemon or the index of its project if there
+// is one
+func (s *Searcher) scan(dir string) []Entry {
+	if s.cfg.NoIndex {
+		return Scan(dir, 0, s.cfg)
+	}
+
+	root := entrypoints.ProjectRoot(dir)
+	if entries, err := queryDaemon(root, dir, s.cfg); err == nil {
+		return entries
+	}
+
+	x, ok := s.indexes[root]
+	if !ok {
+		var err error
+		if x, err = index.Open(root); err != nil {
+			if s.cfg.Verbose {
+				fmt.Printf("Not using the fuzzy search index: %v\n", err)
+			}
+			return Scan(dir, 0, s.cfg)
+		}
+		s.indexes[root] = x
+	}
+
+	entries := scan(dir, 0, s.cfg, x.ReadDir)
+	if err := x.Save(); err != nil && s.cfg.Verbose {
+		fmt.Printf("Error saving the fuzzy search index: %v\n", err)
+	}
+	return entries
+}
+
+// BuildIndex refreshes and saves the index of the project around dir,
+// scanning it to any depth with the ignore rules in cfg. It returns the
+// index and the number of files and directories it lists.
+func BuildIndex(dir string, cfg *config.Config) (*index.Index, int, error) {
+	x, err := index.Open(entrypoints.ProjectRoot(dir))
+	if err != nil {
+		return nil, 0, err
+	}
+	unlimited := *cfg
+	unlimited.SearchDepth = math.MaxInt
+	entries := scan(x.Root, 0, &unlimited, x.ReadDir)
+	return x, len(entries), x.Save()
+}
@@ -0,0 +1,154 @@
+package finder
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Weights for SubsequenceScore, modelled on fzf's: every matched character
+// earns scoreMatch, characters that start a word or continue a run earn a
+// bonus on top, and skipped characters between two matches cost a gap
+const (
+	scoreMatch        = 16
+	scoreGapStart     = -3
+	scoreGapExtension = -1
+	bonusBoundary     = 8
+	bonusSegment      = bonusBoundary + 2 // a word that starts a path segment
+	bonusCamel        = bonusBoundary - 1 // a camelCase hump or a run of digits
+	bonusConsecutive  = 4
+	bonusFirstChar    = 2 // multiplier for the bonus of the first query character
+)
+
+// noMatch marks a cell of the scoring table that can't end an alignment
+const noMatch = -1 << 30
+
+// SubsequenceScore scores name against query when the characters of query
+// appear in name in order, possibly with gaps, so "prcssr" matches
+// "processor.go". Higher is better; ok is false when query isn't a
+// subsequence of name. Words start after delimiters and at camelCase humps,
+// so "upc" matches the word starts of "UserProfileController.ts"; characters
+// at the start of a path segment score a little more than ones at the start
+// of any other word. Comparison is case-insensitive.
+func SubsequenceScore(query, name string) (score int, ok bool) {
+	return subsequenceScore(query, name, false)
+}
+
+// subsequenceScore is SubsequenceScore, comparing case as is when sensitive
+func subsequenceScore(query, name string, sensitive bool) (score int, ok bool) {
+	q := []rune(query)
+	original := []rune(name)
+	n := make([]rune, len(original))
+	copy(n, original)
+	if !sensitive {
+		q = []rune(strings.ToLower(query))
+		for j, r := range original {
+			n[j] = unicode.ToLower(r)
+		}
+	}
+	if len(q) == 0 || len(q) > len(n) {
+		return 0, false
+	}
+
+	bonus := make([]int, len(n))
+	for j := range n {
+		switch {
+		case j == 0 || n[j-1] == '/' || n[j-1] == '\\':
+			bonus[j] = bonusSegment
+		case isDelimiter(n[j-1]):
+			bonus[j] = bonusBoundary
+		case isHump(original, j):
+			bonus[j] = bonusCamel
+		}
+	}
+
+	// prev and cur hold the best score of an alignment of q[:i+1] whose last
+	// character is matched at n[j]; run holds the bonus carried along the
+	// consecutive run that alignment ends in
+	prev := make([]int, len(n))
+	cur := make([]int, len(n))
+	prevRun := make([]int, len(n))
+	curRun := make([]int, len(n))
+	for j := range n {
+		prev[j] = noMatch
+		if n[j] == q[0] {
+			prev[j] = scoreMatch + bonus[j]*bonusFirstChar
+			prevRun[j] = bonus[j]
+		}
+	}
+
+	for i := 1; i < len(q); i++ {
+		// gap is the best score of an alignment of q[:i] that ended before
+		// j-1, with the gap up to j already paid for
+		gap := noMatch
+		for j := range n {
+			cur[j] = noMatch
+			if j >= 2 && prev[j-2] > noMatch {
+				gap = max(gap+scoreGapExtension, prev[j-2]+scoreGapStart)
+			} else if gap > noMatch {
+				gap += scoreGapExtension
+			}
+			if n[j] != q[i] || j == 0 {
+				continue
+			}
+			if gap > noMatch {
+				cur[j] = gap + scoreMatch + bonus[j]
+				curRun[j] = bonus[j]
+			}
+			if prev[j-1] > noMatch {
+				carried := max(prevRun[j-1], bonus[j], bonusConsecutive)
+				if s := prev[j-1] + scoreMatch + carried; s > cur[j] {
+					cur[j] = s
+					curRun[j] = carried
+				}
+			}
+		}
+		prev, cur = cur, prev
+		prevRun, curRun = curRun, prevRun
+	}
+
+	score = noMatch
+	for _, s := range prev {
+		score = max(score, s)
+	}
+	return score, score > noMatch
+}
+
+// subsequenceCost converts SubsequenceScore to the lower-is-better scale of
+// FuzzyMatch.Score: how far the match falls short of query appearing as one
+// run at the start of a name, where every two characters' worth of score
+// count as one edit, so a tidy abbreviation competes with a typo
+func subsequenceCost(query, name string, sensitive bool) (int, bool) {
+	score, ok := subsequenceScore(query, name, sensitive)
+	if !ok {
+		return 0, false
+	}
+	length := utf8.RuneCountInString(query)
+	perfect := scoreMatch + bonusSegment*bonusFirstChar + (length-1)*(scoreMatch+bonusSegment)
+	return max(0, perfect-score+2*scoreMatch-1) / (2 * scoreMatch), true
+}
+
+// isHump reports whether name[j] starts a word inside a camelCase or
+// digit-suffixed token: "Profile" in "userProfile", "Server" in
+// "HTTPServer" or "2" in "utf8v2"
+func isHump(name []rune, j int) bool {
+	prev, cur := name[j-1], name[j]
+	switch {
+	case unicode.IsLower(prev) && unicode.IsUpper(cur):
+		return true
+	case unicode.IsLetter(prev) && unicode.IsDigit(cur):
+		return true
+	case unicode.IsUpper(prev) && unicode.IsUpper(cur):
+		return j+1 < len(name) && unicode.IsLower(name[j+1])
+	}
+	return false
+}
+
+// isDelimiter reports whether r separates words in a file name or path
+func isDelimiter(r rune) bool {
+	switch r {
+	case '/', '\\', '_', '-', '.', ' ':
+		return true
+	}
+	return false
+}
@@ -0,0 +1,172 @@
+package frecency
+
+import (
+	"encoding/json"
+	"fcopy/internal/index"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// MaxTotal is the sum of ranks above which all ranks are aged, so old habits
+// fade and the history stays small
+const MaxTotal = 1000
+
+// Visit records how often and when a path was picked
+type Visit struct {
+	Rank float64 `json:"rank"`
+	Last int64   `json:"last"` // Unix time of the latest pick
+}
+
+// History remembers the paths picked from fuzzy matches in one project, in
+// the same way zoxide ranks directories
+type History struct {
+	Root    string              `json:"root"`
+	Visits  map[string]*Visit   `json:"visits"`            // slash-separated path relative to Root -> visit
+	Queries map[string][]string `json:"queries,omitempty"` // query relative to Root -> paths picked for it
+
+	path string
+}
+
+// Open loads the history of the project at root from the cache directory. A
+// missing or unreadable history yields an empty one.
+func Open(root string) (*History, error) {
+	root, err := filepath.Abs(root)
+	if err != nil {
+		return nil, err
+	}
+	path, err := index.CacheFile(root, ".frecency.json")
+	if err != nil {
+		return nil, err
+	}
+
+	h := &History{path: path}
+	if data, err := os.ReadFile(path); err == nil {
+		json.Unmarshal(data, h)
+	}
+	if h.Root != root || h.Visits == nil {
+		h.Root, h.Visits = root, make(map[string]*Visit)
+	}
+	return h, nil
+}
+
+// Record notes that path was picked at now
+func (h *History) Record(path string, now time.Time) {
+	key, ok := h.key(path)
+	if !ok {
+		return
+	}
+	v := h.Visits[key]
+	if v == nil {
+		v = &Visit{}
+		h.Visits[key] = v
+	}
+	v.Rank++
+	v.Last = now.Unix()
+
+	total := 0.0
+	for _, v := range h.Visits {
+		total += v.Rank
+	}
+	if total > MaxTotal {
+		for key, v := range h.Visits {
+			v.Rank *= 0.9
+			if v.Rank < 1 {
+				delete(h.Visits, key)
+			}
+		}
+	}
+}
+
+// Recall returns the paths, relative to Root, picked the last time query, a
+// path like the one typed, was resolved. Queries are compared as given, so
+// callers ignoring case lowercase them first. Picks that no longer exist are
+// forgotten.
+func (h *History) Recall(query string) ([]string, bool) {
+	key, ok := h.key(query)
+	if !ok {
+		return nil, false
+	}
+	picks, ok := h.Queries[key]
+	if !ok {
+		return nil, false
+	}
+	for _, pick := range picks {
+		if _, err := os.Stat(filepath.Join(h.Root, filepath.FromSlash(pick))); err != nil {
+			delete(h.Queries, strings.ToLower(key))
+			return nil, false
+		}
+	}
+	return picks, true
+}
+
+// Resolved notes that paths were picked for query
+func (h *History) Resolved(query string, paths []string) {
+	key, ok := h.key(query)
+	if !ok {
+		return
+	}
+	var picks []string
+	for _, path := range paths {
+		if pick, ok := h.key(path); ok {
+			picks = append(picks, pick)
+		}
+	}
+	if len(picks) == 0 {
+		return
+	}
+	if h.Queries == nil {
+		h.Queries = make(map[string][]string)
+	}
+	h.Queries[key] = picks
+}
+
+// Score returns the frecency of path at now: how often it was picked,
+// weighted by how recently. Paths never picked score 0.
+func (h *History) Score(path string, now time.Time) float64 {
+	key, ok := h.key(path)
+	if !ok {
+		return 0
+	}
+	v := h.Visits[key]
+	if v == nil {
+		return 0
+	}
+
+	switch age := now.Sub(time.Unix(v.Last, 0)); {
+	case age < time.Hour:
+		return v.Rank * 4
+	case age < 24*time.Hour:
+		return v.Rank * 2
+	case age < 7*24*time.Hour:
+		return v.Rank / 2
+	default:
+		return v.Rank / 4
+	}
+}
+
+// Save writes the history back to the cache directory
+func (h *History) Save() error {
+	data, err := json.Marshal(h)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(h.path), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(h.path, data, 0644)
+}
+
+// key returns path relative to the project root, or false if it lies outside
+func (h *History) key(path string) (string, bool) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return "", false
+	}
+	rel, err := filepath.Rel(h.Root, abs)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", false
+	}
+	return filepath.ToSlash(rel), true
+}
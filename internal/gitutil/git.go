@@ -0,0 +1,221 @@
+package gitutil
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// run executes git with args in dir and returns its standard output
+func run(dir string, args ...string) ([]byte, error) {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	out, err := cmd.Output()
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("git %s: %s", args[0], msg)
+		}
+		return nil, fmt.Errorf("git %s: %v", args[0], err)
+	}
+	return out, nil
+}
+
+// splitNUL splits NUL-terminated git output into its entries
+func splitNUL(out []byte) []string {
+	var entries []string
+	for _, entry := range strings.Split(string(out), "\x00") {
+		if entry != "" {
+			entries = append(entries, entry)
+		}
+	}
+	return entries
+}
+
+// TrackedFiles returns the files under dir that are tracked by git, as
+// paths joined onto dir
+func TrackedFiles(dir string) ([]string, error) {
+	out, err := run(dir, "ls-files", "-z", "--cached", "--", ".")
+	if err != nil {
+		return nil, err
+	}
+
+	var files []string
+	for _, rel := range splitNUL(out) {
+		files = append(files, filepath.Join(dir, filepath.FromSlash(rel)))
+	}
+	return files, nil
+}
+
+// RepoRoot returns the top-level directory of the repository containing dir
+func RepoRoot(dir string) (string, error) {
+	out, err := run(dir, "rev-parse", "--show-toplevel")
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// ChangedFiles returns the files changed in ref, which may be a single
+// revision (compared against the working tree) or a range like main..feature.
+// Deleted files are left out. Paths are relative to dir where possible.
+func ChangedFiles(dir, ref string) ([]string, error) {
+	root, err := RepoRoot(dir)
+	if err != nil {
+		return nil, err
+	}
+	out, err := run(dir, "diff", "--name-only", "-z", "--diff-filter=d", ref, "--")
+	if err != nil {
+		return nil, err
+	}
+
+	absDir, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var files []string
+	for _, rel := range splitNUL(out) {
+		path := filepath.Join(root, filepath.FromSlash(rel))
+		if r, err := filepath.Rel(absDir, path); err == nil {
+			path = r
+		}
+		files = append(files, path)
+	}
+	return files, nil
+}
+
+// Diff returns the unified diff of path for ref, as `git diff ref -- path`
+// in the repository containing dir. path is taken relative to the current
+// directory, not to dir.
+func Diff(dir, ref, path string) (string, error) {
+	root, err := RepoRoot(dir)
+	if err != nil {
+		return "", err
+	}
+	rel, ok := repoRelative(root, path)
+	if !ok {
+		return "", fmt.Errorf("%s is outside the repository at %s", path, root)
+	}
+	out, err := run(root, "diff", ref, "--", pathspec(rel))
+	if err != nil {
+		return "", err
+	}
+	return string(out), nil
+}
+
+// LastCommitted returns when each of paths, files or directories, was last
+// changed by a commit, keyed by the paths as given. Untracked paths are left
+// out. The log is read only as far back as needed to date all of them.
+func LastCommitted(dir string, paths []string) (map[string]time.Time, error) {
+	root, err := RepoRoot(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	rels := make(map[string]string) // path relative to root -> path as given
+	var specs []string
+	for _, path := range paths {
+		if rel, ok := repoRelative(root, path); ok {
+			rels[rel] = path
+			specs = append(specs, pathspec(rel))
+		}
+	}
+	if len(specs) == 0 {
+		return map[string]time.Time{}, nil
+	}
+
+	// Only date paths git tracks, or the log would be read to the end
+	out, err := run(root, append([]string{"ls-files", "-z", "--cached", "--full-name", "--"}, specs...)...)
+	if err != nil {
+		return nil, err
+	}
+	tracked := splitNUL(out)
+	pending := make(map[string]string)
+	specs = specs[:0]
+	for rel, path := range rels {
+		for _, file := range tracked {
+			if under(file, rel) {
+				pending[rel] = path
+				specs = append(specs, pathspec(rel))
+				break
+			}
+		}
+	}
+
+	dates := make(map[string]time.Time, len(pending))
+	if len(pending) == 0 {
+		return dates, nil
+	}
+	cmd := exec.Command("git", append([]string{"-c", "core.quotePath=false", "log", "--format=%x00%ct", "--name-only", "--no-renames", "--"}, specs...)...)
+	cmd.Dir = root
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("git log: %v", err)
+	}
+	defer func() {
+		cmd.Process.Kill()
+		cmd.Wait()
+	}()
+
+	var committed time.Time
+	scanner := bufio.NewScanner(stdout)
+	for scanner.Scan() && len(pending) > 0 {
+		line := scanner.Text()
+		// Commit dates are marked with a NUL, which can't be in a file name
+		if stamp, ok := strings.CutPrefix(line, "\x00"); ok {
+			if secs, err := strconv.ParseInt(stamp, 10, 64); err == nil {
+				committed = time.Unix(secs, 0)
+			}
+			continue
+		}
+		for rel, path := range pending {
+			if under(line, rel) {
+				dates[path] = committed
+				delete(pending, rel)
+			}
+		}
+	}
+	return dates, nil
+}
+
+// repoRelative returns path relative to the repository root, with slashes
+func repoRelative(root, path string) (string, bool) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return "", false
+	}
+	// git reports the root with symlinks resolved, as in /private/tmp on macOS
+	if real, err := filepath.EvalSymlinks(abs); err == nil {
+		abs = real
+	}
+	rel, err := filepath.Rel(root, abs)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", false
+	}
+	return filepath.ToSlash(rel), true
+}
+
+// pathspec matches the path rel, relative to the repository root, with no
+// wildcards
+func pathspec(rel string) string {
+	if rel == "." {
+		return ":(top)"
+	}
+	return ":(top,literal)" + rel
+}
+
+// under reports whether the slash-separated file is dir or inside it
+func under(file, dir string) bool {
+	return dir == "." || file == dir || strings.HasPrefix(file, dir+"/")
+}
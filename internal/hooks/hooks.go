@@ -0,0 +1,97 @@
+// Package hooks runs user-defined shell commands before a copy and after a
+// successful one
+package hooks
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Info describes the copy a hook runs after. It reaches the hook as
+// FCOPY_MANIFEST, FCOPY_FILES, FCOPY_BYTES, FCOPY_TOKENS and FCOPY_BUNDLE.
+type Info struct {
+	ManifestPath string
+	Files        int
+	Bytes        int
+	Tokens       int
+	BundlePath   string // File holding the output, which is also the hook's stdin
+}
+
+// Env returns the environment variables describing info
+func (info Info) Env() []string {
+	return []string{
+		"FCOPY_MANIFEST=" + info.ManifestPath,
+		"FCOPY_FILES=" + strconv.Itoa(info.Files),
+		"FCOPY_BYTES=" + strconv.Itoa(info.Bytes),
+		"FCOPY_TOKENS=" + strconv.Itoa(info.Tokens),
+		"FCOPY_BUNDLE=" + info.BundlePath,
+	}
+}
+
+// Run runs command through the shell with info in its environment and the
+// bundle on its stdin, killing it after timeout. The error includes the
+// command's output when it fails.
+func Run(command string, info Info, timeout time.Duration) error {
+	if info.BundlePath == "" {
+		return run(command, info.Env(), nil, timeout)
+	}
+	bundle, err := os.Open(info.BundlePath)
+	if err != nil {
+		return err
+	}
+	defer bundle.Close()
+	return run(command, info.Env(), bundle, timeout)
+}
+
+// RunPre runs command through the shell before anything is gathered, killing
+// it after timeout. The error includes the command's output when it fails.
+func RunPre(command string, timeout time.Duration) error {
+	return run(command, nil, nil, timeout)
+}
+
+// run runs command with env added to the environment and stdin as its input
+func run(command string, env []string, stdin io.Reader, timeout time.Duration) error {
+	ctx := context.Background()
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	cmd := shell(ctx, command)
+	cmd.Env = append(os.Environ(), env...)
+	cmd.Stdin = stdin
+	var out bytes.Buffer
+	cmd.Stdout = &out
+	cmd.Stderr = &out
+	// Children of the shell may hold the output open after it is killed
+	cmd.WaitDelay = time.Second
+
+	err := cmd.Run()
+	if ctx.Err() == context.DeadlineExceeded {
+		return fmt.Errorf("timed out after %v", timeout)
+	}
+	if err != nil {
+		if msg := strings.TrimSpace(out.String()); msg != "" {
+			return fmt.Errorf("%v: %s", err, msg)
+		}
+		return err
+	}
+	return nil
+}
+
+// shell builds a command running command in the platform's shell
+func shell(ctx context.Context, command string) *exec.Cmd {
+	if runtime.GOOS == "windows" {
+		return exec.CommandContext(ctx, "cmd", "/C", command)
+	}
+	return exec.CommandContext(ctx, "sh", "-c", command)
+}
@@ -0,0 +1,122 @@
+package ignore
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// attrRule is one line of a .gitattributes file
+type attrRule struct {
+	pattern Pattern
+	attrs   map[string]string // attribute -> value, "false" for -attr
+}
+
+// Attributes looks up .gitattributes entries for paths, caching parsed
+// files per directory. It is safe for concurrent use.
+type Attributes struct {
+	mu    sync.Mutex
+	rules map[string][]attrRule // directory -> rules of its .gitattributes
+}
+
+// IsGenerated reports whether path is marked linguist-generated in any
+// .gitattributes file between it and the repository root
+func (a *Attributes) IsGenerated(path string) bool {
+	value := a.Lookup(path, "linguist-generated")
+	return value != "" && value != "false"
+}
+
+// Lookup returns the value of attr for path, "true" for attributes that are
+// set without a value, "false" for unset ones, and "" when unspecified. Rules
+// in deeper directories and later lines take precedence, as in git.
+func (a *Attributes) Lookup(path, attr string) string {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return ""
+	}
+
+	// Collect directories from the file up to the repository root
+	var dirs []string
+	for dir := filepath.Dir(abs); ; dir = filepath.Dir(dir) {
+		dirs = append(dirs, dir)
+		if _, err := os.Stat(filepath.Join(dir, ".git")); err == nil {
+			break
+		}
+		if filepath.Dir(dir) == dir {
+			break
+		}
+	}
+
+	// Evaluate outermost first so that deeper files override
+	value := ""
+	for i := len(dirs) - 1; i >= 0; i-- {
+		rel, err := filepath.Rel(dirs[i], abs)
+		if err != nil {
+			continue
+		}
+		rel = filepath.ToSlash(rel)
+		for _, rule := range a.load(dirs[i]) {
+			if v, ok := rule.attrs[attr]; ok && rule.pattern.Match(rel, false) {
+				value = v
+			}
+		}
+	}
+	return value
+}
+
+// load returns the parsed .gitattributes rules of dir
+func (a *Attributes) load(dir string) []attrRule {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if rules, ok := a.rules[dir]; ok {
+		return rules
+	}
+	if a.rules == nil {
+		a.rules = make(map[string][]attrRule)
+	}
+
+	rules := parseAttributes(filepath.Join(dir, ".gitattributes"))
+	a.rules[dir] = rules
+	return rules
+}
+
+// parseAttributes reads a .gitattributes file, returning nil if it is missing
+func parseAttributes(path string) []attrRule {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil
+	}
+	defer file.Close()
+
+	var rules []attrRule
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 2 {
+			continue
+		}
+		pattern, ok := ParsePattern(fields[0])
+		if !ok || pattern.Negate {
+			continue
+		}
+
+		rule := attrRule{pattern: pattern, attrs: make(map[string]string)}
+		for _, field := range fields[1:] {
+			switch {
+			case strings.HasPrefix(field, "-"):
+				rule.attrs[field[1:]] = "false"
+			case strings.HasPrefix(field, "!"):
+				rule.attrs[field[1:]] = ""
+			case strings.Contains(field, "="):
+				kv := strings.SplitN(field, "=", 2)
+				rule.attrs[kv[0]] = kv[1]
+			default:
+				rule.attrs[field] = "true"
+			}
+		}
+		rules = append(rules, rule)
+	}
+	return rules
+}
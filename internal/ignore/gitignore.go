@@ -0,0 +1,110 @@
+package ignore
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// IgnoreFiles are the files whose patterns Ignores reads in every directory,
+// in order of increasing precedence
+var IgnoreFiles = []string{".gitignore", ".fcopyignore"}
+
+// Ignores matches paths against the .gitignore and .fcopyignore files between
+// them and the repository root, caching parsed files per directory. It is
+// safe for concurrent use.
+type Ignores struct {
+	mu       sync.Mutex
+	patterns map[string][]Pattern // directory -> patterns of its ignore files
+	parents  map[string][]string  // directory -> it and its parents up to the repository root
+}
+
+// Ignored reports whether path is excluded by an ignore file. Rules in deeper
+// directories and later lines take precedence and "!" patterns re-include, as
+// in git. Parent directories aren't checked, since walkers skip an ignored
+// directory before reaching its contents.
+func (g *Ignores) Ignored(path string, isDir bool) bool {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return false
+	}
+
+	dirs := g.dirs(filepath.Dir(abs))
+	ignored := false
+	for i := len(dirs) - 1; i >= 0; i-- {
+		rel, err := filepath.Rel(dirs[i], abs)
+		if err != nil {
+			continue
+		}
+		rel = filepath.ToSlash(rel)
+		for _, p := range g.load(dirs[i]) {
+			if p.Match(rel, isDir) {
+				ignored = !p.Negate
+			}
+		}
+	}
+	return ignored
+}
+
+// dirs returns dir and its parents up to the repository root, innermost first
+func (g *Ignores) dirs(dir string) []string {
+	g.mu.Lock()
+	if dirs, ok := g.parents[dir]; ok {
+		g.mu.Unlock()
+		return dirs
+	}
+	g.mu.Unlock()
+
+	dirs := []string{dir}
+	_, err := os.Stat(filepath.Join(dir, ".git"))
+	if parent := filepath.Dir(dir); err != nil && parent != dir {
+		dirs = append(dirs, g.dirs(parent)...)
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.parents == nil {
+		g.parents = make(map[string][]string)
+	}
+	g.parents[dir] = dirs
+	return dirs
+}
+
+// load returns the parsed ignore patterns of dir
+func (g *Ignores) load(dir string) []Pattern {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if patterns, ok := g.patterns[dir]; ok {
+		return patterns
+	}
+	if g.patterns == nil {
+		g.patterns = make(map[string][]Pattern)
+	}
+
+	var patterns []Pattern
+	for _, name := range IgnoreFiles {
+		patterns = append(patterns, parseIgnoreFile(filepath.Join(dir, name))...)
+	}
+	g.patterns[dir] = patterns
+	return patterns
+}
+
+// parseIgnoreFile reads the patterns of an ignore file, returning nil if it
+// is missing
+func parseIgnoreFile(path string) []Pattern {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil
+	}
+	defer file.Close()
+
+	var patterns []Pattern
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		if p, ok := ParsePattern(scanner.Text()); ok {
+			patterns = append(patterns, p)
+		}
+	}
+	return patterns
+}
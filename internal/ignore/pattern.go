@@ -0,0 +1,102 @@
+package ignore
+
+import (
+	"path"
+	"strings"
+)
+
+// Pattern is a single gitignore-style glob pattern
+type Pattern struct {
+	Negate   bool // Pattern started with "!"
+	DirOnly  bool // Pattern ended with "/" and only matches directories
+	anchored bool // Pattern contains a "/" and matches from the base directory
+	segments []string
+}
+
+// ParsePattern parses a gitignore-style pattern. ok is false for blank lines
+// and comments.
+func ParsePattern(line string) (p Pattern, ok bool) {
+	line = strings.TrimRight(line, " \t\r")
+	if line == "" || strings.HasPrefix(line, "#") {
+		return p, false
+	}
+
+	if strings.HasPrefix(line, "!") {
+		p.Negate = true
+		line = line[1:]
+	} else if strings.HasPrefix(line, `\!`) || strings.HasPrefix(line, `\#`) {
+		line = line[1:]
+	}
+
+	if strings.HasSuffix(line, "/") {
+		p.DirOnly = true
+		line = strings.TrimRight(line, "/")
+	}
+	if strings.Contains(line, "/") {
+		p.anchored = true
+		line = strings.TrimPrefix(line, "/")
+	}
+	if line == "" {
+		return p, false
+	}
+
+	p.segments = strings.Split(line, "/")
+	return p, true
+}
+
+// Match reports whether rel, a slash-separated path relative to the
+// pattern's base directory, matches the pattern
+func (p Pattern) Match(rel string, isDir bool) bool {
+	if p.DirOnly && !isDir {
+		return false
+	}
+	rel = strings.Trim(rel, "/")
+
+	if !p.anchored {
+		// Unanchored patterns match against the final path component
+		return matchSegment(p.segments[0], path.Base(rel))
+	}
+	return matchSegments(p.segments, strings.Split(rel, "/"))
+}
+
+// MatchPrefix reports whether the pattern matches rel or any of its parent
+// directories, as gitignore does for files inside an ignored directory
+func (p Pattern) MatchPrefix(rel string, isDir bool) bool {
+	parts := strings.Split(strings.Trim(rel, "/"), "/")
+	for i := 1; i < len(parts); i++ {
+		if p.Match(strings.Join(parts[:i], "/"), true) {
+			return true
+		}
+	}
+	return p.Match(rel, isDir)
+}
+
+// matchSegments matches pattern segments against path segments, where a
+// "**" segment matches zero or more path segments
+func matchSegments(pattern, parts []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			rest := pattern[1:]
+			if len(rest) == 0 {
+				return true
+			}
+			for i := 0; i <= len(parts); i++ {
+				if matchSegments(rest, parts[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(parts) == 0 || !matchSegment(pattern[0], parts[0]) {
+			return false
+		}
+		pattern, parts = pattern[1:], parts[1:]
+	}
+	return len(parts) == 0
+}
+
+// matchSegment matches a single path component against a glob
+func matchSegment(pattern, name string) bool {
+	ok, err := path.Match(pattern, name)
+	return err == nil && ok
+}
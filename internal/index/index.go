@@ -0,0 +1,250 @@
+package index
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// Dir is the listing of one directory as of its modification time
+type Dir struct {
+	ModTime int64   `json:"mtime"`
+	Entries []Entry `json:"entries"`
+}
+
+// Entry is a file or directory inside a Dir
+type Entry struct {
+	Name string      `json:"name"`
+	Type fs.FileMode `json:"type,omitempty"` // Type bits, as returned by fs.DirEntry.Type
+}
+
+// Index caches the directory listings of one project root. A listing is
+// read again only when the directory's modification time changes, which
+// happens whenever an entry is added, removed or renamed in it. It is safe
+// for concurrent use.
+type Index struct {
+	Root string          `json:"root"`
+	Dirs map[string]*Dir `json:"dirs"` // slash-separated path relative to Root -> listing
+
+	path  string
+	mu    sync.Mutex
+	dirty bool
+	reads int
+}
+
+// CacheDir returns the directory indexes are stored in:
+// $XDG_CACHE_HOME/fcopy/index, or the platform's equivalent
+func CacheDir() (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "fcopy", "index"), nil
+}
+
+// SocketPath returns the unix socket the daemon keeping the index of root
+// in memory listens on
+func SocketPath(root string) (string, error) {
+	root, err := filepath.Abs(root)
+	if err != nil {
+		return "", err
+	}
+	return CacheFile(root, ".sock")
+}
+
+// CacheFile names the file with extension ext that belongs to the project
+// at root, an absolute path, in the cache directory
+func CacheFile(root, ext string) (string, error) {
+	dir, err := CacheDir()
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256([]byte(root))
+	return filepath.Join(dir, hex.EncodeToString(sum[:8])+ext), nil
+}
+
+// Open loads the index of root from the cache directory. A missing or
+// unreadable index yields an empty one, which is filled as directories are
+// read.
+func Open(root string) (*Index, error) {
+	root, err := filepath.Abs(root)
+	if err != nil {
+		return nil, err
+	}
+	path, err := CacheFile(root, ".json")
+	if err != nil {
+		return nil, err
+	}
+	x := &Index{path: path}
+
+	if data, err := os.ReadFile(x.path); err == nil {
+		json.Unmarshal(data, x)
+	}
+	if x.Root != root || x.Dirs == nil {
+		x.Root, x.Dirs = root, make(map[string]*Dir)
+	}
+	return x, nil
+}
+
+// ReadDir lists dir like os.ReadDir, from the index when dir hasn't changed
+// since it was last read. Directories outside the root are read directly.
+func (x *Index) ReadDir(dir string) ([]fs.DirEntry, error) {
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+	rel, err := filepath.Rel(x.Root, abs)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return os.ReadDir(dir)
+	}
+	rel = filepath.ToSlash(rel)
+
+	info, err := os.Stat(dir)
+	if err != nil {
+		return nil, err
+	}
+	mtime := info.ModTime().UnixNano()
+
+	x.mu.Lock()
+	cached, ok := x.Dirs[rel]
+	x.mu.Unlock()
+	if ok && cached.ModTime == mtime {
+		return cached.list(dir), nil
+	}
+
+	list, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	fresh := &Dir{ModTime: mtime, Entries: make([]Entry, len(list))}
+	for i, e := range list {
+		fresh.Entries[i] = Entry{Name: e.Name(), Type: e.Type()}
+	}
+
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	if ok {
+		x.forgetRemoved(rel, cached, fresh)
+	}
+	x.Dirs[rel] = fresh
+	x.dirty = true
+	x.reads++
+	return list, nil
+}
+
+// Cached returns the listing of dir as it was last read, without checking
+// whether dir changed since
+func (x *Index) Cached(dir string) ([]fs.DirEntry, bool) {
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, false
+	}
+	rel, err := filepath.Rel(x.Root, abs)
+	if err != nil {
+		return nil, false
+	}
+	x.mu.Lock()
+	cached, ok := x.Dirs[filepath.ToSlash(rel)]
+	x.mu.Unlock()
+	if !ok {
+		return nil, false
+	}
+	return cached.list(dir), true
+}
+
+// forgetRemoved drops the listings of subdirectories of rel that are gone
+// from its fresh listing, and everything below them
+func (x *Index) forgetRemoved(rel string, cached, fresh *Dir) {
+	kept := make(map[string]bool, len(fresh.Entries))
+	for _, e := range fresh.Entries {
+		kept[e.Name] = e.Type.IsDir()
+	}
+	for _, e := range cached.Entries {
+		if !e.Type.IsDir() || kept[e.Name] {
+			continue
+		}
+		gone := join(rel, e.Name)
+		for key := range x.Dirs {
+			if key == gone || strings.HasPrefix(key, gone+"/") {
+				delete(x.Dirs, key)
+			}
+		}
+	}
+}
+
+// Reads returns how many directories had to be read from disk since the
+// index was opened
+func (x *Index) Reads() int {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	return x.reads
+}
+
+// Save writes the index back to the cache directory if it changed
+func (x *Index) Save() error {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	if !x.dirty {
+		return nil
+	}
+	data, err := json.Marshal(x)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(x.path), 0755); err != nil {
+		return err
+	}
+
+	// Write to a temporary file first so that concurrent runs never read a
+	// partial index
+	tmp, err := os.CreateTemp(filepath.Dir(x.path), filepath.Base(x.path)+".*")
+	if err != nil {
+		return err
+	}
+	_, err = tmp.Write(data)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), x.path)
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	x.dirty = false
+	return nil
+}
+
+// list returns the cached entries of d, which is at dir on disk
+func (d *Dir) list(dir string) []fs.DirEntry {
+	list := make([]fs.DirEntry, len(d.Entries))
+	for i, e := range d.Entries {
+		list[i] = dirEntry{entry: e, dir: dir}
+	}
+	return list
+}
+
+// dirEntry implements fs.DirEntry for a cached Entry
+type dirEntry struct {
+	entry Entry
+	dir   string
+}
+
+func (e dirEntry) Name() string               { return e.entry.Name }
+func (e dirEntry) IsDir() bool                { return e.entry.Type.IsDir() }
+func (e dirEntry) Type() fs.FileMode          { return e.entry.Type }
+func (e dirEntry) Info() (fs.FileInfo, error) { return os.Lstat(filepath.Join(e.dir, e.entry.Name)) }
+
+// join appends name to the slash-separated relative path rel
+func join(rel, name string) string {
+	if rel == "." {
+		return name
+	}
+	return rel + "/" + name
+}
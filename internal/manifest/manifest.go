@@ -0,0 +1,51 @@
+package manifest
+
+import (
+	"encoding/json"
+	"fcopy/internal/processor"
+	"fcopy/internal/tokens"
+	"os"
+)
+
+// Entry describes a single file included in the output
+type Entry struct {
+	Path   string `json:"path"`
+	Bytes  int    `json:"bytes"`
+	Tokens int    `json:"tokens"`
+	Arg    string `json:"arg,omitempty"`
+	Reason string `json:"reason"`
+}
+
+// Manifest lists every file that was copied and why it was included
+type Manifest struct {
+	Files       []Entry `json:"files"`
+	TotalBytes  int     `json:"total_bytes"`
+	TotalTokens int     `json:"total_tokens"`
+}
+
+// Build creates a manifest from the collected file contents
+func Build(files []processor.FileContent) *Manifest {
+	m := &Manifest{Files: make([]Entry, 0, len(files))}
+	for _, f := range files {
+		n := tokens.Count(f.Content)
+		m.Files = append(m.Files, Entry{
+			Path:   f.Path,
+			Bytes:  len(f.Content),
+			Tokens: n,
+			Arg:    f.Origin.Arg,
+			Reason: f.Origin.Rule,
+		})
+		m.TotalBytes += len(f.Content)
+		m.TotalTokens += n
+	}
+	return m
+}
+
+// Write saves the manifest as indented JSON to path
+func (m *Manifest) Write(path string) error {
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0644)
+}
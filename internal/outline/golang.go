@@ -0,0 +1,45 @@
+package outline
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"strings"
+)
+
+// Go keeps the package clause, imports, type definitions and function
+// signatures of a Go source file, with their doc comments. Function bodies
+// are replaced with "{ ... }"; constants and variables are left out.
+func Go(src string) (string, error) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "", src, parser.ParseComments|parser.SkipObjectResolution)
+	if err != nil {
+		return "", err
+	}
+	offset := func(pos token.Pos) int { return fset.Position(pos).Offset }
+	// start includes the doc comment of a declaration, if any
+	start := func(doc *ast.CommentGroup, pos token.Pos) int {
+		if doc != nil {
+			return offset(doc.Pos())
+		}
+		return offset(pos)
+	}
+
+	parts := []string{src[start(file.Doc, file.Package):offset(file.Name.End())]}
+	for _, decl := range file.Decls {
+		switch d := decl.(type) {
+		case *ast.GenDecl:
+			if d.Tok == token.IMPORT || d.Tok == token.TYPE {
+				parts = append(parts, src[start(d.Doc, d.Pos()):offset(d.End())])
+			}
+		case *ast.FuncDecl:
+			if d.Body == nil {
+				parts = append(parts, src[start(d.Doc, d.Pos()):offset(d.End())])
+			} else {
+				signature := strings.TrimRight(src[start(d.Doc, d.Pos()):offset(d.Body.Lbrace)], " ")
+				parts = append(parts, signature+" { ... }")
+			}
+		}
+	}
+	return strings.Join(parts, "\n\n") + "\n", nil
+}
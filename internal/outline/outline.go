@@ -0,0 +1,22 @@
+// Package outline reduces source files to their API surface: declarations
+// and signatures without bodies, for when a reader needs the shape of the
+// code rather than its implementation
+package outline
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+// Outline returns the outline of the source file at path, or false when its
+// language isn't supported or the file can't be parsed. Go is outlined with
+// go/ast; TypeScript, JavaScript, Python, Rust and Java with tree-sitter.
+func Outline(path, src string) (string, bool) {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".go":
+		outline, err := Go(src)
+		return outline, err == nil
+	default:
+		return treeSitter(path, src)
+	}
+}
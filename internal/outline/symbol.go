@@ -0,0 +1,105 @@
+package outline
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"path/filepath"
+	"strings"
+)
+
+// Definition is the span of lines, 1-based and inclusive, defining a symbol,
+// including its doc comment
+type Definition struct {
+	Name       string // Qualified name, like "Tracker.Claim" for a method
+	Start, End int
+}
+
+// Find locates the functions, methods and types called name in the source
+// file at path. name may be qualified with the enclosing type, as in
+// "Tracker.Claim". It returns false when the language isn't supported or
+// the file can't be parsed.
+func Find(path, src, name string) ([]Definition, bool) {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".go":
+		defs, err := findGo(src, name)
+		return defs, err == nil
+	default:
+		return findTreeSitter(path, src, name)
+	}
+}
+
+// matchName reports whether a symbol declared as name inside container
+// (empty at top level) is what query asks for
+func matchName(query, container, name string) bool {
+	if query == name {
+		return true
+	}
+	return container != "" && query == container+"."+name
+}
+
+// findGo finds functions, methods and types of a Go file
+func findGo(src, name string) ([]Definition, error) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "", src, parser.ParseComments|parser.SkipObjectResolution)
+	if err != nil {
+		return nil, err
+	}
+	span := func(qualified string, doc *ast.CommentGroup, start, end token.Pos) Definition {
+		if doc != nil {
+			start = doc.Pos()
+		}
+		return Definition{Name: qualified, Start: fset.Position(start).Line, End: fset.Position(end).Line}
+	}
+
+	var defs []Definition
+	for _, decl := range file.Decls {
+		switch d := decl.(type) {
+		case *ast.FuncDecl:
+			receiver := ""
+			if d.Recv != nil && len(d.Recv.List) > 0 {
+				receiver = receiverName(d.Recv.List[0].Type)
+			}
+			if matchName(name, receiver, d.Name.Name) {
+				qualified := d.Name.Name
+				if receiver != "" {
+					qualified = receiver + "." + qualified
+				}
+				defs = append(defs, span(qualified, d.Doc, d.Pos(), d.End()))
+			}
+		case *ast.GenDecl:
+			if d.Tok != token.TYPE {
+				continue
+			}
+			for _, spec := range d.Specs {
+				ts := spec.(*ast.TypeSpec)
+				if ts.Name.Name != name {
+					continue
+				}
+				// A lone type keeps its "type" keyword and the declaration's doc
+				if len(d.Specs) == 1 {
+					defs = append(defs, span(name, d.Doc, d.Pos(), d.End()))
+				} else {
+					defs = append(defs, span(name, ts.Doc, ts.Pos(), ts.End()))
+				}
+			}
+		}
+	}
+	return defs, nil
+}
+
+// receiverName returns the type name of a method receiver such as *T or
+// T[K, V]
+func receiverName(expr ast.Expr) string {
+	switch e := expr.(type) {
+	case *ast.StarExpr:
+		return receiverName(e.X)
+	case *ast.IndexExpr:
+		return receiverName(e.X)
+	case *ast.IndexListExpr:
+		return receiverName(e.X)
+	case *ast.Ident:
+		return e.Name
+	}
+	return ""
+}
@@ -0,0 +1,271 @@
+//go:build cgo
+
+package outline
+
+import (
+	"context"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	sitter "github.com/smacker/go-tree-sitter"
+	"github.com/smacker/go-tree-sitter/java"
+	"github.com/smacker/go-tree-sitter/javascript"
+	"github.com/smacker/go-tree-sitter/python"
+	"github.com/smacker/go-tree-sitter/rust"
+	"github.com/smacker/go-tree-sitter/typescript/tsx"
+	"github.com/smacker/go-tree-sitter/typescript/typescript"
+)
+
+// grammar describes how to outline one language parsed with tree-sitter
+type grammar struct {
+	language *sitter.Language
+	keep     map[string]bool // Top-level nodes kept in the outline
+	attached map[string]bool // Nodes kept when directly above a kept node, like comments
+	bodies   map[string]bool // Nodes whose "body" field is elided
+	python   bool            // Bodies are indented blocks rather than braces
+	symbols  map[string]bool // Nodes defining a named function, method or type
+	scopes   map[string]bool // Nodes whose name qualifies the symbols inside
+}
+
+var (
+	tsKeep = set("import_statement", "export_statement", "class_declaration",
+		"abstract_class_declaration", "interface_declaration", "type_alias_declaration",
+		"enum_declaration", "function_declaration", "generator_function_declaration",
+		"function_signature", "ambient_declaration", "module", "internal_module")
+	jsBodies = set("function_declaration", "generator_function_declaration", "method_definition",
+		"arrow_function", "function_expression", "function", "generator_function")
+	comments  = set("comment", "line_comment", "block_comment")
+	jsSymbols = set("function_declaration", "generator_function_declaration", "method_definition",
+		"class_declaration", "abstract_class_declaration", "interface_declaration",
+		"type_alias_declaration", "enum_declaration", "function_signature", "variable_declarator")
+	jsScopes = set("class_declaration", "abstract_class_declaration", "interface_declaration", "class")
+	// wrappers are nodes around a definition that belong to its source,
+	// like "export" or decorators
+	wrappers = set("export_statement", "decorated_definition", "lexical_declaration", "variable_declaration")
+)
+
+// grammars maps file extensions to the grammar outlining them
+var grammars = map[string]*grammar{
+	".ts":  {language: typescript.GetLanguage(), keep: tsKeep, attached: comments, bodies: jsBodies, symbols: jsSymbols, scopes: jsScopes},
+	".mts": {language: typescript.GetLanguage(), keep: tsKeep, attached: comments, bodies: jsBodies, symbols: jsSymbols, scopes: jsScopes},
+	".cts": {language: typescript.GetLanguage(), keep: tsKeep, attached: comments, bodies: jsBodies, symbols: jsSymbols, scopes: jsScopes},
+	".tsx": {language: tsx.GetLanguage(), keep: tsKeep, attached: comments, bodies: jsBodies, symbols: jsSymbols, scopes: jsScopes},
+	".js":  {language: javascript.GetLanguage(), keep: tsKeep, attached: comments, bodies: jsBodies, symbols: jsSymbols, scopes: jsScopes},
+	".jsx": {language: javascript.GetLanguage(), keep: tsKeep, attached: comments, bodies: jsBodies, symbols: jsSymbols, scopes: jsScopes},
+	".mjs": {language: javascript.GetLanguage(), keep: tsKeep, attached: comments, bodies: jsBodies, symbols: jsSymbols, scopes: jsScopes},
+	".cjs": {language: javascript.GetLanguage(), keep: tsKeep, attached: comments, bodies: jsBodies, symbols: jsSymbols, scopes: jsScopes},
+	".py": {
+		language: python.GetLanguage(),
+		keep: set("import_statement", "import_from_statement", "future_import_statement",
+			"class_definition", "function_definition", "decorated_definition"),
+		attached: comments,
+		bodies:   set("function_definition"),
+		python:   true,
+		symbols:  set("function_definition", "class_definition"),
+		scopes:   set("class_definition"),
+	},
+	".rs": {
+		language: rust.GetLanguage(),
+		keep: set("use_declaration", "extern_crate_declaration", "mod_item", "struct_item",
+			"enum_item", "union_item", "trait_item", "impl_item", "type_item",
+			"function_item", "function_signature_item"),
+		attached: set("line_comment", "block_comment", "attribute_item"),
+		bodies:   set("function_item"),
+		symbols: set("function_item", "function_signature_item", "struct_item", "enum_item",
+			"union_item", "trait_item", "type_item"),
+		scopes: set("impl_item", "trait_item"),
+	},
+	".java": {
+		language: java.GetLanguage(),
+		keep: set("package_declaration", "import_declaration", "class_declaration",
+			"interface_declaration", "enum_declaration", "record_declaration",
+			"annotation_type_declaration"),
+		attached: comments,
+		bodies:   set("method_declaration", "constructor_declaration"),
+		symbols: set("method_declaration", "constructor_declaration", "class_declaration",
+			"interface_declaration", "enum_declaration", "record_declaration"),
+		scopes: set("class_declaration", "interface_declaration", "enum_declaration", "record_declaration"),
+	},
+}
+
+// treeSitter outlines TypeScript, JavaScript, Python, Rust and Java files:
+// top-level declarations and their comments are kept, function bodies are
+// replaced with "{ ... }" (or "..." in Python, after any docstring)
+func treeSitter(path, src string) (string, bool) {
+	g, ok := grammars[strings.ToLower(filepath.Ext(path))]
+	if !ok {
+		return "", false
+	}
+	root, err := sitter.ParseCtx(context.Background(), []byte(src), g.language)
+	if err != nil || root.HasError() {
+		return "", false
+	}
+
+	var parts []string
+	last := -1
+	count := int(root.NamedChildCount())
+	for i := 0; i < count; i++ {
+		node := root.NamedChild(i)
+		if !g.keep[node.Type()] {
+			continue
+		}
+		// Pull in the comments and attributes directly above the node
+		first, row := i, node.StartPoint().Row
+		for j := i - 1; j >= 0; j-- {
+			prev := root.NamedChild(j)
+			if !g.attached[prev.Type()] || prev.EndPoint().Row+1 < row {
+				break
+			}
+			first, row = j, prev.StartPoint().Row
+		}
+
+		text := g.elide(src, node, root.NamedChild(first).StartByte())
+		// Runs of adjacent declarations, like imports, stay together
+		if last >= 0 && last == first-1 && root.NamedChild(last).EndPoint().Row+1 == row {
+			parts[len(parts)-1] += "\n" + text
+		} else {
+			parts = append(parts, text)
+		}
+		last = i
+	}
+	return strings.Join(parts, "\n\n") + "\n", true
+}
+
+// findTreeSitter finds the functions, methods and types called name in a
+// file of a language parsed with tree-sitter
+func findTreeSitter(path, src, name string) ([]Definition, bool) {
+	g, ok := grammars[strings.ToLower(filepath.Ext(path))]
+	if !ok {
+		return nil, false
+	}
+	root, err := sitter.ParseCtx(context.Background(), []byte(src), g.language)
+	if err != nil {
+		return nil, false
+	}
+	var defs []Definition
+	g.findSymbols([]byte(src), root, "", name, &defs)
+	return defs, true
+}
+
+// findSymbols collects the definitions of name below node, where scope is
+// the name of the enclosing class or type
+func (g *grammar) findSymbols(src []byte, node *sitter.Node, scope, name string, defs *[]Definition) {
+	count := int(node.NamedChildCount())
+	for i := 0; i < count; i++ {
+		child := node.NamedChild(i)
+		symbol := symbolName(src, child)
+		if g.symbols[child.Type()] && symbol != "" && matchName(name, scope, symbol) {
+			// Variables only count when they hold a function
+			value := child.ChildByFieldName("value")
+			if child.Type() != "variable_declarator" || (value != nil && jsBodies[value.Type()]) {
+				*defs = append(*defs, g.definition(src, child, qualify(scope, symbol)))
+			}
+		}
+
+		inner := scope
+		if g.scopes[child.Type()] && symbol != "" {
+			inner = symbol
+		}
+		g.findSymbols(src, child, inner, name, defs)
+	}
+}
+
+// definition returns the lines of a symbol's node, widened to the export,
+// decorators and declaration around it and the comments above them
+func (g *grammar) definition(src []byte, node *sitter.Node, name string) Definition {
+	for parent := node.Parent(); parent != nil && wrappers[parent.Type()]; parent = node.Parent() {
+		node = parent
+	}
+	start := node.StartPoint().Row
+	for prev := node.PrevNamedSibling(); prev != nil && g.attached[prev.Type()] && prev.EndPoint().Row+1 >= start; prev = prev.PrevNamedSibling() {
+		start = prev.StartPoint().Row
+	}
+	return Definition{Name: name, Start: int(start) + 1, End: int(node.EndPoint().Row) + 1}
+}
+
+// symbolName returns the name a node declares; Rust impl blocks are named
+// after the type they implement
+func symbolName(src []byte, node *sitter.Node) string {
+	field := node.ChildByFieldName("name")
+	if node.Type() == "impl_item" {
+		field = node.ChildByFieldName("type")
+	}
+	if field == nil {
+		return ""
+	}
+	return field.Content(src)
+}
+
+// qualify joins a symbol name to the scope it is declared in
+func qualify(scope, name string) string {
+	if scope == "" {
+		return name
+	}
+	return scope + "." + name
+}
+
+// replacement swaps the bytes from start to end of the source for text
+type replacement struct {
+	start, end uint32
+	text       string
+}
+
+// elide returns the source of node from start, with function bodies inside
+// it replaced
+func (g *grammar) elide(src string, node *sitter.Node, start uint32) string {
+	var replacements []replacement
+	g.collectBodies(src, node, &replacements)
+	sort.Slice(replacements, func(a, b int) bool { return replacements[a].start < replacements[b].start })
+
+	var b strings.Builder
+	pos := start
+	for _, r := range replacements {
+		b.WriteString(src[pos:r.start])
+		b.WriteString(r.text)
+		pos = r.end
+	}
+	b.WriteString(src[pos:node.EndByte()])
+	return b.String()
+}
+
+// collectBodies finds the function bodies below node, outermost first
+func (g *grammar) collectBodies(src string, node *sitter.Node, replacements *[]replacement) {
+	if g.bodies[node.Type()] {
+		if body := node.ChildByFieldName("body"); body != nil && body.NamedChildCount() > 0 {
+			if g.python {
+				*replacements = append(*replacements, pythonBody(src, body))
+				return
+			}
+			// Arrow functions may have a bare expression as their body
+			if body.Type() != "expression" && strings.HasPrefix(body.Content([]byte(src)), "{") {
+				*replacements = append(*replacements, replacement{body.StartByte(), body.EndByte(), "{ ... }"})
+				return
+			}
+		}
+	}
+	count := int(node.NamedChildCount())
+	for i := 0; i < count; i++ {
+		g.collectBodies(src, node.NamedChild(i), replacements)
+	}
+}
+
+// pythonBody replaces a Python block with "...", keeping its docstring
+func pythonBody(src string, body *sitter.Node) replacement {
+	r := replacement{body.StartByte(), body.EndByte(), "..."}
+	first := body.NamedChild(0)
+	if first.Type() == "expression_statement" && first.NamedChildCount() == 1 && first.NamedChild(0).Type() == "string" {
+		indent := strings.Repeat(" ", int(body.StartPoint().Column))
+		r.text = first.Content([]byte(src)) + "\n" + indent + "..."
+	}
+	return r
+}
+
+// set builds a lookup table of node types
+func set(types ...string) map[string]bool {
+	m := make(map[string]bool, len(types))
+	for _, t := range types {
+		m[t] = true
+	}
+	return m
+}
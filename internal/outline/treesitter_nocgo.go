@@ -0,0 +1,15 @@
+//go:build !cgo
+
+package outline
+
+// treeSitter needs cgo for the tree-sitter grammars; without it only Go files
+// can be outlined
+func treeSitter(path, src string) (string, bool) {
+	return "", false
+}
+
+// findTreeSitter needs cgo for the tree-sitter grammars; without it only
+// symbols in Go files can be found
+func findTreeSitter(path, src, name string) ([]Definition, bool) {
+	return nil, false
+}
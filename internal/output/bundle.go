@@ -0,0 +1,55 @@
+package output
+
+import (
+	"crypto/sha256"
+	"fcopy/internal/processor"
+	"fmt"
+	"io"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Delimiters of the bundle format, each on a line of its own, with
+// "<<<file key=value ...>>>" lines starting every file. Content lines that could be mistaken for
+// one, those starting with "<<<" after any backslashes, get one more
+// backslash, which the parser removes again.
+const (
+	BundleStart = "<<<fcopy bundle v1>>>"
+	BundleEnd   = "<<<end>>>"
+)
+
+// EscapedLine matches content lines that need escaping in a bundle
+var EscapedLine = regexp.MustCompile(`^\\*<<<`)
+
+// writeBundle writes files in a format that parses back losslessly: every
+// file is framed by delimiter lines carrying its path, size and SHA-256, and
+// content lines resembling a delimiter are escaped
+func writeBundle(w io.Writer, files []processor.FileContent) error {
+	var b strings.Builder
+	b.WriteString(BundleStart + "\n")
+	for _, f := range files {
+		fmt.Fprintf(&b, "<<<file path=%s bytes=%d sha256=%x", strconv.Quote(filepath.ToSlash(f.Path)),
+			len(f.Content), sha256.Sum256([]byte(f.Content)))
+		if len(f.Notes) > 0 {
+			fmt.Fprintf(&b, " notes=%s", strconv.Quote(strings.Join(f.Notes, "; ")))
+		}
+		content := f.Content
+		if content != "" && !strings.HasSuffix(content, "\n") {
+			b.WriteString(" eol=none")
+			content += "\n"
+		}
+		b.WriteString(">>>\n")
+
+		for _, line := range strings.SplitAfter(content, "\n") {
+			if EscapedLine.MatchString(line) {
+				b.WriteString(`\`)
+			}
+			b.WriteString(line)
+		}
+		b.WriteString(BundleEnd + "\n")
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
@@ -0,0 +1,193 @@
+package output
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"fcopy/internal/classify"
+	"fcopy/internal/config"
+	"fcopy/internal/processor"
+	"fcopy/internal/tokens"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// Formats lists the supported output formats
+var Formats = []string{"plain", "cat", "diff", "jsonl", "bundle", "repomix"}
+
+// Validate checks that the output options in cfg are usable
+func Validate(cfg *config.Config) error {
+	var b strings.Builder
+	return Write(&b, nil, cfg)
+}
+
+// Write renders files to w using the format selected in cfg
+func Write(w io.Writer, files []processor.FileContent, cfg *config.Config) error {
+	switch cfg.Format {
+	case "", "plain":
+		return writePlain(w, files)
+	case "cat":
+		sep, err := Unescape(cfg.Separator)
+		if err != nil {
+			return fmt.Errorf("invalid separator %q: %v", cfg.Separator, err)
+		}
+		return writeCat(w, files, sep)
+	case "diff":
+		if cfg.Changed == "" {
+			return fmt.Errorf("--format diff requires --changed")
+		}
+		return writeDiff(w, files)
+	case "jsonl":
+		return writeJSONL(w, files)
+	case "bundle":
+		return writeBundle(w, files)
+	case "repomix":
+		return writeRepomix(w, files)
+	default:
+		return fmt.Errorf("unknown format %q (expected one of: %s)", cfg.Format, strings.Join(Formats, ", "))
+	}
+}
+
+// IsFileStart reports whether line starts a new file in output of the given
+// format. Every line of jsonl output is a file of its own.
+func IsFileStart(format, line string) bool {
+	switch format {
+	case "", "plain":
+		return strings.HasPrefix(line, "-- ") && strings.HasSuffix(line, " --")
+	case "bundle":
+		return strings.HasPrefix(line, "<<<file ")
+	case "repomix":
+		return strings.HasPrefix(line, "File: ") || line == repomixFileRule
+	case "diff":
+		return strings.HasPrefix(line, "diff --git ") || strings.HasPrefix(line, "-- ") && strings.HasSuffix(line, " --")
+	case "jsonl":
+		return true
+	default:
+		return false
+	}
+}
+
+// WriteOmitted appends a list of files left out of the output, with their
+// sizes, so the reader knows what is missing. Only the plain format has room
+// for it; other formats are left untouched.
+func WriteOmitted(w io.Writer, omitted []processor.FileContent, reason string, cfg *config.Config) error {
+	if len(omitted) == 0 || (cfg.Format != "" && cfg.Format != "plain") {
+		return nil
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "-- omitted by %s (%d files) --\n", reason, len(omitted))
+	for _, f := range omitted {
+		fmt.Fprintf(&b, "%s (%d bytes)\n", f.Path, len(f.Content))
+	}
+	_, err := io.WriteString(w, b.String()+"\n")
+	return err
+}
+
+// writePlain writes every file preceded by a "-- path --" header
+func writePlain(w io.Writer, files []processor.FileContent) error {
+	for _, f := range files {
+		if _, err := io.WriteString(w, f.Header()+"\n"+f.Content+"\n\n"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeDiff writes patches as they are, since they carry their own
+// "diff --git" headers, and other files as the plain format does
+func writeDiff(w io.Writer, files []processor.FileContent) error {
+	for _, f := range files {
+		if f.Patch {
+			if _, err := io.WriteString(w, f.Content); err != nil {
+				return err
+			}
+		} else if err := writePlain(w, []processor.FileContent{f}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Record is a file as written by the jsonl format, with metadata for
+// building datasets
+type Record struct {
+	Path     string   `json:"path"`
+	Language string   `json:"language,omitempty"`
+	Category string   `json:"category"`
+	Bytes    int      `json:"bytes"`
+	Lines    int      `json:"lines"`
+	Tokens   int      `json:"tokens"`
+	SHA256   string   `json:"sha256"`
+	Arg      string   `json:"arg,omitempty"`
+	Reason   string   `json:"reason"`
+	Notes    []string `json:"notes,omitempty"`
+	Score    float64  `json:"score,omitempty"`
+	Content  string   `json:"content"`
+}
+
+// writeJSONL writes one JSON record per file
+func writeJSONL(w io.Writer, files []processor.FileContent) error {
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	for _, f := range files {
+		lines := strings.Count(f.Content, "\n")
+		if f.Content != "" && !strings.HasSuffix(f.Content, "\n") {
+			lines++
+		}
+		record := Record{
+			Path:     filepath.ToSlash(f.Path),
+			Language: classify.Language(f.Path),
+			Category: string(classify.Classify(f.Path)),
+			Bytes:    len(f.Content),
+			Lines:    lines,
+			Tokens:   tokens.Count(f.Content),
+			SHA256:   fmt.Sprintf("%x", sha256.Sum256([]byte(f.Content))),
+			Arg:      f.Origin.Arg,
+			Reason:   f.Origin.Rule,
+			Notes:    f.Notes,
+			Score:    f.Score,
+			Content:  f.Content,
+		}
+		if err := enc.Encode(record); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeCat writes file contents verbatim, each followed by sep
+func writeCat(w io.Writer, files []processor.FileContent, sep string) error {
+	for _, f := range files {
+		if _, err := io.WriteString(w, f.Content+sep); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Unescape interprets backslash escapes such as \n, \t, \x1e and \0 in s
+func Unescape(s string) (string, error) {
+	// \0 isn't a valid Go escape but is the conventional spelling of NUL
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\\' && i+1 < len(s) {
+			if s[i+1] == '0' && (i+2 >= len(s) || s[i+2] < '0' || s[i+2] > '7') {
+				b.WriteString(`\x00`)
+				i++
+				continue
+			}
+			b.WriteByte(s[i])
+			b.WriteByte(s[i+1])
+			i++
+			continue
+		}
+		if s[i] == '"' {
+			b.WriteString(`\"`)
+			continue
+		}
+		b.WriteByte(s[i])
+	}
+	return strconv.Unquote(`"` + b.String() + `"`)
+}
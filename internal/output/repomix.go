@@ -0,0 +1,112 @@
+package output
+
+import (
+	"fcopy/internal/processor"
+	"io"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Separators of the repomix plain layout
+const (
+	repomixRule     = "================================================================"
+	repomixFileRule = "================"
+)
+
+// repomixSummary is the file summary repomix puts at the top of its output,
+// which prompts written for repomix refer to
+const repomixSummary = `This file is a merged representation of the selected files, combined into a single document by fcopy in the repomix layout.
+
+` + repomixRule + `
+File Summary
+` + repomixRule + `
+
+Purpose:
+--------
+This file contains a packed representation of the repository's contents.
+It is designed to be easily consumable by AI systems for analysis, code review,
+or other automated processes.
+
+File Format:
+------------
+The content is organized as follows:
+1. This summary section
+2. Directory structure
+3. Multiple file entries, each consisting of:
+  a. A separator line (================)
+  b. The file path (File: path/to/file)
+  c. Another separator line
+  d. The full contents of the file
+  e. A blank line
+
+Usage Guidelines:
+-----------------
+- This file should be treated as read-only. Any changes should be made to the
+  original repository files, not this packed version.
+- When processing this file, use the file path to distinguish
+  between different files in the repository.
+- Be aware that this file may contain sensitive information. Handle it with
+  the same level of security as you would the original repository.
+
+Notes:
+------
+- Some files may have been excluded based on fcopy's ignore rules and options
+- Binary files are not included in this packed representation
+`
+
+// writeRepomix writes files in the plain text layout of repomix: a summary,
+// the directory structure and then every file between separator lines
+func writeRepomix(w io.Writer, files []processor.FileContent) error {
+	var b strings.Builder
+	b.WriteString(repomixSummary)
+
+	b.WriteString("\n" + repomixRule + "\nDirectory Structure\n" + repomixRule + "\n")
+	root := &treeNode{children: make(map[string]*treeNode)}
+	for _, f := range files {
+		node := root
+		for _, part := range strings.Split(filepath.ToSlash(f.Path), "/") {
+			if part == "" || part == "." {
+				continue
+			}
+			child, ok := node.children[part]
+			if !ok {
+				child = &treeNode{children: make(map[string]*treeNode)}
+				node.children[part] = child
+			}
+			node = child
+		}
+	}
+	drawIndented(&b, root, "")
+
+	b.WriteString("\n" + repomixRule + "\nFiles\n" + repomixRule + "\n")
+	for _, f := range files {
+		b.WriteString("\n" + repomixFileRule + "\nFile: " + filepath.ToSlash(f.Path) + "\n" + repomixFileRule + "\n")
+		b.WriteString(f.Content)
+		if !strings.HasSuffix(f.Content, "\n") {
+			b.WriteString("\n")
+		}
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// drawIndented writes the children of node indented by two spaces per
+// level, directories marked with a slash
+func drawIndented(b *strings.Builder, node *treeNode, indent string) {
+	names := make([]string, 0, len(node.children))
+	for name := range node.children {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		child := node.children[name]
+		if len(child.children) > 0 {
+			b.WriteString(indent + name + "/\n")
+			drawIndented(b, child, indent+"  ")
+		} else {
+			b.WriteString(indent + name + "\n")
+		}
+	}
+}
@@ -0,0 +1,95 @@
+package output
+
+import (
+	"bufio"
+	"bytes"
+	"fcopy/internal/tokens"
+	"io"
+	"os"
+)
+
+// Sink is where output goes as it is formatted, rather than being built up
+// in memory first: a file, the clipboard or anything else written to as an
+// io.Writer. Close delivers what was written, for destinations that take it
+// all at once, and releases the destination.
+type Sink interface {
+	io.Writer
+	Close() error
+}
+
+// FileSink writes output to the file at Path, created on the first write so
+// that a run producing nothing leaves no empty file behind
+type FileSink struct {
+	Path string
+
+	f *os.File
+	w *bufio.Writer
+}
+
+func (s *FileSink) Write(p []byte) (int, error) {
+	if s.f == nil {
+		f, err := os.Create(s.Path)
+		if err != nil {
+			return 0, err
+		}
+		s.f, s.w = f, bufio.NewWriterSize(f, 64*1024)
+	}
+	return s.w.Write(p)
+}
+
+// Close flushes the output and closes the file
+func (s *FileSink) Close() error {
+	if s.f == nil {
+		return nil
+	}
+	if err := s.w.Flush(); err != nil {
+		s.f.Close()
+		return err
+	}
+	return s.f.Close()
+}
+
+// BufferSink collects output in memory and hands it to Deliver on Close, for
+// destinations like the clipboard that take everything at once
+type BufferSink struct {
+	Deliver func(data []byte) error
+
+	buf bytes.Buffer
+}
+
+func (s *BufferSink) Write(p []byte) (int, error) {
+	return s.buf.Write(p)
+}
+
+// Close delivers everything written
+func (s *BufferSink) Close() error {
+	return s.Deliver(s.buf.Bytes())
+}
+
+// WriterSink returns a Sink writing to w, whose Close does nothing
+func WriterSink(w io.Writer) Sink {
+	return writerSink{w}
+}
+
+type writerSink struct {
+	io.Writer
+}
+
+func (writerSink) Close() error {
+	return nil
+}
+
+// Meter passes writes on to W, counting the bytes and tokens written, so
+// output can be measured without keeping it
+type Meter struct {
+	W      io.Writer
+	Bytes  int64
+	Tokens int
+}
+
+func (m *Meter) Write(p []byte) (int, error) {
+	n, err := m.W.Write(p)
+	m.Bytes += int64(n)
+	m.Tokens += tokens.Count(string(p[:n]))
+	return n, err
+}
@@ -0,0 +1,150 @@
+package output
+
+import (
+	"fcopy/internal/processor"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// templateFields are the variables every prompt template gets; --var can't
+// redefine them
+var templateFields = []string{"Files", "Tree", "Date", "Paths", "Count"}
+
+// Prompt wraps the output in a user-defined text/template, so the clipboard
+// holds a ready-to-send prompt rather than bare files
+type Prompt struct {
+	tmpl *template.Template
+	vars map[string]string
+}
+
+// LoadPrompt parses the template at path and the key=value pairs given with
+// --var. Unknown variables used in the template are reported when rendering.
+func LoadPrompt(path string, vars []string) (*Prompt, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading prompt template: %v", err)
+	}
+	tmpl, err := template.New(filepath.Base(path)).Option("missingkey=error").Parse(string(data))
+	if err != nil {
+		return nil, fmt.Errorf("invalid prompt template: %v", err)
+	}
+
+	p := &Prompt{tmpl: tmpl, vars: make(map[string]string)}
+	for _, v := range vars {
+		key, value, ok := strings.Cut(v, "=")
+		if !ok || key == "" {
+			return nil, fmt.Errorf("invalid --var %q (expected key=value)", v)
+		}
+		for _, field := range templateFields {
+			if key == field {
+				return nil, fmt.Errorf("--var %s would hide the built-in template variable of that name", key)
+			}
+		}
+		p.vars[key] = value
+	}
+	return p, nil
+}
+
+// Render writes the template to w. {{.Files}} is the formatted output,
+// {{.Tree}} a directory tree of the files, {{.Paths}} their paths,
+// {{.Count}} their number and {{.Date}} today's date; --var values are
+// available under their own names.
+func (p *Prompt) Render(w io.Writer, bundle string, files []processor.FileContent, now time.Time) error {
+	paths := make([]string, len(files))
+	for i, f := range files {
+		paths[i] = filepath.ToSlash(f.Path)
+	}
+
+	data := map[string]any{
+		"Files": bundle,
+		"Tree":  Tree(paths),
+		"Date":  now.Format("2006-01-02"),
+		"Paths": paths,
+		"Count": len(files),
+	}
+	for key, value := range p.vars {
+		data[key] = value
+	}
+	if err := p.tmpl.Execute(w, data); err != nil {
+		return fmt.Errorf("error rendering prompt template: %v", err)
+	}
+	return nil
+}
+
+// treeNode is a directory or file in the tree drawn by Tree
+type treeNode struct {
+	children map[string]*treeNode
+}
+
+// Tree draws slash-separated paths as an indented tree like the tree command,
+// relative to their common parent directory
+func Tree(paths []string) string {
+	root := &treeNode{children: make(map[string]*treeNode)}
+	prefix := commonDir(paths)
+	for _, path := range paths {
+		node := root
+		for _, part := range strings.Split(strings.TrimPrefix(path, prefix), "/") {
+			if part == "" {
+				continue
+			}
+			child, ok := node.children[part]
+			if !ok {
+				child = &treeNode{children: make(map[string]*treeNode)}
+				node.children[part] = child
+			}
+			node = child
+		}
+	}
+
+	var b strings.Builder
+	if prefix == "" {
+		b.WriteString(".\n")
+	} else {
+		b.WriteString(prefix + "\n")
+	}
+	drawTree(&b, root, "")
+	return b.String()
+}
+
+// drawTree writes the children of node, directories marked with a slash
+func drawTree(b *strings.Builder, node *treeNode, indent string) {
+	names := make([]string, 0, len(node.children))
+	for name := range node.children {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for i, name := range names {
+		child := node.children[name]
+		branch, next := "├── ", "│   "
+		if i == len(names)-1 {
+			branch, next = "└── ", "    "
+		}
+		if len(child.children) > 0 {
+			name += "/"
+		}
+		b.WriteString(indent + branch + name + "\n")
+		drawTree(b, child, indent+next)
+	}
+}
+
+// commonDir returns the longest directory prefix, ending in a slash, shared
+// by all paths
+func commonDir(paths []string) string {
+	if len(paths) == 0 {
+		return ""
+	}
+	prefix := paths[0][:strings.LastIndex(paths[0], "/")+1]
+	for _, path := range paths[1:] {
+		for !strings.HasPrefix(path, prefix) {
+			prefix = prefix[:strings.LastIndex(strings.TrimSuffix(prefix, "/"), "/")+1]
+		}
+	}
+	return prefix
+}
@@ -0,0 +1,66 @@
+package picker
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+)
+
+// ErrNoFzf is returned by Fzf when fzf isn't installed
+var ErrNoFzf = errors.New("fzf not found in PATH")
+
+// Fzf lets the user choose among candidates with fzf, so its key bindings
+// and $FZF_DEFAULT_OPTS apply. Like Pick, it returns the indexes of the
+// chosen candidates, or none if the user gave up.
+func Fzf(candidates []Candidate, opts Options) ([]int, error) {
+	path, err := exec.LookPath("fzf")
+	if err != nil {
+		return nil, ErrNoFzf
+	}
+
+	// Each line carries its index, hidden from the user, so the selection
+	// maps back to candidates even if two of them look the same
+	var input bytes.Buffer
+	for i, c := range candidates {
+		name := c.Path
+		if c.IsDir {
+			name += string(os.PathSeparator)
+		}
+		fmt.Fprintf(&input, "%d\t%s\n", i, name)
+	}
+
+	args := []string{"--delimiter", "\t", "--with-nth", "2..", "--no-sort"}
+	if opts.Title != "" {
+		args = append(args, "--header", opts.Title)
+	}
+	if opts.Multi {
+		args = append(args, "--multi")
+	}
+
+	cmd := exec.Command(path, args...)
+	cmd.Stdin = &input
+	cmd.Stderr = os.Stderr
+	output, err := cmd.Output()
+	if err != nil {
+		// fzf exits with 1 when nothing matched the query and 130 when it was
+		// cancelled
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) && (exitErr.ExitCode() == 1 || exitErr.ExitCode() == 130) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var chosen []int
+	for _, line := range strings.Split(strings.TrimRight(string(output), "\n"), "\n") {
+		field, _, _ := strings.Cut(line, "\t")
+		if i, err := strconv.Atoi(field); err == nil && i >= 0 && i < len(candidates) {
+			chosen = append(chosen, i)
+		}
+	}
+	return chosen, nil
+}
@@ -0,0 +1,308 @@
+// Package picker lets the user choose among fuzzy match candidates in a
+// full-screen terminal interface with a preview of the highlighted file
+package picker
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/mattn/go-runewidth"
+)
+
+// Candidate is one choice offered by Pick
+type Candidate struct {
+	Path  string
+	IsDir bool
+	Note  string // Shown after the path, such as the match score
+}
+
+// Options control Pick
+type Options struct {
+	Title string
+	Multi bool // Space toggles candidates and Enter returns all toggled ones
+	// Filter scores a candidate path against what the user typed; lower
+	// scores are listed first and ok false hides the candidate
+	Filter func(query, path string) (score int, ok bool)
+	In     io.Reader
+	Out    io.Writer
+}
+
+// previewLines is how many lines of a file are read for the preview
+const previewLines = 200
+
+// Pick shows candidates and returns the indexes of the chosen ones, in the
+// order they were listed, or none if the user gave up
+func Pick(candidates []Candidate, opts Options) ([]int, error) {
+	m := &model{
+		candidates: candidates,
+		opts:       opts,
+		selected:   make(map[int]bool),
+		previews:   make(map[string][]string),
+	}
+	m.refilter()
+
+	var programOpts []tea.ProgramOption
+	programOpts = append(programOpts, tea.WithAltScreen())
+	if opts.In != nil {
+		programOpts = append(programOpts, tea.WithInput(opts.In))
+	}
+	if opts.Out != nil {
+		programOpts = append(programOpts, tea.WithOutput(opts.Out))
+	}
+	if _, err := tea.NewProgram(m, programOpts...).Run(); err != nil {
+		return nil, err
+	}
+	return m.chosen, nil
+}
+
+// model is the bubbletea state of a Pick
+type model struct {
+	candidates []Candidate
+	opts       Options
+
+	query    []rune
+	visible  []int // Indexes of the candidates matching query, best first
+	cursor   int   // Position in visible
+	offset   int   // First position of visible on screen
+	selected map[int]bool
+	chosen   []int
+
+	width, height int
+	previews      map[string][]string
+}
+
+func (m *model) Init() tea.Cmd { return nil }
+
+func (m *model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width, m.height = msg.Width, msg.Height
+	case tea.KeyMsg:
+		switch msg.Type {
+		case tea.KeyCtrlC, tea.KeyEsc:
+			m.chosen = nil
+			return m, tea.Quit
+		case tea.KeyEnter:
+			m.chosen = m.result()
+			return m, tea.Quit
+		case tea.KeyUp, tea.KeyCtrlP, tea.KeyShiftTab:
+			m.move(-1)
+		case tea.KeyDown, tea.KeyCtrlN:
+			m.move(1)
+		case tea.KeyPgUp:
+			m.move(-m.listHeight())
+		case tea.KeyPgDown:
+			m.move(m.listHeight())
+		case tea.KeyTab:
+			m.toggle()
+			m.move(1)
+		case tea.KeySpace:
+			if m.opts.Multi {
+				m.toggle()
+				m.move(1)
+			} else {
+				m.type_([]rune{' '})
+			}
+		case tea.KeyBackspace:
+			if len(m.query) > 0 {
+				m.query = m.query[:len(m.query)-1]
+				m.refilter()
+			}
+		case tea.KeyCtrlU:
+			m.query = nil
+			m.refilter()
+		case tea.KeyRunes:
+			m.type_(msg.Runes)
+		}
+	}
+	return m, nil
+}
+
+// type_ appends typed runes to the query
+func (m *model) type_(runes []rune) {
+	m.query = append(m.query, runes...)
+	m.refilter()
+}
+
+// toggle flips the selection of the highlighted candidate in multi mode
+func (m *model) toggle() {
+	if !m.opts.Multi || len(m.visible) == 0 {
+		return
+	}
+	i := m.visible[m.cursor]
+	m.selected[i] = !m.selected[i]
+}
+
+// move moves the cursor by delta, keeping it on screen
+func (m *model) move(delta int) {
+	m.cursor = max(0, min(m.cursor+delta, len(m.visible)-1))
+	if m.cursor < m.offset {
+		m.offset = m.cursor
+	}
+	if h := m.listHeight(); m.cursor >= m.offset+h {
+		m.offset = m.cursor - h + 1
+	}
+}
+
+// result returns the toggled candidates, or the highlighted one if none are
+func (m *model) result() []int {
+	var chosen []int
+	for i := range m.candidates {
+		if m.selected[i] {
+			chosen = append(chosen, i)
+		}
+	}
+	if len(chosen) == 0 && len(m.visible) > 0 {
+		chosen = []int{m.visible[m.cursor]}
+	}
+	return chosen
+}
+
+// refilter recomputes the visible candidates after the query changed
+func (m *model) refilter() {
+	query := strings.TrimSpace(string(m.query))
+	scores := make(map[int]int)
+	m.visible = m.visible[:0]
+	for i, c := range m.candidates {
+		if query != "" && m.opts.Filter != nil {
+			score, ok := m.opts.Filter(query, c.Path)
+			if !ok {
+				continue
+			}
+			scores[i] = score
+		}
+		m.visible = append(m.visible, i)
+	}
+	sort.SliceStable(m.visible, func(a, b int) bool {
+		return scores[m.visible[a]] < scores[m.visible[b]]
+	})
+	m.cursor, m.offset = 0, 0
+}
+
+// listHeight is the number of candidates shown at once
+func (m *model) listHeight() int {
+	return max(1, m.height-3)
+}
+
+func (m *model) View() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s> %s█\n", m.opts.Title, string(m.query))
+	help := "↑/↓ move, Enter pick, Esc cancel"
+	if m.opts.Multi {
+		help = "↑/↓ move, Space toggle, Enter pick, Esc cancel"
+	}
+	fmt.Fprintf(&b, "  %d/%d  %s\n", len(m.visible), len(m.candidates), help)
+
+	listWidth := m.width
+	var preview []string
+	if m.width >= 60 {
+		listWidth = m.width / 2
+		if len(m.visible) > 0 {
+			preview = m.preview(m.candidates[m.visible[m.cursor]])
+		}
+	}
+
+	for row := 0; row < m.listHeight(); row++ {
+		line := ""
+		if pos := m.offset + row; pos < len(m.visible) {
+			i := m.visible[pos]
+			c := m.candidates[i]
+			marker := "  "
+			if pos == m.cursor {
+				marker = "> "
+			}
+			if m.opts.Multi {
+				if m.selected[i] {
+					marker += "[x] "
+				} else {
+					marker += "[ ] "
+				}
+			}
+			path := c.Path
+			if c.IsDir {
+				path += string(os.PathSeparator)
+			}
+			line = marker + path
+			if c.Note != "" {
+				line += "  " + c.Note
+			}
+		}
+		if preview != nil {
+			line = fit(line, listWidth-1) + "│"
+			if row < len(preview) {
+				line += " " + fit(preview[row], m.width-listWidth-2)
+			}
+		} else if m.width > 0 {
+			line = fit(line, m.width)
+		}
+		b.WriteString(strings.TrimRight(line, " "))
+		if row < m.listHeight()-1 {
+			b.WriteByte('\n')
+		}
+	}
+	return b.String()
+}
+
+// preview returns the first lines of a file, or the entries of a directory
+func (m *model) preview(c Candidate) []string {
+	if lines, ok := m.previews[c.Path]; ok {
+		return lines
+	}
+
+	var lines []string
+	if c.IsDir {
+		entries, err := os.ReadDir(c.Path)
+		if err != nil {
+			lines = []string{err.Error()}
+		}
+		for _, e := range entries {
+			name := e.Name()
+			if e.IsDir() {
+				name += string(os.PathSeparator)
+			}
+			lines = append(lines, name)
+		}
+	} else {
+		lines = readHead(c.Path)
+	}
+	m.previews[c.Path] = lines
+	return lines
+}
+
+// readHead returns the first previewLines lines of the file at path
+func readHead(path string) []string {
+	file, err := os.Open(path)
+	if err != nil {
+		return []string{err.Error()}
+	}
+	defer file.Close()
+
+	data := make([]byte, 64*1024)
+	n, _ := io.ReadFull(file, data)
+	data = data[:n]
+	if bytes.IndexByte(data, 0) >= 0 {
+		return []string{"(binary file)"}
+	}
+	lines := strings.Split(strings.ReplaceAll(string(data), "\t", "    "), "\n")
+	if len(lines) > previewLines {
+		lines = lines[:previewLines]
+	}
+	for i, line := range lines {
+		lines[i] = strings.TrimRight(line, "\r")
+	}
+	return lines
+}
+
+// fit truncates or pads s to exactly width terminal cells
+func fit(s string, width int) string {
+	if width <= 0 {
+		return ""
+	}
+	s = runewidth.Truncate(s, width, "…")
+	return runewidth.FillRight(s, width)
+}
@@ -0,0 +1,72 @@
+package processor
+
+import (
+	"encoding/base64"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+)
+
+// BinaryModes lists the ways binary files given explicitly can be included
+var BinaryModes = []string{"placeholder", "base64", "skip"}
+
+// binaryFile represents a binary file the user asked for explicitly. It is
+// embedded as base64 in base64 mode when no larger than limit, and replaced
+// by a one-line placeholder naming its type and size otherwise.
+func binaryFile(path string, fileInfo os.FileInfo, origin Origin, mode string, limit int64) (FileContent, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return FileContent{}, err
+	}
+	defer file.Close()
+
+	embed := mode == "base64" && fileInfo.Size() <= limit
+	readLimit := int64(512) // Enough to sniff the content type
+	if embed {
+		readLimit = fileInfo.Size()
+	}
+	data, err := io.ReadAll(io.LimitReader(file, readLimit))
+	if err != nil {
+		return FileContent{}, err
+	}
+	mimeType := strings.SplitN(http.DetectContentType(data), ";", 2)[0]
+
+	if !embed {
+		return FileContent{
+			Path:    path,
+			Content: fmt.Sprintf("[binary skipped: %s, %s]", mimeType, formatSize(fileInfo.Size())),
+			Origin:  origin,
+		}, nil
+	}
+	return FileContent{
+		Path:    path,
+		Content: wrap(base64.StdEncoding.EncodeToString(data), 76),
+		Origin:  origin,
+		Notes:   []string{fmt.Sprintf("base64, %s, %s", mimeType, formatSize(fileInfo.Size()))},
+	}, nil
+}
+
+// formatSize formats n bytes for humans, such as "34 KB"
+func formatSize(n int64) string {
+	switch {
+	case n >= 1<<20:
+		return fmt.Sprintf("%.1f MB", float64(n)/(1<<20))
+	case n >= 1<<10:
+		return fmt.Sprintf("%d KB", (n+1<<9)>>10)
+	default:
+		return fmt.Sprintf("%d bytes", n)
+	}
+}
+
+// wrap breaks s into lines of at most width characters
+func wrap(s string, width int) string {
+	var b strings.Builder
+	for len(s) > width {
+		b.WriteString(s[:width] + "\n")
+		s = s[width:]
+	}
+	b.WriteString(s)
+	return b.String()
+}
@@ -0,0 +1,153 @@
+package processor
+
+import (
+	"context"
+	"fcopy/internal/classify"
+	"fcopy/internal/config"
+	"fcopy/internal/finder"
+	"fcopy/internal/gitutil"
+	"fcopy/internal/ignore"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Explain runs path through the same filters fcopy applies to it, both when
+// it is given as an argument and when it is found while walking root. It
+// returns the rule that would leave it out in each case, or "" if it would
+// be copied.
+func Explain(path, root string, cfg *config.Config) (direct, walked string, err error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return "", "", err
+	}
+	if info.IsDir() {
+		return "", walkDirReason(path, root, cfg), nil
+	}
+
+	direct = contentReason(path, info, Origin{}, cfg)
+	walked = walkReason(path, root, cfg)
+	if walked == "" {
+		var attributes ignore.Attributes
+		if !cfg.IncludeGenerated && attributes.IsGenerated(path) {
+			walked = "marked linguist-generated in .gitattributes (use --include-generated to include it)"
+		} else {
+			walked = contentReason(path, info, Origin{Discovered: true}, cfg)
+		}
+	}
+	return direct, walked, nil
+}
+
+// walkReason returns the rule that keeps the walker from picking up the file
+// at path, judged by its name and the directories above it
+func walkReason(path, root string, cfg *config.Config) string {
+	if info, err := os.Lstat(path); err == nil && info.Mode()&os.ModeSymlink != 0 && !cfg.FollowSymlinks {
+		return "symlink (use --follow-symlinks to include it)"
+	}
+
+	if reason := walkDirReason(filepath.Dir(path), root, cfg); reason != "" {
+		return reason
+	}
+
+	if isArtifact(path) {
+		return "fcopy's own artifact"
+	}
+	if isOutputFile(path, cfg) {
+		return "written by this run (--output or --manifest)"
+	}
+
+	if cfg.GitOnly {
+		tracked, err := gitutil.TrackedFiles(root)
+		if err != nil {
+			return fmt.Sprintf("can't list files tracked by git: %v", err)
+		}
+		found := false
+		for _, t := range tracked {
+			if sameFile(t, path) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return "not tracked by git (--git-only)"
+		}
+	} else if reason := finder.IgnoreReason(path, false, cfg); reason != "" {
+		return reason
+	}
+
+	if cfg.NoTests && isTestPath(path, false) {
+		return "test file (--no-tests)"
+	}
+	if len(cfg.Types) > 0 && !classify.Matches(path, cfg.Types) {
+		return fmt.Sprintf("%s file, not selected by --type %s", classify.Classify(path), strings.Join(cfg.Types, ","))
+	}
+	return ""
+}
+
+// walkDirReason returns the rule that keeps the walker out of dir, checking
+// every directory between root and dir. Directories outside root are only
+// judged by their own name.
+func walkDirReason(dir, root string, cfg *config.Config) string {
+	dirs := []string{dir}
+	if rel, err := relPath(root, dir); err == nil && !strings.HasPrefix(rel, "..") {
+		dirs = nil
+		if rel != "." {
+			parts := strings.Split(rel, string(filepath.Separator))
+			for i := range parts {
+				dirs = append(dirs, filepath.Join(root, filepath.Join(parts[:i+1]...)))
+			}
+		}
+	}
+
+	for _, d := range dirs {
+		if !cfg.GitOnly {
+			if reason := finder.IgnoreReason(d, true, cfg); reason != "" {
+				return fmt.Sprintf("inside %s: %s", d, reason)
+			}
+		}
+		if cfg.NoTests && isTestPath(d, true) {
+			return fmt.Sprintf("inside test directory %s (--no-tests)", d)
+		}
+	}
+	return ""
+}
+
+// contentReason returns why ProcessSingleFile would leave out path, or "" if
+// it would be copied
+func contentReason(path string, info os.FileInfo, origin Origin, cfg *config.Config) string {
+	results := make(chan FileContent, 1)
+	if err := ProcessSingleFile(context.Background(), path, info, origin, cfg, results); err != nil {
+		if strings.HasPrefix(err.Error(), "file too large") {
+			return fmt.Sprintf("%v, over --max-size %d (use --truncate to include part of it)", err, cfg.MaxFileSize)
+		}
+		return err.Error()
+	}
+	return ""
+}
+
+// relPath returns target relative to base after making both absolute
+func relPath(base, target string) (string, error) {
+	absBase, err := filepath.Abs(base)
+	if err != nil {
+		return "", err
+	}
+	absTarget, err := filepath.Abs(target)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Rel(absBase, absTarget)
+}
+
+// sameFile reports whether a and b name the same file
+func sameFile(a, b string) bool {
+	ia, err := os.Stat(a)
+	if err != nil {
+		return false
+	}
+	ib, err := os.Stat(b)
+	if err != nil {
+		return false
+	}
+	return os.SameFile(ia, ib)
+}
@@ -0,0 +1,23 @@
+//go:build !windows
+
+package processor
+
+import (
+	"os"
+	"syscall"
+)
+
+// fileID uniquely identifies a file or directory on disk
+type fileID struct {
+	dev uint64
+	ino uint64
+}
+
+// getFileID returns the device and inode of the file described by info
+func getFileID(path string, info os.FileInfo) (fileID, bool) {
+	st, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return fileID{}, false
+	}
+	return fileID{dev: uint64(st.Dev), ino: uint64(st.Ino)}, true
+}
@@ -0,0 +1,41 @@
+//go:build windows
+
+package processor
+
+import (
+	"os"
+	"syscall"
+)
+
+// fileID uniquely identifies a file or directory on disk by the serial
+// number of its volume and its NTFS file index, which hard links share
+type fileID struct {
+	volume uint32
+	index  uint64
+}
+
+// getFileID returns the volume serial number and file index of the file at
+// path. os.FileInfo doesn't expose them, so the file is opened to ask.
+func getFileID(path string, info os.FileInfo) (fileID, bool) {
+	name, err := syscall.UTF16PtrFromString(path)
+	if err != nil {
+		return fileID{}, false
+	}
+	// FILE_FLAG_BACKUP_SEMANTICS is needed to open directories
+	handle, err := syscall.CreateFile(name, 0,
+		syscall.FILE_SHARE_READ|syscall.FILE_SHARE_WRITE|syscall.FILE_SHARE_DELETE,
+		nil, syscall.OPEN_EXISTING, syscall.FILE_FLAG_BACKUP_SEMANTICS, 0)
+	if err != nil {
+		return fileID{}, false
+	}
+	defer syscall.CloseHandle(handle)
+
+	var data syscall.ByHandleFileInformation
+	if err := syscall.GetFileInformationByHandle(handle, &data); err != nil {
+		return fileID{}, false
+	}
+	return fileID{
+		volume: data.VolumeSerialNumber,
+		index:  uint64(data.FileIndexHigh)<<32 | uint64(data.FileIndexLow),
+	}, true
+}
@@ -0,0 +1,98 @@
+package processor
+
+import (
+	"regexp"
+	"strings"
+)
+
+// licenseWords matches text found in copyright and license headers
+var licenseWords = regexp.MustCompile(`(?i)copyright|\blicen[cs]ed?\b|spdx-license-identifier|all rights reserved|permission is hereby granted`)
+
+// preambleLine matches lines that must stay above a license header, such as
+// shebangs and Python encoding declarations
+var preambleLine = regexp.MustCompile(`^(#!|#.*-\*-.*coding[:=])`)
+
+// lineComments are the line comment markers a license header may use
+var lineComments = []string{"//", "#", "--", ";;", "%", "'"}
+
+// blockComments are the block comment delimiters a license header may use
+var blockComments = [][2]string{{"/*", "*/"}, {"<!--", "-->"}, {"(*", "*)"}, {`"""`, `"""`}, {"{-", "-}"}}
+
+// StripLicense removes the copyright or license comment block at the top of
+// content, along with the blank lines after it. The block has to be followed
+// by a blank line, so documentation comments are left alone. Shebangs and encoding
+// declarations above it are kept. It returns the new content and the number
+// of lines removed.
+func StripLicense(content string) (string, int) {
+	lines := strings.SplitAfter(content, "\n")
+	start := 0
+	for start < len(lines) && preambleLine.MatchString(lines[start]) {
+		start++
+	}
+	blank := start
+	for blank < len(lines) && strings.TrimSpace(lines[blank]) == "" {
+		blank++
+	}
+
+	end := commentBlockEnd(lines, blank)
+	if end <= blank || !licenseWords.MatchString(strings.Join(lines[blank:end], "")) {
+		return content, 0
+	}
+	// A comment running straight into code documents it, like a Go package
+	// comment, even if it mentions a license
+	if end < len(lines) && strings.TrimSpace(lines[end]) != "" {
+		return content, 0
+	}
+	for end < len(lines) && lines[end] != "" && strings.TrimSpace(lines[end]) == "" {
+		end++
+	}
+
+	stripped := strings.Join(lines[:start], "") + strings.Join(lines[end:], "")
+	return stripped, end - start
+}
+
+// commentBlockEnd returns the index of the first line after the comment
+// starting at lines[start], or start if no comment starts there
+func commentBlockEnd(lines []string, start int) int {
+	if start >= len(lines) {
+		return start
+	}
+	first := strings.TrimSpace(lines[start])
+
+	for _, delims := range blockComments {
+		if !strings.HasPrefix(first, delims[0]) {
+			continue
+		}
+		rest := strings.TrimPrefix(first, delims[0])
+		if strings.Contains(rest, delims[1]) {
+			return start + 1
+		}
+		for i := start + 1; i < len(lines); i++ {
+			if strings.Contains(lines[i], delims[1]) {
+				return i + 1
+			}
+		}
+		return start // Unterminated, leave it alone
+	}
+
+	for _, marker := range lineComments {
+		if !strings.HasPrefix(first, marker) || strings.HasPrefix(first, "#!") {
+			continue
+		}
+		end := start
+		for end < len(lines) && strings.HasPrefix(strings.TrimSpace(lines[end]), marker) &&
+			!isDirective(lines[end]) {
+			end++
+		}
+		return end
+	}
+	return start
+}
+
+// isDirective reports whether line is a comment the compiler or tooling
+// reads, such as //go:build or // +build, which must never be stripped
+func isDirective(line string) bool {
+	line = strings.TrimSpace(line)
+	return strings.HasPrefix(line, "//go:") || strings.HasPrefix(line, "// +build") ||
+		strings.HasPrefix(line, "//nolint") || strings.HasPrefix(line, "# frozen_string_literal")
+}
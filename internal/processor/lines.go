@@ -0,0 +1,119 @@
+package processor
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// LineRange is an inclusive range of 1-based line numbers. An End of 0 means
+// "until the end of the file".
+type LineRange struct {
+	Start int
+	End   int
+}
+
+// String formats the range as START-END
+func (r LineRange) String() string {
+	switch {
+	case r.End == 0:
+		return fmt.Sprintf("%d-", r.Start)
+	case r.Start == r.End:
+		return strconv.Itoa(r.Start)
+	default:
+		return fmt.Sprintf("%d-%d", r.Start, r.End)
+	}
+}
+
+// ParseLineRanges parses a comma-separated list of ranges such as
+// "10-20,35,40-"
+func ParseLineRanges(s string) ([]LineRange, error) {
+	var ranges []LineRange
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+
+		var r LineRange
+		var err error
+		if i := strings.Index(part, "-"); i >= 0 {
+			if r.Start, err = strconv.Atoi(part[:i]); err != nil {
+				return nil, fmt.Errorf("invalid line range %q", part)
+			}
+			if end := part[i+1:]; end != "" {
+				if r.End, err = strconv.Atoi(end); err != nil {
+					return nil, fmt.Errorf("invalid line range %q", part)
+				}
+			}
+		} else {
+			if r.Start, err = strconv.Atoi(part); err != nil {
+				return nil, fmt.Errorf("invalid line range %q", part)
+			}
+			r.End = r.Start
+		}
+
+		if r.Start < 1 || (r.End != 0 && r.End < r.Start) {
+			return nil, fmt.Errorf("invalid line range %q", part)
+		}
+		ranges = append(ranges, r)
+	}
+	return ranges, nil
+}
+
+// ExtractLines returns only the lines of content covered by ranges, with an
+// elision marker wherever lines were left out
+func ExtractLines(content string, ranges []LineRange) string {
+	lines := strings.Split(strings.TrimSuffix(content, "\n"), "\n")
+	sorted := append([]LineRange(nil), ranges...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Start < sorted[j].Start })
+
+	var b strings.Builder
+	next := 1 // First line not yet written or elided
+	for _, r := range sorted {
+		start, end := r.Start, r.End
+		if end == 0 || end > len(lines) {
+			end = len(lines)
+		}
+		if start < next {
+			start = next
+		}
+		if start > end {
+			continue
+		}
+		if start > next {
+			b.WriteString(elision(next, start-1))
+		}
+		for _, line := range lines[start-1 : end] {
+			b.WriteString(line + "\n")
+		}
+		next = end + 1
+	}
+	if next <= len(lines) {
+		b.WriteString(elision(next, len(lines)))
+	}
+	return b.String()
+}
+
+// elision returns the marker replacing lines start to end
+func elision(start, end int) string {
+	return fmt.Sprintf("... (%s omitted)\n", describeLines(start, end))
+}
+
+// describeLines describes the line span start to end
+func describeLines(start, end int) string {
+	if start == end {
+		return fmt.Sprintf("line %d", start)
+	}
+	return fmt.Sprintf("lines %d-%d", start, end)
+}
+
+// FormatLineRanges joins ranges for display in a header note
+func FormatLineRanges(ranges []LineRange) string {
+	parts := make([]string, len(ranges))
+	for i, r := range ranges {
+		parts[i] = r.String()
+	}
+	return strings.Join(parts, ",")
+}
@@ -0,0 +1,76 @@
+package processor
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// Thresholds used to recognize minified or bundled files
+const (
+	minifiedMinSize       = 2 * 1024  // Smaller files are never considered minified
+	minifiedAvgLineLength = 250       // Average characters per line in minified code
+	minifiedLongLine      = 10 * 1024 // A single line this long dominates the file
+)
+
+// isMinified reports whether content looks like minified or bundled code,
+// judged by its average line length and by single lines holding most of it
+func isMinified(content []byte) bool {
+	if len(content) < minifiedMinSize {
+		return false
+	}
+
+	lines := bytes.Count(content, []byte("\n")) + 1
+	if len(content)/lines > minifiedAvgLineLength {
+		return true
+	}
+
+	longest := 0
+	for _, line := range bytes.Split(content, []byte("\n")) {
+		if len(line) > longest {
+			longest = len(line)
+		}
+	}
+	return longest > minifiedLongLine && longest > len(content)/2
+}
+
+// cutLongLines shortens every line of text longer than max bytes, marking
+// how much was cut, and returns the number of lines shortened
+func cutLongLines(text string, max int) (string, int) {
+	lines := strings.SplitAfter(text, "\n")
+	cut := 0
+	for i, line := range lines {
+		body := strings.TrimSuffix(line, "\n")
+		if len(body) <= max {
+			continue
+		}
+		end := max
+		for end > 0 && !utf8.RuneStart(body[end]) {
+			end--
+		}
+		lines[i] = fmt.Sprintf("%s... (%d more characters)%s", body[:end],
+			utf8.RuneCountInString(body[end:]), line[len(body):])
+		cut++
+	}
+	if cut == 0 {
+		return text, 0
+	}
+	return strings.Join(lines, ""), cut
+}
+
+// longestLine returns the length in bytes of the longest line in content
+func longestLine(content []byte) int {
+	longest := 0
+	for len(content) > 0 {
+		i := bytes.IndexByte(content, '\n')
+		if i < 0 {
+			i = len(content)
+		}
+		if i > longest {
+			longest = i
+		}
+		content = content[min(i+1, len(content)):]
+	}
+	return longest
+}
@@ -0,0 +1,92 @@
+package processor
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// notebookSizeFactor is how many times --max-size a notebook may be on disk,
+// since outputs and embedded images make up most of it and are dropped
+const notebookSizeFactor = 50
+
+// notebook is the part of a Jupyter notebook's JSON that fcopy reads
+type notebook struct {
+	Cells    []notebookCell `json:"cells"`
+	Metadata struct {
+		LanguageInfo struct {
+			Name string `json:"name"`
+		} `json:"language_info"`
+	} `json:"metadata"`
+}
+
+// notebookCell is a single cell; source is a string or a list of lines
+type notebookCell struct {
+	CellType string          `json:"cell_type"`
+	Source   json.RawMessage `json:"source"`
+}
+
+// text returns the cell's source as a single string
+func (c notebookCell) text() string {
+	var lines []string
+	if err := json.Unmarshal(c.Source, &lines); err == nil {
+		return strings.Join(lines, "")
+	}
+	var s string
+	json.Unmarshal(c.Source, &s)
+	return s
+}
+
+// convertNotebook renders the code cells of a Jupyter notebook as plain
+// source in the "# %%" cell format, dropping outputs and attachments.
+// Markdown cells are included as comments when markdown is set. It returns
+// a note describing what was kept.
+func convertNotebook(data []byte, markdown bool) (string, string, error) {
+	var nb notebook
+	if err := json.Unmarshal(data, &nb); err != nil {
+		return "", "", fmt.Errorf("invalid notebook: %v", err)
+	}
+
+	// Comment markdown with the notebook language's line comment
+	comment := "#"
+	switch strings.ToLower(nb.Metadata.LanguageInfo.Name) {
+	case "javascript", "typescript", "c++", "java", "scala", "go", "rust", "kotlin", "c#":
+		comment = "//"
+	}
+
+	var b strings.Builder
+	code, text := 0, 0
+	for _, cell := range nb.Cells {
+		source := strings.TrimRight(cell.text(), "\n")
+		if strings.TrimSpace(source) == "" {
+			continue
+		}
+
+		marker := comment + " %%"
+		switch {
+		case cell.CellType == "code":
+			code++
+		case cell.CellType == "markdown" && markdown:
+			text++
+			marker += " [markdown]"
+			var commented []string
+			for _, line := range strings.Split(source, "\n") {
+				commented = append(commented, strings.TrimRight(comment+" "+line, " "))
+			}
+			source = strings.Join(commented, "\n")
+		default:
+			continue
+		}
+
+		if code+text > 1 {
+			b.WriteString("\n")
+		}
+		b.WriteString(marker + "\n" + source + "\n")
+	}
+
+	note := fmt.Sprintf("notebook, %d code cells, outputs dropped", code)
+	if markdown {
+		note = fmt.Sprintf("notebook, %d code and %d markdown cells, outputs dropped", code, text)
+	}
+	return b.String(), note, nil
+}
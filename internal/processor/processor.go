@@ -2,51 +2,171 @@ package processor
 
 import (
 	"context"
+	"errors"
+	"fcopy/internal/charset"
+	"fcopy/internal/classify"
 	"fcopy/internal/config"
 	"fcopy/internal/finder"
+	"fcopy/internal/gitutil"
+	"fcopy/internal/ignore"
+	"fcopy/internal/utils"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
+	"regexp"
 	"strings"
 	"sync"
-	"sync/atomic"
 )
 
+// Rules describing why a file was included
+const (
+	RuleExplicit  = "explicit path"
+	RuleFuzzy     = "fuzzy match"
+	RuleDirectory = "directory walk"
+	RuleSelection = "editor selection"
+	RuleChanged   = "changed in git"
+	RuleFileList  = "file list"
+	RuleStdin     = "standard input"
+	RuleEntry     = "entrypoint detection"
+	RuleImport    = "imported"
+	RuleMeta      = "project metadata"
+	RuleBookmark  = "bookmark"
+	RuleSet       = "named set"
+)
+
+// Origin records which argument and rule caused a file to be included
+type Origin struct {
+	Arg        string // Command-line argument as typed by the user
+	Rule       string // Chain of rules that led from the argument to the file
+	Discovered bool   // File was found while walking rather than named directly
+	Index      int    // Position of the argument, used to keep output in argument order
+	Order      int    // Position in which the walk of the argument found the file
+}
+
+// Then returns a copy of the origin with rule appended to the chain
+func (o Origin) Then(rule string) Origin {
+	if o.Rule == "" {
+		o.Rule = rule
+	} else {
+		o.Rule = o.Rule + " > " + rule
+	}
+	return o
+}
+
+// String formats the origin for display
+func (o Origin) String() string {
+	if o.Arg == "" {
+		return o.Rule
+	}
+	return fmt.Sprintf("%s (from %q)", o.Rule, o.Arg)
+}
+
 // FileContent represents a file's name and content
 type FileContent struct {
 	Path    string
 	Content string
+	Origin  Origin
+	Notes   []string // Remarks shown next to the path, e.g. how content was altered
+	Score   float64  // Relevance to the --relevant-to query, if any
+	Patch   bool     // Content is the file's git diff rather than the file
 }
 
+// Header returns the separator line written before the file's content
+func (f FileContent) Header() string {
+	if len(f.Notes) == 0 {
+		return fmt.Sprintf("-- %s --", f.Path)
+	}
+	return fmt.Sprintf("-- %s (%s) --", f.Path, strings.Join(f.Notes, "; "))
+}
+
+// SkipError reports that a file was deliberately left out rather than failing
+type SkipError struct {
+	Reason string
+}
+
+func (e *SkipError) Error() string {
+	return e.Reason
+}
+
+// generatedHeader matches the conventional marker of generated source files
+var generatedHeader = regexp.MustCompile(`(?im)^\W*(code generated .*do not edit|@generated\b)`)
+
 // ProcessPath processes a single path which may be a file or directory
 func ProcessPath(
 	ctx context.Context,
 	path string,
+	origin Origin,
 	cfg *config.Config,
 	results chan<- FileContent,
-	processed *atomic.Int64,
-	errors *atomic.Int64,
+	tracker *Tracker,
 ) {
 	fileInfo, err := os.Stat(path)
 	if err != nil {
 		fmt.Printf("Error accessing %s: %v\n", path, err)
-		errors.Add(1)
+		tracker.Errors.Add(1)
 		return
 	}
 
 	if fileInfo.IsDir() {
 		// Process directory recursively
-		ProcessDirectory(ctx, path, cfg, results, processed, errors)
+		processDirectory(ctx, path, origin.Then(RuleDirectory), cfg, results, tracker)
 	} else {
 		// Process single file
-		if err := ProcessSingleFile(ctx, path, fileInfo, cfg, results); err != nil {
-			errors.Add(1)
-			if cfg.Verbose {
-				fmt.Printf("Error processing %s: %v\n", path, err)
-			}
-		} else {
-			processed.Add(1)
+		processFile(ctx, path, fileInfo, origin, cfg, results, tracker)
+	}
+}
+
+// processFile processes a single file unless it was already emitted and
+// updates the tracker's counters accordingly
+func processFile(
+	ctx context.Context,
+	path string,
+	fileInfo os.FileInfo,
+	origin Origin,
+	cfg *config.Config,
+	results chan<- FileContent,
+	tracker *Tracker,
+) {
+	if !tracker.Kept(origin) {
+		tracker.Omitted.Add(1)
+		return
+	}
+	emitted := false
+	defer func() { tracker.resolve(origin, emitted) }()
+
+	if !tracker.Claim(path, fileInfo, origin) {
+		if cfg.Verbose {
+			fmt.Printf("Skipping %s: already included\n", path)
+		}
+		return
+	}
+
+	var err error
+	if origin.Discovered && !cfg.IncludeGenerated && tracker.attributes.IsGenerated(path) {
+		err = &SkipError{Reason: "marked linguist-generated in .gitattributes"}
+	} else if tracker.ListOnly {
+		err = listSingleFile(ctx, path, fileInfo, origin, cfg, results)
+	} else {
+		err = ProcessSingleFile(ctx, path, fileInfo, origin, cfg, results)
+	}
+
+	var skip *SkipError
+	if errors.As(err, &skip) {
+		tracker.Skip(path, skip.Reason)
+		if cfg.Verbose {
+			fmt.Printf("Skipping %s: %s\n", path, skip.Reason)
+		}
+	} else if err == context.Canceled && !tracker.Kept(origin) {
+		tracker.Omitted.Add(1)
+	} else if err != nil {
+		tracker.Errors.Add(1)
+		if cfg.Verbose && err != context.Canceled {
+			fmt.Printf("Error processing %s: %v\n", path, err)
 		}
+	} else {
+		tracker.Processed.Add(1)
+		emitted = true
 	}
 }
 
@@ -55,114 +175,506 @@ func ProcessSingleFile(
 	ctx context.Context,
 	path string,
 	fileInfo os.FileInfo,
+	origin Origin,
 	cfg *config.Config,
 	results chan<- FileContent,
 ) error {
-	// Skip files that are too large
-	if fileInfo.Size() > cfg.MaxFileSize {
-		return fmt.Errorf("file too large (size: %d bytes)", fileInfo.Size())
+	// Binary files may be included as a bounded hex dump instead of skipped
+	ext := strings.ToLower(filepath.Ext(path))
+	if config.BinaryExts[ext] && cfg.HexdumpBinaries {
+		result, err := hexdumpFile(path, fileInfo, origin, cfg.HexdumpLimit)
+		if err != nil {
+			return err
+		}
+		return send(ctx, results, result)
 	}
 
-	// Skip binary files by extension (simple heuristic)
-	ext := strings.ToLower(filepath.Ext(path))
+	// Skip binary files by extension (simple heuristic), unless they were
+	// asked for explicitly, which gets them a placeholder or base64 block
 	if config.BinaryExts[ext] {
-		return fmt.Errorf("skipped binary file")
+		if origin.Discovered || cfg.Binary == "skip" {
+			return &SkipError{Reason: "binary file"}
+		}
+		result, err := binaryFile(path, fileInfo, origin, cfg.Binary, cfg.Base64Limit)
+		if err != nil {
+			return err
+		}
+		return send(ctx, results, result)
+	}
+
+	// Skip files that are too large, or keep only their head and tail
+	var truncatedFrom int
+	isNotebook := ext == ".ipynb" && fileInfo.Size() <= cfg.MaxFileSize*notebookSizeFactor
+	if fileInfo.Size() > cfg.MaxFileSize && cfg.Truncate == "" && !isNotebook {
+		return fmt.Errorf("file too large (size: %d bytes)", fileInfo.Size())
 	}
 
 	select {
 	case <-ctx.Done():
 		return ctx.Err()
 	default:
-		content, err := os.ReadFile(path)
+		var content []byte
+		var err error
+		if fileInfo.Size() > cfg.MaxFileSize && !isNotebook {
+			truncation, err := ParseTruncation(cfg.Truncate)
+			if err != nil {
+				return err
+			}
+			content, truncatedFrom, err = truncateFile(path, truncation)
+		} else {
+			content, err = os.ReadFile(path)
+		}
 		if err != nil {
 			return err
 		}
 
-		select {
-		case results <- FileContent{
+		// Notebooks are mostly outputs and embedded images; keep the source
+		var notes []string
+		if isNotebook {
+			source, note, err := convertNotebook(content, cfg.NotebookMarkdown)
+			if err != nil {
+				return err
+			}
+			if int64(len(source)) > cfg.MaxFileSize {
+				return fmt.Errorf("file too large (notebook source: %d bytes)", len(source))
+			}
+			content = []byte(source)
+			notes = append(notes, note)
+		}
+
+		// Leave out generated code found while walking, judged by its header
+		if origin.Discovered && !cfg.IncludeGenerated && isGenerated(content) {
+			return &SkipError{Reason: "generated file"}
+		}
+
+		// Bundles without ".min" in their name still blow up the output
+		if origin.Discovered && isMinified(content) {
+			return &SkipError{Reason: "looks minified or bundled"}
+		}
+
+		// Pathologically long lines, like embedded base64 blobs, are only
+		// kept, cut short, in files the user asked for
+		if cfg.MaxLineLength > 0 && origin.Discovered {
+			if longest := longestLine(content); longest > cfg.MaxLineLength {
+				return &SkipError{Reason: fmt.Sprintf("line of %d bytes exceeds --max-line-length %d", longest, cfg.MaxLineLength)}
+			}
+		}
+
+		// Transcode legacy encodings such as UTF-16 from Windows tools
+		content, transcodedFrom := charset.ToUTF8(content, cfg.Encoding)
+
+		// Replace invalid UTF-8 so downstream consumers like JSON encoders
+		// don't choke, unless the file is mostly undecodable
+		text, replaced := utils.RepairUTF8(content)
+		if replaced > len(content)/10 {
+			if origin.Discovered || cfg.Binary == "skip" || truncatedFrom > 0 {
+				return &SkipError{Reason: "binary content"}
+			}
+			result, err := binaryFile(path, fileInfo, origin, cfg.Binary, cfg.Base64Limit)
+			if err != nil {
+				return err
+			}
+			return send(ctx, results, result)
+		}
+
+		if cfg.MaxLineLength > 0 {
+			var cut int
+			if text, cut = cutLongLines(text, cfg.MaxLineLength); cut == 1 {
+				notes = append(notes, fmt.Sprintf("1 line cut at %d bytes", cfg.MaxLineLength))
+			} else if cut > 1 {
+				notes = append(notes, fmt.Sprintf("%d lines cut at %d bytes", cut, cfg.MaxLineLength))
+			}
+		}
+
+		result := FileContent{
 			Path:    path,
-			Content: string(content),
-		}:
-			return nil
-		case <-ctx.Done():
-			return ctx.Err()
+			Content: text,
+			Origin:  origin,
+			Notes:   notes,
+		}
+		if transcodedFrom != "" {
+			result.Notes = append(result.Notes, "transcoded from "+transcodedFrom)
 		}
+		if truncatedFrom > 0 {
+			result.Notes = append(result.Notes,
+				fmt.Sprintf("truncated from %d lines, %d bytes", truncatedFrom, fileInfo.Size()))
+		}
+		if replaced == 1 {
+			result.Notes = append(result.Notes, "1 invalid UTF-8 sequence replaced")
+		} else if replaced > 1 {
+			result.Notes = append(result.Notes,
+				fmt.Sprintf("%d invalid UTF-8 sequences replaced", replaced))
+		}
+		return send(ctx, results, result)
 	}
 }
 
+// listSingleFile emits a file without its content, unless its extension or
+// size alone show ProcessSingleFile would leave it out
+func listSingleFile(
+	ctx context.Context,
+	path string,
+	fileInfo os.FileInfo,
+	origin Origin,
+	cfg *config.Config,
+	results chan<- FileContent,
+) error {
+	ext := strings.ToLower(filepath.Ext(path))
+	if config.BinaryExts[ext] && !cfg.HexdumpBinaries && (origin.Discovered || cfg.Binary == "skip") {
+		return &SkipError{Reason: "binary file"}
+	}
+	isNotebook := ext == ".ipynb" && fileInfo.Size() <= cfg.MaxFileSize*notebookSizeFactor
+	if fileInfo.Size() > cfg.MaxFileSize && cfg.Truncate == "" && !isNotebook && !config.BinaryExts[ext] {
+		return fmt.Errorf("file too large (size: %d bytes)", fileInfo.Size())
+	}
+	return send(ctx, results, FileContent{Path: path, Origin: origin})
+}
+
+// send delivers result unless the context is cancelled first
+func send(ctx context.Context, results chan<- FileContent, result FileContent) error {
+	select {
+	case results <- result:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// hexdumpFile renders at most limit bytes of a binary file as a hex dump
+func hexdumpFile(path string, fileInfo os.FileInfo, origin Origin, limit int64) (FileContent, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return FileContent{}, err
+	}
+	defer file.Close()
+
+	data, err := io.ReadAll(io.LimitReader(file, limit))
+	if err != nil {
+		return FileContent{}, err
+	}
+
+	note := fmt.Sprintf("hex dump, %d bytes", fileInfo.Size())
+	if int64(len(data)) < fileInfo.Size() {
+		note = fmt.Sprintf("hex dump of first %d of %d bytes", len(data), fileInfo.Size())
+	}
+	return FileContent{
+		Path:    path,
+		Content: utils.Hexdump(data),
+		Origin:  origin,
+		Notes:   []string{note},
+	}, nil
+}
+
 // ProcessDirectory processes a directory recursively
 func ProcessDirectory(
 	ctx context.Context,
 	dirPath string,
 	cfg *config.Config,
 	results chan<- FileContent,
-	processed *atomic.Int64,
-	errors *atomic.Int64,
+	tracker *Tracker,
+) {
+	origin := Origin{Arg: dirPath, Rule: RuleDirectory}
+	processDirectory(ctx, dirPath, origin, cfg, results, tracker)
+}
+
+// processDirectory walks dirPath, tagging every file found with origin
+func processDirectory(
+	ctx context.Context,
+	dirPath string,
+	origin Origin,
+	cfg *config.Config,
+	results chan<- FileContent,
+	tracker *Tracker,
 ) {
+	origin.Discovered = true
+
 	var wg sync.WaitGroup
 	files := make(chan string, 100)
 
+	// Number files in the order the walk found them, for --max-files
+	type foundFile struct {
+		path   string
+		origin Origin
+	}
+	found := make(chan foundFile)
+	go func() {
+		defer close(found)
+		file := origin
+		for path := range files {
+			found <- foundFile{path, file}
+			file.Order++
+		}
+	}()
+
 	// Start worker pool for processing files
 	for i := 0; i < cfg.Workers; i++ {
 		wg.Add(1)
 		go func(workerNum int) {
 			defer wg.Done()
-			for path := range files {
+			for file := range found {
+				path := file.path
 				fileInfo, err := os.Stat(path)
 				if err != nil {
 					if cfg.Verbose {
 						fmt.Printf("Error stating %s: %v\n", path, err)
 					}
-					errors.Add(1)
+					tracker.Errors.Add(1)
+					tracker.resolve(file.origin, false)
 					continue
 				}
 
-				if err := ProcessSingleFile(ctx, path, fileInfo, cfg, results); err != nil {
-					errors.Add(1)
-					if cfg.Verbose && err != context.Canceled {
-						fmt.Printf("Error processing %s: %v\n", path, err)
-					}
-				} else {
-					processed.Add(1)
-				}
+				processFile(ctx, path, fileInfo, file.origin, cfg, results, tracker)
 			}
 		}(i)
 	}
 
 	// Walk directory and send files to worker pool
-	fileCount := 0
-	err := filepath.WalkDir(dirPath, func(path string, d os.DirEntry, err error) error {
+	var err error
+	if cfg.GitOnly {
+		err = sendTrackedFiles(ctx, dirPath, cfg, files)
+	} else {
+		visited := make(map[fileID]bool)
+		err = walkDirectory(ctx, dirPath, cfg, visited, files, tracker)
+	}
+
+	close(files)
+
+	if err != nil && err != context.Canceled {
+		fmt.Printf("Error walking directory %s: %v\n", dirPath, err)
+		tracker.Errors.Add(1)
+	}
+
+	wg.Wait()
+}
+
+// testPatterns holds the compiled form of config.TestPatterns
+var testPatterns = compilePatterns(config.TestPatterns)
+
+// isTestPath reports whether path is a test file or directory by convention
+func isTestPath(path string, isDir bool) bool {
+	name := filepath.Base(path)
+	for _, p := range testPatterns {
+		if p.Match(name, isDir) {
+			return true
+		}
+	}
+	return false
+}
+
+// compilePatterns parses a list of gitignore-style patterns
+func compilePatterns(lines []string) []ignore.Pattern {
+	var patterns []ignore.Pattern
+	for _, line := range lines {
+		if p, ok := ignore.ParsePattern(line); ok {
+			patterns = append(patterns, p)
+		}
+	}
+	return patterns
+}
+
+// isGenerated reports whether the first lines of content carry a
+// "Code generated ... DO NOT EDIT" or "@generated" marker
+func isGenerated(content []byte) bool {
+	head := content
+	if len(head) > 2048 {
+		head = head[:2048]
+	}
+	return generatedHeader.Match(head)
+}
+
+// sendTrackedFiles sends the files tracked by git under dirPath to files.
+// Git's view of the tree replaces the static ignore lists, but symlinks and
+// --no-tests are handled the same way as during a walk.
+func sendTrackedFiles(ctx context.Context, dirPath string, cfg *config.Config, files chan<- string) error {
+	tracked, err := gitutil.TrackedFiles(dirPath)
+	if err != nil {
+		return err
+	}
+
+	for _, path := range tracked {
+		info, err := os.Lstat(path)
+		if err != nil {
+			// Tracked but deleted from the working tree
+			continue
+		}
+		if info.Mode()&os.ModeSymlink != 0 && !cfg.FollowSymlinks {
+			if cfg.Verbose {
+				fmt.Printf("Skipping symlink %s (use --follow-symlinks to include it)\n", path)
+			}
+			continue
+		}
+		if (cfg.NoTests && isTestFile(dirPath, path)) || excludeFile(path, cfg) {
+			continue
+		}
+
+		select {
+		case files <- path:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	return nil
+}
+
+// excludeFile reports whether a file found while walking is filtered out by
+// --no-tests or --type, or is one of fcopy's own files
+func excludeFile(path string, cfg *config.Config) bool {
+	if isArtifact(path) || isOutputFile(path, cfg) {
+		return true
+	}
+	if cfg.NoTests && isTestPath(path, false) {
+		return true
+	}
+	return len(cfg.Types) > 0 && !classify.Matches(path, cfg.Types)
+}
+
+// isArtifact reports whether path is, or lies inside, a file or directory
+// written by fcopy itself. Git may track them, so --git-only checks too.
+func isArtifact(path string) bool {
+	for _, part := range strings.Split(filepath.ToSlash(path), "/") {
+		if config.ArtifactNames[part] {
+			return true
+		}
+	}
+	return false
+}
+
+// isOutputFile reports whether path is a file this run writes, such as the
+// --output, --manifest or --log-file file, which may be left over from a
+// previous run
+func isOutputFile(path string, cfg *config.Config) bool {
+	for _, output := range []string{cfg.OutputPath, cfg.ManifestPath, cfg.LogPath} {
+		if output != "" && canonicalPath(path) == canonicalPath(output) {
+			return true
+		}
+	}
+	return false
+}
+
+// isTestFile reports whether path, found under root, is a test file or lies
+// inside a test directory
+func isTestFile(root, path string) bool {
+	if isTestPath(path, false) {
+		return true
+	}
+	for dir := filepath.Dir(path); dir != root && dir != filepath.Dir(dir); dir = filepath.Dir(dir) {
+		if isTestPath(dir, true) {
+			return true
+		}
+	}
+	return false
+}
+
+// walkDirectory walks root and sends every file that isn't ignored to files.
+// Symlinks are skipped unless cfg.FollowSymlinks is set, in which case
+// symlinked directories are walked as well; visited tracks the directories
+// already seen by device and inode so that symlink cycles are broken and
+// bind-mounted copies of a directory are walked once. A symlinked directory
+// whose device and inode can't be read is skipped and counted in tracker.
+func walkDirectory(
+	ctx context.Context,
+	root string,
+	cfg *config.Config,
+	visited map[fileID]bool,
+	files chan<- string,
+	tracker *Tracker,
+) error {
+	// WalkDir doesn't descend into a root that is itself a symlink, so walk
+	// the resolved directory and report paths relative to the link instead
+	walkRoot := root
+	if info, err := os.Lstat(root); err == nil && info.Mode()&os.ModeSymlink != 0 {
+		if walkRoot, err = filepath.EvalSymlinks(root); err != nil {
+			return err
+		}
+	}
+
+	return filepath.WalkDir(walkRoot, func(path string, d os.DirEntry, err error) error {
 		if err != nil {
 			return err
 		}
+		isRoot := path == walkRoot
+		if walkRoot != root {
+			rel, err := filepath.Rel(walkRoot, path)
+			if err != nil {
+				return err
+			}
+			path = filepath.Join(root, rel)
+		}
 
 		// Skip ignored directories
 		if d.IsDir() && finder.ShouldIgnore(path, true, cfg) {
 			return filepath.SkipDir
 		}
 
-		if !d.IsDir() {
-			// Skip ignored files
-			if finder.ShouldIgnore(path, false, cfg) {
+		if cfg.NoTests && !isRoot && d.IsDir() && isTestPath(path, true) {
+			return filepath.SkipDir
+		}
+
+		if d.IsDir() {
+			// Remember directories so that symlinks and bind mounts pointing
+			// at them aren't walked twice
+			if info, err := d.Info(); err == nil {
+				if id, ok := getFileID(path, info); ok {
+					if visited[id] && !isRoot {
+						if cfg.Verbose {
+							fmt.Printf("Skipping %s: directory already visited\n", path)
+						}
+						return filepath.SkipDir
+					}
+					visited[id] = true
+				}
+			}
+			return nil
+		}
+
+		if d.Type()&os.ModeSymlink != 0 {
+			if !cfg.FollowSymlinks {
+				if cfg.Verbose {
+					fmt.Printf("Skipping symlink %s (use --follow-symlinks to include it)\n", path)
+				}
 				return nil
 			}
 
-			fileCount++
-			select {
-			case files <- path:
-			case <-ctx.Done():
-				return ctx.Err()
+			target, err := os.Stat(path)
+			if err != nil {
+				if cfg.Verbose {
+					fmt.Printf("Skipping broken symlink %s: %v\n", path, err)
+				}
+				return nil
 			}
-		}
-		return nil
-	})
 
-	close(files)
+			if target.IsDir() {
+				if finder.ShouldIgnore(path, true, cfg) {
+					return nil
+				}
+				// Without an identity a cycle couldn't be noticed, so the link
+				// isn't followed
+				id, ok := getFileID(path, target)
+				if !ok {
+					fmt.Printf("Skipping symlink %s: can't tell which directory it points to\n", path)
+					tracker.Skip(path, "symlinked directory can't be identified")
+					return nil
+				}
+				if visited[id] {
+					if cfg.Verbose {
+						fmt.Printf("Skipping symlink %s: directory already visited\n", path)
+					}
+					return nil
+				}
+				return walkDirectory(ctx, path, cfg, visited, files, tracker)
+			}
+		}
 
-	if err != nil && err != context.Canceled {
-		fmt.Printf("Error walking directory %s: %v\n", dirPath, err)
-		errors.Add(1)
-	}
+		// Skip ignored files
+		if finder.ShouldIgnore(path, false, cfg) || excludeFile(path, cfg) {
+			return nil
+		}
 
-	wg.Wait()
+		select {
+		case files <- path:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		return nil
+	})
 }
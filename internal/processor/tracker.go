@@ -0,0 +1,258 @@
+package processor
+
+import (
+	"context"
+	"fcopy/internal/ignore"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"sync/atomic"
+)
+
+// Tracker holds the state shared by every worker during a single run
+type Tracker struct {
+	Processed atomic.Int64
+	Errors    atomic.Int64
+	Skipped   atomic.Int64
+	Omitted   atomic.Int64 // Files left out because a limit was reached
+
+	// ListOnly emits files without reading them, applying only the filters
+	// that need no more than their path and size
+	ListOnly bool
+
+	mu         sync.Mutex
+	seen       map[string]claim    // Canonical paths already emitted
+	byID       map[fileID]claim    // Path emitted for each file on disk
+	aliases    map[string][]string // Emitted path -> other links to the same file
+	superseded map[[2]int]bool     // Positions of claims taken over by an earlier argument
+	attributes ignore.Attributes
+	skips      []Skip
+	limit      *fileLimit
+}
+
+// fileLimit stops a run once max files were emitted. Files are ranked in
+// the order they were found, by argument and then in walk order, so the
+// files kept don't depend on which worker finished first.
+type fileLimit struct {
+	max    int
+	args   int
+	cancel context.CancelFunc
+
+	found   map[[2]int]bool // Position -> whether it was emitted, until ranked
+	done    map[int]bool    // Arguments fully processed
+	arg     int             // Next position to rank
+	order   int
+	count   int // Files emitted before that position
+	cut     [2]int
+	reached bool
+}
+
+// Skip is a file left out by the filters, and why
+type Skip struct {
+	Path   string `json:"path"`
+	Reason string `json:"reason"`
+}
+
+// Skip counts path as skipped for reason
+func (t *Tracker) Skip(path, reason string) {
+	t.Skipped.Add(1)
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.skips = append(t.skips, Skip{Path: path, Reason: reason})
+}
+
+// Skips returns the files skipped so far, in the order they were
+func (t *Tracker) Skips() []Skip {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return slices.Clone(t.skips)
+}
+
+// claim is the path a file is emitted under and the position, by argument
+// and walk order, where it was found
+type claim struct {
+	path     string
+	position [2]int
+}
+
+// before reports whether position a comes before b
+func before(a, b [2]int) bool {
+	return a[0] < b[0] || a[0] == b[0] && a[1] < b[1]
+}
+
+// Claim marks path, found as origin says, as emitted and reports whether it
+// should be. Paths are compared by their canonical absolute form, so the
+// same file reached through overlapping arguments is only emitted once.
+// Hard links to a claimed file, detected through the device and inode in
+// info, are recorded as aliases of it instead. Whichever was found first by
+// argument and walk order keeps the claim, however the workers race: an
+// earlier one takes over a later claim, which Superseded then reports.
+func (t *Tracker) Claim(path string, info os.FileInfo, origin Origin) bool {
+	key := canonicalPath(path)
+	c := claim{path, [2]int{origin.Index, origin.Order}}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.seen == nil {
+		t.seen = make(map[string]claim)
+		t.byID = make(map[fileID]claim)
+		t.aliases = make(map[string][]string)
+		t.superseded = make(map[[2]int]bool)
+	}
+	id, hasID := getFileID(path, info)
+	if old, ok := t.seen[key]; ok {
+		if !before(c.position, old.position) {
+			return false
+		}
+		t.supersede(old, c)
+		t.seen[key] = c
+		if hasID && t.byID[id] == old {
+			t.byID[id] = c
+		}
+		return true
+	}
+
+	if hasID {
+		if old, ok := t.byID[id]; ok {
+			if !before(c.position, old.position) {
+				t.seen[key] = old
+				t.aliases[old.path] = append(t.aliases[old.path], path)
+				return false
+			}
+			t.supersede(old, c)
+			t.aliases[path] = append(t.aliases[path], old.path)
+			for k, other := range t.seen {
+				if other == old {
+					t.seen[k] = c
+				}
+			}
+		}
+		t.byID[id] = c
+	}
+	t.seen[key] = c
+	return true
+}
+
+// supersede hands the claim old over to c, along with its aliases
+func (t *Tracker) supersede(old, c claim) {
+	t.superseded[old.position] = true
+	if aliases, ok := t.aliases[old.path]; ok && old.path != c.path {
+		t.aliases[c.path] = append(t.aliases[c.path], aliases...)
+		delete(t.aliases, old.path)
+	}
+}
+
+// Superseded reports whether the file found as origin says was claimed, but
+// the claim was taken over by an earlier argument, so it must not be emitted
+func (t *Tracker) Superseded(origin Origin) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.superseded[[2]int{origin.Index, origin.Order}]
+}
+
+// Aliases returns the other paths under which the file emitted as path was
+// found, such as hard links to it
+func (t *Tracker) Aliases(path string) []string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.aliases[path]
+}
+
+// LimitFiles makes the run over args arguments stop at max emitted files, by
+// calling cancel once the first max files, in the order they were found, are
+// known. A max of 0 means unlimited.
+func (t *Tracker) LimitFiles(max, args int, cancel context.CancelFunc) {
+	if max <= 0 {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.limit = &fileLimit{
+		max:    max,
+		args:   args,
+		cancel: cancel,
+		found:  make(map[[2]int]bool),
+		done:   make(map[int]bool),
+	}
+}
+
+// Kept reports whether a file from origin is within the file limit, which
+// is always the case until the limit is reached
+func (t *Tracker) Kept(origin Origin) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	l := t.limit
+	if l == nil || !l.reached {
+		return true
+	}
+	return origin.Index < l.cut[0] || origin.Index == l.cut[0] && origin.Order <= l.cut[1]
+}
+
+// LimitReached reports whether the run was stopped by the file limit
+func (t *Tracker) LimitReached() bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.limit != nil && t.limit.reached
+}
+
+// resolve records whether the file found at origin was emitted
+func (t *Tracker) resolve(origin Origin, emitted bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if l := t.limit; l != nil && !l.reached {
+		l.found[[2]int{origin.Index, origin.Order}] = emitted
+		l.rank()
+	}
+}
+
+// FinishArg records that every file of the argument at index was processed
+func (t *Tracker) FinishArg(index int) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if l := t.limit; l != nil && !l.reached {
+		l.done[index] = true
+		l.rank()
+	}
+}
+
+// rank counts the emitted files found without a gap from the start, and
+// cuts off and cancels the run at the last one allowed
+func (l *fileLimit) rank() {
+	for l.arg < l.args {
+		for {
+			position := [2]int{l.arg, l.order}
+			emitted, ok := l.found[position]
+			if !ok {
+				break
+			}
+			delete(l.found, position)
+			l.order++
+			if emitted {
+				l.count++
+				if l.count == l.max {
+					l.cut, l.reached = position, true
+					l.cancel()
+					return
+				}
+			}
+		}
+		if !l.done[l.arg] {
+			return
+		}
+		l.arg, l.order = l.arg+1, 0
+	}
+}
+
+// canonicalPath returns the absolute, symlink-free form of path, falling
+// back to a cleaned path when it can't be resolved
+func canonicalPath(path string) string {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return filepath.Clean(path)
+	}
+	if resolved, err := filepath.EvalSymlinks(abs); err == nil {
+		return resolved
+	}
+	return abs
+}
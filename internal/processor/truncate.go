@@ -0,0 +1,102 @@
+package processor
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// Truncation selects how many leading and trailing lines of an oversized
+// file to keep
+type Truncation struct {
+	Head int
+	Tail int
+}
+
+// ParseTruncation parses a spec such as "head:200,tail:50". Either part may
+// be left out.
+func ParseTruncation(s string) (Truncation, error) {
+	var t Truncation
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		key, value, ok := strings.Cut(part, ":")
+		n, err := strconv.Atoi(value)
+		if !ok || err != nil || n < 0 {
+			return Truncation{}, fmt.Errorf("invalid truncation %q (expected head:N or tail:N)", part)
+		}
+		switch key {
+		case "head":
+			t.Head = n
+		case "tail":
+			t.Tail = n
+		default:
+			return Truncation{}, fmt.Errorf("invalid truncation %q (expected head:N or tail:N)", part)
+		}
+	}
+	if t.Head == 0 && t.Tail == 0 {
+		return Truncation{}, fmt.Errorf("invalid truncation %q: keeps no lines", s)
+	}
+	return t, nil
+}
+
+// truncateFile reads the first t.Head and last t.Tail lines of path, with an
+// elision marker for the lines in between. The file is streamed so only the
+// kept lines are held in memory. It also returns the total number of lines.
+func truncateFile(path string, t Truncation) ([]byte, int, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer file.Close()
+
+	var head []string
+	tail := make([]string, 0, t.Tail) // Ring buffer of the latest lines
+	total := 0
+	reader := bufio.NewReader(file)
+	for {
+		line, err := reader.ReadString('\n')
+		if line != "" {
+			total++
+			line = strings.TrimSuffix(line, "\n")
+			switch {
+			case len(head) < t.Head:
+				head = append(head, line)
+			case t.Tail == 0:
+			case len(tail) < t.Tail:
+				tail = append(tail, line)
+			default:
+				tail[(total-len(head)-1)%t.Tail] = line
+			}
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, 0, err
+		}
+	}
+
+	// Unroll the ring buffer so the tail reads in file order
+	if len(tail) == t.Tail && t.Tail > 0 {
+		start := (total - len(head)) % t.Tail
+		tail = append(tail[start:], tail[:start]...)
+	}
+
+	var b strings.Builder
+	for _, line := range head {
+		b.WriteString(line + "\n")
+	}
+	if omitted := total - len(head) - len(tail); omitted > 0 {
+		b.WriteString(elision(len(head)+1, len(head)+omitted))
+	}
+	for _, line := range tail {
+		b.WriteString(line + "\n")
+	}
+	return []byte(b.String()), total, nil
+}
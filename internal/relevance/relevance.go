@@ -0,0 +1,149 @@
+// Package relevance ranks collected files by how well they match a free-text
+// query, so fcopy can gather the context for a question
+package relevance
+
+import (
+	"fcopy/internal/processor"
+	"math"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// BM25 parameters: k1 limits how much repeating a term helps, b how much
+// long files are penalized
+const (
+	k1 = 1.2
+	b  = 0.75
+)
+
+// pathWeight counts a term in the file's path this many times over a term in
+// its content, since names are a strong hint of what a file is about
+const pathWeight = 3
+
+// stopWords are dropped from queries; they match everything and mean nothing
+var stopWords = map[string]bool{
+	"a": true, "an": true, "and": true, "are": true, "as": true, "at": true,
+	"be": true, "by": true, "do": true, "doe": true, "for": true, "from": true,
+	"how": true, "in": true, "is": true, "it": true, "of": true, "on": true,
+	"or": true, "that": true, "the": true, "this": true, "to": true,
+	"what": true, "when": true, "where": true, "which": true, "with": true,
+	"why": true,
+}
+
+// Rank scores files against query with BM25 over their paths and contents
+// and returns them best first, setting each file's Score. Files found while
+// walking that match no query term are dropped; files named explicitly are
+// always kept.
+func Rank(files []processor.FileContent, query string) []processor.FileContent {
+	var queryTerms []string
+	seen := make(map[string]bool)
+	for _, term := range Terms(query) {
+		if !stopWords[term] && !seen[term] {
+			seen[term] = true
+			queryTerms = append(queryTerms, term)
+		}
+	}
+	if len(queryTerms) == 0 || len(files) == 0 {
+		return files
+	}
+
+	// Term frequencies of the query terms per file, and document frequencies
+	freqs := make([]map[string]int, len(files))
+	lengths := make([]int, len(files))
+	df := make(map[string]int)
+	total := 0
+	for i, f := range files {
+		freqs[i] = make(map[string]int)
+		for _, term := range Terms(f.Path) {
+			if seen[term] {
+				freqs[i][term] += pathWeight
+			}
+		}
+		terms := Terms(f.Content)
+		for _, term := range terms {
+			if seen[term] {
+				freqs[i][term]++
+			}
+		}
+		lengths[i] = len(terms)
+		total += len(terms)
+		for term := range freqs[i] {
+			df[term]++
+		}
+	}
+	avgLength := math.Max(float64(total)/float64(len(files)), 1)
+
+	n := float64(len(files))
+	for i := range files {
+		score := 0.0
+		for _, term := range queryTerms {
+			tf := float64(freqs[i][term])
+			if tf == 0 {
+				continue
+			}
+			idf := math.Log(1 + (n-float64(df[term])+0.5)/(float64(df[term])+0.5))
+			norm := k1 * (1 - b + b*float64(lengths[i])/avgLength)
+			score += idf * tf * (k1 + 1) / (tf + norm)
+		}
+		files[i].Score = score
+	}
+
+	var ranked []processor.FileContent
+	for _, f := range files {
+		if f.Score > 0 || !f.Origin.Discovered {
+			ranked = append(ranked, f)
+		}
+	}
+	sort.SliceStable(ranked, func(i, j int) bool { return ranked[i].Score > ranked[j].Score })
+	return ranked
+}
+
+// Terms splits text into lowercase, lightly stemmed words. Identifiers are
+// broken at camelCase and snake_case boundaries, so "RateLimiter" and
+// "rate_limit" both yield "rate" and "limit".
+func Terms(text string) []string {
+	var terms []string
+	var word []rune
+	flush := func() {
+		if len(word) > 1 {
+			terms = append(terms, stem(strings.ToLower(string(word))))
+		}
+		word = word[:0]
+	}
+
+	runes := []rune(text)
+	for i, r := range runes {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			flush()
+			continue
+		}
+		if len(word) > 0 {
+			prev := word[len(word)-1]
+			lowerToUpper := unicode.IsLower(prev) && unicode.IsUpper(r)
+			// The last capital of an acronym starts the next word: HTTPServer
+			acronymEnd := unicode.IsUpper(prev) && unicode.IsUpper(r) &&
+				i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			if lowerToUpper || acronymEnd || unicode.IsDigit(prev) != unicode.IsDigit(r) {
+				flush()
+			}
+		}
+		word = append(word, r)
+	}
+	flush()
+	return terms
+}
+
+// stem strips a few common English suffixes so "limits", "limiting" and
+// "limiter" match each other
+func stem(word string) string {
+	for _, suffix := range []string{"ing", "ers", "er", "ed", "s"} {
+		if strings.HasSuffix(word, suffix) && len(word)-len(suffix) >= 3 {
+			if suffix == "s" && strings.HasSuffix(word, "ss") {
+				continue
+			}
+			return strings.TrimSuffix(word, suffix)
+		}
+	}
+	return word
+}
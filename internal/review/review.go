@@ -0,0 +1,82 @@
+// Package review lets the user look over the final file list and leave
+// files out before anything is copied
+package review
+
+import (
+	"bufio"
+	"fcopy/internal/processor"
+	"fcopy/internal/tokens"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Run shows files with their sizes and token estimates and reads toggles
+// from in until the user confirms. It returns the files still selected and
+// false if the user aborted.
+func Run(files []processor.FileContent, in io.Reader, out io.Writer) ([]processor.FileContent, bool) {
+	selected := make([]bool, len(files))
+	for i := range selected {
+		selected[i] = true
+	}
+
+	reader := bufio.NewReader(in)
+	for {
+		list(files, selected, out)
+		fmt.Fprint(out, "Toggle files by number (e.g. 2 5-7), a for all, n for none, Enter to copy, q to abort: ")
+		input, err := reader.ReadString('\n')
+		if err != nil && input == "" {
+			fmt.Fprintln(out)
+			return nil, false
+		}
+
+		switch input = strings.TrimSpace(input); input {
+		case "":
+			var kept []processor.FileContent
+			for i, f := range files {
+				if selected[i] {
+					kept = append(kept, f)
+				}
+			}
+			return kept, true
+		case "q":
+			return nil, false
+		case "a", "n":
+			for i := range selected {
+				selected[i] = input == "a"
+			}
+		default:
+			ranges, err := processor.ParseLineRanges(strings.Join(strings.Fields(input), ","))
+			if err != nil {
+				fmt.Fprintln(out, "Invalid selection. Please try again.")
+				continue
+			}
+			for _, r := range ranges {
+				end := r.End
+				if end == 0 || end > len(files) {
+					end = len(files)
+				}
+				for n := r.Start; n <= end; n++ {
+					selected[n-1] = !selected[n-1]
+				}
+			}
+		}
+	}
+}
+
+// list prints the numbered file list with a running total of the selection
+func list(files []processor.FileContent, selected []bool, out io.Writer) {
+	var count, bytes, estimate int
+	for i, f := range files {
+		mark := " "
+		n := tokens.Count(f.Content)
+		if selected[i] {
+			mark = "x"
+			count++
+			bytes += len(f.Content)
+			estimate += n
+		}
+		fmt.Fprintf(out, "[%s] %d. %s (%d bytes, %s)\n", mark, i+1, f.Path, len(f.Content), tokens.Format(n))
+	}
+	fmt.Fprintf(out, "Selected %d of %d files (%d bytes, %s)\n", count, len(files), bytes, tokens.Format(estimate))
+}
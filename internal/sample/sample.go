@@ -0,0 +1,79 @@
+// Package sample draws a reproducible random subset of collected files, for
+// building datasets out of a source tree
+package sample
+
+import (
+	"fcopy/internal/classify"
+	"fcopy/internal/processor"
+	"math"
+	"math/rand/v2"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Options controls how many files Sample draws
+type Options struct {
+	Count       int    // Total number of files to draw, 0 for no limit
+	PerDir      int    // Maximum files drawn from one directory, 0 for no limit
+	PerLanguage int    // Maximum files drawn per language, 0 for no limit
+	Seed        uint64 // Seed making the draw reproducible
+}
+
+// Sample draws up to opts.Count files without replacement, respecting the
+// per-directory and per-language quotas. Files are weighted by the log of
+// their line count, so substantial files are favoured over stubs without
+// letting a few huge ones dominate. The same files and seed always give the
+// same sample, returned in the original order.
+func Sample(files []processor.FileContent, opts Options) []processor.FileContent {
+	// Weighted sampling without replacement (Efraimidis-Spirakis): draw a
+	// key u^(1/w) per file and take the files with the largest keys. Keys
+	// are drawn in path order so worker scheduling can't change the result.
+	order := make([]int, len(files))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool { return files[order[a]].Path < files[order[b]].Path })
+
+	rng := rand.New(rand.NewPCG(opts.Seed, 0))
+	keys := make([]float64, len(files))
+	for _, i := range order {
+		keys[i] = math.Pow(rng.Float64(), 1/weight(files[i]))
+	}
+	sort.SliceStable(order, func(a, b int) bool { return keys[order[a]] > keys[order[b]] })
+
+	picked := make([]bool, len(files))
+	perDir := make(map[string]int)
+	perLanguage := make(map[string]int)
+	count := 0
+	for _, i := range order {
+		if opts.Count > 0 && count >= opts.Count {
+			break
+		}
+		dir := filepath.Dir(files[i].Path)
+		language := classify.Language(files[i].Path)
+		if opts.PerDir > 0 && perDir[dir] >= opts.PerDir {
+			continue
+		}
+		if opts.PerLanguage > 0 && perLanguage[language] >= opts.PerLanguage {
+			continue
+		}
+		perDir[dir]++
+		perLanguage[language]++
+		picked[i] = true
+		count++
+	}
+
+	var sampled []processor.FileContent
+	for i, f := range files {
+		if picked[i] {
+			sampled = append(sampled, f)
+		}
+	}
+	return sampled
+}
+
+// weight returns the sampling weight of f
+func weight(f processor.FileContent) float64 {
+	return 1 + math.Log2(1+float64(strings.Count(f.Content, "\n")))
+}
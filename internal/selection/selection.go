@@ -0,0 +1,115 @@
+// Package selection implements the editor selection protocol.
+//
+// Editors describe the files (and optionally line ranges) to copy either in
+// the FCOPY_SELECTION environment variable or by writing them to an inherited
+// file descriptor whose number is given in FCOPY_SELECTION_FD. Both carry one
+// entry per line:
+//
+//	path/to/file.go
+//	path/to/other.go:10-20,35
+//
+// Ranges are 1-based and inclusive; "40-" selects from line 40 to the end.
+// A file whose own name ends in what looks like ranges, such as "v1:2", is
+// taken whole when it exists. Blank lines and lines starting with # are
+// ignored.
+package selection
+
+import (
+	"bufio"
+	"fcopy/internal/processor"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// Environment variables of the protocol
+const (
+	EnvSelection = "FCOPY_SELECTION"
+	EnvFD        = "FCOPY_SELECTION_FD"
+)
+
+// Entry is a single selected path with optional line ranges
+type Entry struct {
+	Path   string
+	Ranges []processor.LineRange
+}
+
+// FromEnv reads the selection from FCOPY_SELECTION and FCOPY_SELECTION_FD
+func FromEnv() ([]Entry, error) {
+	var entries []Entry
+
+	if value, ok := os.LookupEnv(EnvSelection); ok {
+		parsed, err := Parse(strings.NewReader(value))
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", EnvSelection, err)
+		}
+		entries = append(entries, parsed...)
+	}
+
+	if value, ok := os.LookupEnv(EnvFD); ok {
+		fd, err := strconv.Atoi(value)
+		if err != nil || fd < 0 {
+			return nil, fmt.Errorf("%s: invalid file descriptor %q", EnvFD, value)
+		}
+		file := os.NewFile(uintptr(fd), "selection")
+		if file == nil {
+			return nil, fmt.Errorf("%s: invalid file descriptor %d", EnvFD, fd)
+		}
+		defer file.Close()
+
+		parsed, err := Parse(file)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", EnvFD, err)
+		}
+		entries = append(entries, parsed...)
+	}
+
+	if len(entries) == 0 {
+		return nil, fmt.Errorf("no selection found in %s or %s", EnvSelection, EnvFD)
+	}
+	return entries, nil
+}
+
+// Parse reads selection entries, one per line
+func Parse(r io.Reader) ([]Entry, error) {
+	var entries []Entry
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		entry, err := parseEntry(line)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, entry)
+	}
+	return entries, scanner.Err()
+}
+
+// parseEntry splits "path:ranges" into its parts. The suffix is only treated
+// as ranges when it parses as such and the whole line isn't an existing file,
+// so Windows drive letters and colons in file names are left alone.
+func parseEntry(line string) (Entry, error) {
+	i := strings.LastIndex(line, ":")
+	if i <= 0 || i == len(line)-1 {
+		return Entry{Path: line}, nil
+	}
+
+	suffix := line[i+1:]
+	if strings.Trim(suffix, "0123456789-,") != "" {
+		return Entry{Path: line}, nil
+	}
+	if _, err := os.Lstat(line); err == nil {
+		return Entry{Path: line}, nil
+	}
+
+	ranges, err := processor.ParseLineRanges(suffix)
+	if err != nil {
+		return Entry{}, err
+	}
+	return Entry{Path: line[:i], Ranges: ranges}, nil
+}
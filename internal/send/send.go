@@ -0,0 +1,85 @@
+// Package send posts a prompt to an OpenAI-compatible chat completions
+// endpoint, such as OpenAI itself, ollama or a llama.cpp server
+package send
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// Options selects the endpoint and model to send to
+type Options struct {
+	BaseURL string // API root, e.g. https://api.openai.com/v1 or http://localhost:11434/v1
+	Model   string
+	APIKey  string // Sent as a bearer token when set; local servers often need none
+}
+
+// message is a chat message in the request and response
+type message struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+// response is the part of a chat completion response fcopy reads
+type response struct {
+	Choices []struct {
+		Message message `json:"message"`
+	} `json:"choices"`
+	Error *struct {
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+// Chat sends prompt as a single user message and returns the reply
+func Chat(ctx context.Context, opts Options, prompt string) (string, error) {
+	body, err := json.Marshal(map[string]any{
+		"model":    opts.Model,
+		"messages": []message{{Role: "user", Content: prompt}},
+	})
+	if err != nil {
+		return "", err
+	}
+
+	url := strings.TrimSuffix(opts.BaseURL, "/") + "/chat/completions"
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if opts.APIKey != "" {
+		req.Header.Set("Authorization", "Bearer "+opts.APIKey)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+
+	var parsed response
+	if err := json.Unmarshal(data, &parsed); err != nil {
+		if resp.StatusCode != http.StatusOK {
+			return "", fmt.Errorf("%s: %s", resp.Status, strings.TrimSpace(string(data)))
+		}
+		return "", fmt.Errorf("invalid response: %v", err)
+	}
+	if parsed.Error != nil {
+		return "", fmt.Errorf("%s: %s", resp.Status, parsed.Error.Message)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("%s", resp.Status)
+	}
+	if len(parsed.Choices) == 0 {
+		return "", fmt.Errorf("response has no choices")
+	}
+	return parsed.Choices[0].Message.Content, nil
+}
@@ -0,0 +1,89 @@
+// Package split breaks a large output into numbered parts that can be pasted
+// into a chat one message at a time
+package split
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Split breaks text at line boundaries into pieces costing at most max each,
+// as measured by cost. A single line over max becomes a piece of its own.
+func Split(text string, max int, cost func(string) int) []string {
+	if max <= 0 || cost(text) <= max {
+		return []string{text}
+	}
+
+	var parts []string
+	var current strings.Builder
+	total := 0
+	for _, line := range strings.SplitAfter(text, "\n") {
+		n := cost(line)
+		if current.Len() > 0 && total+n > max {
+			parts = append(parts, current.String())
+			current.Reset()
+			total = 0
+		}
+		current.WriteString(line)
+		total += n
+	}
+	if current.Len() > 0 {
+		parts = append(parts, current.String())
+	}
+	return parts
+}
+
+// Overlap repeats the last n lines of each part at the start of the next
+// when a file straddles the two, so the reader of the later part sees where
+// it continues from. isFileStart recognizes the first line of a file; a part
+// starting with one needs no overlap, and the repeated lines never reach back
+// into an earlier file.
+func Overlap(parts []string, n int, isFileStart func(string) bool) []string {
+	if n <= 0 || len(parts) < 2 {
+		return parts
+	}
+
+	overlapped := append([]string(nil), parts...)
+	for i := 1; i < len(parts); i++ {
+		first, _, _ := strings.Cut(parts[i], "\n")
+		if isFileStart(first) {
+			continue
+		}
+
+		lines := strings.SplitAfter(strings.TrimSuffix(parts[i-1], "\n"), "\n")
+		start := max(len(lines)-n, 0)
+		for j := len(lines) - 1; j >= start; j-- {
+			if isFileStart(strings.TrimSuffix(lines[j], "\n")) {
+				start = j + 1
+				break
+			}
+		}
+		if start == len(lines) {
+			continue
+		}
+		repeated := strings.Join(lines[start:], "")
+		if !strings.HasSuffix(repeated, "\n") {
+			repeated += "\n"
+		}
+		overlapped[i] = fmt.Sprintf("[Repeated from the end of part %d:]\n%s[End of repeated lines]\n%s", i, repeated, parts[i])
+	}
+	return overlapped
+}
+
+// Label frames each part with a "Part i/n" header, and tells the reader of
+// every part but the last that more is coming
+func Label(parts []string) []string {
+	if len(parts) < 2 {
+		return parts
+	}
+
+	labeled := make([]string, len(parts))
+	for i, part := range parts {
+		footer := fmt.Sprintf("[End of part %d/%d. More parts follow; reply only \"OK\" until the last one.]", i+1, len(parts))
+		if i == len(parts)-1 {
+			footer = fmt.Sprintf("[End of part %d/%d. This was the last part.]", i+1, len(parts))
+		}
+		labeled[i] = fmt.Sprintf("Part %d/%d\n\n%s\n%s\n", i+1, len(parts), strings.TrimRight(part, "\n"), footer)
+	}
+	return labeled
+}
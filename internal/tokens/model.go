@@ -0,0 +1,117 @@
+package tokens
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/pkoukk/tiktoken-go"
+	tiktoken_loader "github.com/pkoukk/tiktoken-go-loader"
+)
+
+// Models maps --model presets to the tiktoken encoding that counts their
+// tokens. Claude and Llama tokenizers aren't published as tiktoken
+// encodings; cl100k_base, which Llama 3's vocabulary extends, is a close
+// approximation for both.
+var Models = map[string]string{
+	"gpt-4o":  "o200k_base",
+	"gpt-4.1": "o200k_base",
+	"o1":      "o200k_base",
+	"gpt-4":   "cl100k_base",
+	"gpt-3.5": "cl100k_base",
+	"claude":  "cl100k_base",
+	"llama":   "cl100k_base",
+}
+
+// ContextWindows holds the context window, in tokens, of each --model
+// preset, used by --fit
+var ContextWindows = map[string]int{
+	"gpt-4o":  128000,
+	"gpt-4.1": 1047576,
+	"o1":      200000,
+	"gpt-4":   128000,
+	"gpt-3.5": 16385,
+	"claude":  200000,
+	"llama":   128000,
+}
+
+var (
+	mu       sync.Mutex
+	model    string
+	encoding *tiktoken.Tiktoken
+)
+
+// SetModel makes Count use the tokenizer of the named --model preset. An
+// empty name goes back to the Estimate heuristic.
+func SetModel(name string) error {
+	if name == "" {
+		mu.Lock()
+		model, encoding = "", nil
+		mu.Unlock()
+		return nil
+	}
+
+	encodingName, ok := Models[strings.ToLower(name)]
+	if !ok {
+		return fmt.Errorf("unknown model %q (expected one of: %s)", name, strings.Join(ModelNames(), ", "))
+	}
+	// Load the encodings bundled into the binary rather than downloading them
+	tiktoken.SetBpeLoader(tiktoken_loader.NewOfflineLoader())
+	enc, err := tiktoken.GetEncoding(encodingName)
+	if err != nil {
+		return fmt.Errorf("loading %s tokenizer: %v", encodingName, err)
+	}
+
+	mu.Lock()
+	model, encoding = strings.ToLower(name), enc
+	mu.Unlock()
+	return nil
+}
+
+// Model returns the preset selected with SetModel, or "" when tokens are
+// estimated
+func Model() string {
+	mu.Lock()
+	defer mu.Unlock()
+	return model
+}
+
+// Count returns the number of tokens in text using the tokenizer selected
+// with SetModel, falling back to Estimate
+func Count(text string) int {
+	mu.Lock()
+	enc := encoding
+	mu.Unlock()
+	if enc == nil {
+		return Estimate(text)
+	}
+	return len(enc.EncodeOrdinary(text))
+}
+
+// approximate lists the presets whose tokenizer is only approximated
+var approximate = map[string]bool{"claude": true, "llama": true}
+
+// Format describes n tokens counted by Count, marking counts that are only
+// estimates and naming the model's tokenizer when one is used
+func Format(n int) string {
+	current := Model()
+	switch {
+	case current == "":
+		return fmt.Sprintf("~%d tokens", n)
+	case approximate[current]:
+		return fmt.Sprintf("~%d %s tokens", n, current)
+	default:
+		return fmt.Sprintf("%d %s tokens", n, current)
+	}
+}
+
+// ModelNames returns the --model presets in alphabetical order
+func ModelNames() []string {
+	names := make([]string, 0, len(Models))
+	for name := range Models {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,48 @@
+package tokens
+
+import (
+	"fmt"
+	"unicode"
+)
+
+// Estimate approximates the number of tokens a BPE tokenizer produces for
+// text: runs of letters and digits cost about one token per four characters,
+// punctuation one token each, and CJK characters one token each
+func Estimate(text string) int {
+	tokens, run := 0, 0
+	flush := func() {
+		tokens += (run + 3) / 4
+		run = 0
+	}
+
+	for _, r := range text {
+		switch {
+		case r >= 0x2E80 && unicode.IsLetter(r):
+			flush()
+			tokens++
+		case unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_':
+			run++
+		case unicode.IsSpace(r):
+			flush()
+		default:
+			flush()
+			tokens++
+		}
+	}
+	flush()
+	return tokens
+}
+
+// Cost returns the price in dollars of sending n input tokens at
+// pricePerMillion dollars per million tokens
+func Cost(n int, pricePerMillion float64) float64 {
+	return float64(n) * pricePerMillion / 1e6
+}
+
+// FormatCost formats a dollar amount, keeping small amounts readable
+func FormatCost(dollars float64) string {
+	if dollars < 0.01 {
+		return fmt.Sprintf("$%.4f", dollars)
+	}
+	return fmt.Sprintf("$%.2f", dollars)
+}
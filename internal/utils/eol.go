@@ -0,0 +1,41 @@
+package utils
+
+import "strings"
+
+// NormalizeEOL strips a leading byte order mark and converts CRLF and lone
+// CR line endings to LF
+func NormalizeEOL(s string) string {
+	s = strings.TrimPrefix(s, "\uFEFF")
+	if !strings.Contains(s, "\r") {
+		return s
+	}
+	s = strings.ReplaceAll(s, "\r\n", "\n")
+	return strings.ReplaceAll(s, "\r", "\n")
+}
+
+// ExpandTabs replaces tabs with spaces up to the next multiple of width
+// columns
+func ExpandTabs(s string, width int) string {
+	if width <= 0 || !strings.Contains(s, "\t") {
+		return s
+	}
+
+	var b strings.Builder
+	b.Grow(len(s))
+	column := 0
+	for _, r := range s {
+		switch r {
+		case '\t':
+			spaces := width - column%width
+			b.WriteString(strings.Repeat(" ", spaces))
+			column += spaces
+		case '\n':
+			b.WriteRune(r)
+			column = 0
+		default:
+			b.WriteRune(r)
+			column++
+		}
+	}
+	return b.String()
+}
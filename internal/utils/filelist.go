@@ -0,0 +1,55 @@
+package utils
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"os"
+	"strings"
+)
+
+// ReadFileList reads paths from the file at path, or from stdin when path is
+// "-". Entries are separated by newlines, or by NUL bytes when nul is set, as
+// produced by `find -print0`, `fd -0` or `rg -l -0`. Blank entries are
+// skipped, and so are lines starting with # in a newline-separated list,
+// which is often written by hand; NUL-separated entries are taken as-is.
+func ReadFileList(path string, nul bool) ([]string, error) {
+	var r io.Reader = os.Stdin
+	if path != "-" {
+		file, err := os.Open(path)
+		if err != nil {
+			return nil, err
+		}
+		defer file.Close()
+		r = file
+	}
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	if nul {
+		scanner.Split(func(data []byte, atEOF bool) (int, []byte, error) {
+			if i := bytes.IndexByte(data, 0); i >= 0 {
+				return i + 1, data[:i], nil
+			}
+			if atEOF && len(data) > 0 {
+				return len(data), data, nil
+			}
+			return 0, nil, nil
+		})
+	}
+
+	var paths []string
+	for scanner.Scan() {
+		entry := scanner.Text()
+		if !nul {
+			entry = strings.TrimRight(entry, "\r")
+			if strings.HasPrefix(strings.TrimSpace(entry), "#") {
+				continue
+			}
+		}
+		if strings.TrimSpace(entry) != "" {
+			paths = append(paths, entry)
+		}
+	}
+	return paths, scanner.Err()
+}
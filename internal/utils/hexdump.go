@@ -0,0 +1,42 @@
+package utils
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Hexdump formats data like `xxd`: an offset, 16 bytes as grouped hex pairs
+// and their printable ASCII representation on each line
+func Hexdump(data []byte) string {
+	var b strings.Builder
+	for offset := 0; offset < len(data); offset += 16 {
+		end := offset + 16
+		if end > len(data) {
+			end = len(data)
+		}
+		row := data[offset:end]
+
+		fmt.Fprintf(&b, "%08x: ", offset)
+		for i := 0; i < 16; i++ {
+			if i < len(row) {
+				fmt.Fprintf(&b, "%02x", row[i])
+			} else {
+				b.WriteString("  ")
+			}
+			if i%2 == 1 {
+				b.WriteByte(' ')
+			}
+		}
+
+		b.WriteByte(' ')
+		for _, c := range row {
+			if c >= 0x20 && c < 0x7f {
+				b.WriteByte(c)
+			} else {
+				b.WriteByte('.')
+			}
+		}
+		b.WriteByte('\n')
+	}
+	return b.String()
+}
@@ -0,0 +1,63 @@
+package utils
+
+import (
+	"os"
+	"os/user"
+	"path/filepath"
+	"strings"
+)
+
+// ExpandPath canonicalizes a path argument: surrounding quotes are removed,
+// a leading ~ or ~user is expanded to the home directory, environment
+// variables are substituted, and relative paths are resolved against cwd
+// when it is set
+func ExpandPath(path, cwd string) string {
+	path = strings.Trim(path, "\"'")
+	if path == "" {
+		return path
+	}
+
+	path = expandHome(path)
+
+	// Substitute $VAR and ${VAR}, leaving unknown variables untouched
+	path = os.Expand(path, func(name string) string {
+		if value, ok := os.LookupEnv(name); ok {
+			return value
+		}
+		return "$" + name
+	})
+	path = expandHome(path)
+
+	if cwd != "" && !filepath.IsAbs(path) {
+		path = filepath.Join(ExpandPath(cwd, ""), path)
+	}
+	return filepath.Clean(path)
+}
+
+// expandHome replaces a leading ~ or ~user with the matching home directory
+func expandHome(path string) string {
+	if !strings.HasPrefix(path, "~") {
+		return path
+	}
+
+	name, rest := path[1:], ""
+	if i := strings.IndexAny(name, `/\`); i >= 0 {
+		name, rest = name[:i], name[i:]
+	}
+
+	var home string
+	if name == "" {
+		dir, err := os.UserHomeDir()
+		if err != nil {
+			return path
+		}
+		home = dir
+	} else {
+		u, err := user.Lookup(name)
+		if err != nil {
+			return path
+		}
+		home = u.HomeDir
+	}
+	return home + rest
+}
@@ -0,0 +1,29 @@
+package utils
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// RepairUTF8 replaces every invalid UTF-8 sequence in data with U+FFFD and
+// returns the repaired text along with the number of replacements
+func RepairUTF8(data []byte) (string, int) {
+	if utf8.Valid(data) {
+		return string(data), 0
+	}
+
+	var b strings.Builder
+	b.Grow(len(data))
+	replaced := 0
+	for len(data) > 0 {
+		r, size := utf8.DecodeRune(data)
+		if r == utf8.RuneError && size <= 1 {
+			b.WriteRune(utf8.RuneError)
+			replaced++
+		} else {
+			b.Write(data[:size])
+		}
+		data = data[size:]
+	}
+	return b.String(), replaced
+}
@@ -1,5 +1,7 @@
 package utils
 
+import "golang.org/x/text/cases"
+
 // Min returns the minimum of three integers
 func Min(a, b, c int) int {
 	if a < b {
@@ -22,10 +24,22 @@ func Abs(n int) int {
 	return n
 }
 
-// CalculateSimilarity returns the Levenshtein distance between two strings
-// Lower values mean strings are more similar
+// CalculateSimilarity returns the Levenshtein distance between two strings,
+// counted in characters rather than bytes, so "café" and "cafe" are one edit
+// apart. Lower values mean strings are more similar
 func CalculateSimilarity(s1, s2 string) int {
-	// Levenshtein distance implementation
+	return levenshtein([]rune(s1), []rune(s2))
+}
+
+// CalculateSimilarityFold is CalculateSimilarity ignoring case by Unicode
+// case folding, under which "STRASSE" and "straße" are equal
+func CalculateSimilarityFold(s1, s2 string) int {
+	fold := cases.Fold()
+	return levenshtein([]rune(fold.String(s1)), []rune(fold.String(s2)))
+}
+
+// levenshtein computes the Levenshtein distance between two rune slices
+func levenshtein(s1, s2 []rune) int {
 	if len(s1) == 0 {
 		return len(s2)
 	}
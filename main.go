@@ -0,0 +1,9 @@
+// Command fcopy is the same binary as cmd/fcopy, so that "go build" and
+// "go install" work from the root of the module
+package main
+
+import "fcopy/internal/cli"
+
+func main() {
+	cli.Main()
+}
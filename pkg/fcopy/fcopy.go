@@ -0,0 +1,284 @@
+// Package fcopy gathers files and formats them for pasting into a language
+// model, as the fcopy command does, for tools that embed it instead of
+// shelling out. It never prompts, prints or touches the clipboard:
+//
+//	bundle, err := fcopy.NewCopier(fcopy.Options{MaxTokens: 30000}).Run(ctx, []string{"internal/"})
+//	if err != nil {
+//		return err
+//	}
+//	bundle.WriteTo(os.Stdout)
+package fcopy
+
+import (
+	"context"
+	"errors"
+	"fcopy/internal/collector"
+	"fcopy/internal/config"
+	"fcopy/internal/finder"
+	"fcopy/internal/output"
+	"fcopy/internal/processor"
+	"fcopy/internal/tokens"
+	"fcopy/internal/utils"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// Options select what a Copier gathers and how it formats it. The zero value
+// gathers what the fcopy command does without flags.
+type Options struct {
+	Dir          string   // Directory relative paths are resolved against, instead of the current one
+	Format       string   // Output format: plain (the default), cat, jsonl, bundle or repomix
+	MaxFileSize  int64    // Files over this many bytes are skipped (0 for the default of 1 MiB)
+	MaxFiles     int      // Most files to gather (0 for no limit)
+	MaxTotalSize int64    // Most bytes of output; files that don't fit are omitted (0 for no limit)
+	MaxTokens    int      // Most tokens of output; files that don't fit are omitted (0 for no limit)
+	Workers      int      // Files processed in parallel (0 for the default)
+	Types        []string // Only gather files of these categories found while walking: code, config, docs, data
+	NoTests      bool     // Leave out test files found while walking
+	GitOnly      bool     // Only gather files tracked by git from directories
+	Outline      bool     // Replace function bodies by { ... }, keeping declarations and signatures
+	StripLicense bool     // Remove license comment blocks from the top of files
+	NormalizeEOL bool     // Strip byte order marks and convert CRLF line endings to LF
+
+	// Fuzzy resolves paths that don't exist to their best fuzzy match, as
+	// fcopy --first does; without it they are an error
+	Fuzzy bool
+}
+
+// File is one file of a Bundle
+type File struct {
+	Path    string
+	Content string
+	Reason  string   // Why it was gathered, such as "explicit > directory (from \"internal/\")"
+	Notes   []string // How its content was altered, such as "lines 10-20"
+}
+
+// Bundle is what a Copier gathered
+type Bundle struct {
+	Files      []File // In output order
+	OverSize   []File // Left out by MaxTotalSize
+	OverTokens []File // Left out by MaxTokens
+	Skipped    int    // Files left out by the filters, such as binaries and oversized files
+	Errors     int    // Files that couldn't be read
+
+	cfg                         *config.Config
+	files, overSize, overTokens []processor.FileContent
+}
+
+// Copier gathers files with fixed Options. It is safe to Run concurrently.
+type Copier struct {
+	opts Options
+}
+
+// NewCopier returns a Copier gathering files as opts say
+func NewCopier(opts Options) *Copier {
+	return &Copier{opts: opts}
+}
+
+// config translates the options into the configuration of the fcopy command
+func (c *Copier) config() *config.Config {
+	cfg := config.Defaults()
+	cfg.Cwd = c.opts.Dir
+	if c.opts.Format != "" {
+		cfg.Format = c.opts.Format
+	}
+	if c.opts.MaxFileSize > 0 {
+		cfg.MaxFileSize = c.opts.MaxFileSize
+	}
+	if c.opts.Workers > 0 {
+		cfg.Workers = c.opts.Workers
+	}
+	cfg.MaxFiles = c.opts.MaxFiles
+	cfg.MaxTotalSize = c.opts.MaxTotalSize
+	cfg.MaxTokens = c.opts.MaxTokens
+	cfg.Types = config.ListFlag(c.opts.Types)
+	cfg.NoTests = c.opts.NoTests
+	cfg.GitOnly = c.opts.GitOnly
+	cfg.Outline = c.opts.Outline
+	cfg.StripLicense = c.opts.StripLicense
+	cfg.NormalizeEOL = c.opts.NormalizeEOL
+
+	// Nobody can answer a prompt, and picks aren't remembered
+	cfg.NonInteractive = true
+	cfg.PickFirst = true
+	cfg.NoFrecency = true
+	return cfg
+}
+
+// Run gathers the files and directories at paths, in the order given
+func (c *Copier) Run(ctx context.Context, paths []string) (Bundle, error) {
+	cfg := c.config()
+	if err := output.Validate(cfg); err != nil {
+		return Bundle{}, err
+	}
+	resolved, origins, err := c.resolve(cfg, paths)
+	if err != nil {
+		return Bundle{}, err
+	}
+
+	tracker := &processor.Tracker{}
+	files := collector.Finish(collector.Collect(ctx, resolved, origins, cfg, tracker), cfg)
+	if err := ctx.Err(); err != nil {
+		return Bundle{}, err
+	}
+	files, overSize := collector.Budget(files, cfg.MaxTotalSize, collector.Size)
+	files, overTokens := collector.Budget(files, int64(cfg.MaxTokens), collector.Tokens)
+
+	return Bundle{
+		Files:      toFiles(files),
+		OverSize:   toFiles(overSize),
+		OverTokens: toFiles(overTokens),
+		Skipped:    int(tracker.Skipped.Load()),
+		Errors:     int(tracker.Errors.Load()),
+		cfg:        cfg,
+		files:      files,
+		overSize:   overSize,
+		overTokens: overTokens,
+	}, nil
+}
+
+// resolve finds the files and directories named by paths, with the reason
+// each was gathered for
+func (c *Copier) resolve(cfg *config.Config, paths []string) ([]string, []processor.Origin, error) {
+	var resolved []string
+	var origins []processor.Origin
+	var searcher *finder.Searcher
+	for _, path := range paths {
+		cleanPath := utils.ExpandPath(path, cfg.Cwd)
+		origin := processor.Origin{Arg: path}
+		if _, err := os.Stat(cleanPath); err == nil {
+			resolved = append(resolved, cleanPath)
+			origins = append(origins, origin.Then(processor.RuleExplicit))
+			continue
+		} else if !errors.Is(err, os.ErrNotExist) || !c.opts.Fuzzy {
+			return nil, nil, err
+		}
+
+		if searcher == nil {
+			searcher = finder.NewSearcher(cfg)
+		}
+		matches := searcher.Candidates(cleanPath)
+		if len(matches) == 0 {
+			return nil, nil, fmt.Errorf("%s doesn't exist and nothing matches it", path)
+		}
+		resolved = append(resolved, matches[0].Path)
+		origins = append(origins, origin.Then(processor.RuleFuzzy))
+	}
+	return resolved, origins, nil
+}
+
+// ErrSkipped is returned, wrapped, by Segment.Open for a file that reading
+// shows the filters leave out, such as a generated or minified file
+var ErrSkipped = errors.New("left out by the filters")
+
+// Segment is a gathered file whose content is only read when it is opened,
+// for consumers such as indexers and uploaders that stream a selection too
+// large to hold in memory
+type Segment struct {
+	Path   string
+	Reason string // Why it was gathered, as in File
+
+	cfg    *config.Config
+	origin processor.Origin
+}
+
+// Segments gathers the files and directories at paths as Run does, but
+// without reading them: files are walked and filtered on their paths and
+// sizes only, and the filters that need their content apply when they are
+// opened. MaxTotalSize and MaxTokens don't apply.
+func (c *Copier) Segments(ctx context.Context, paths []string) ([]Segment, error) {
+	cfg := c.config()
+	resolved, origins, err := c.resolve(cfg, paths)
+	if err != nil {
+		return nil, err
+	}
+
+	files := collector.List(ctx, resolved, origins, cfg, &processor.Tracker{})
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	collector.Sort(files)
+	segments := make([]Segment, len(files))
+	for i, f := range files {
+		segments[i] = Segment{Path: f.Path, Reason: f.Origin.String(), cfg: cfg, origin: f.Origin}
+	}
+	return segments, nil
+}
+
+// Open reads the segment's file and returns its content as Run would, or an
+// error wrapping ErrSkipped if the filters leave it out
+func (s Segment) Open() (io.ReadCloser, error) {
+	info, err := os.Stat(s.Path)
+	if err != nil {
+		return nil, err
+	}
+	results := make(chan processor.FileContent, 1)
+	err = processor.ProcessSingleFile(context.Background(), s.Path, info, s.origin, s.cfg, results)
+	var skip *processor.SkipError
+	if errors.As(err, &skip) {
+		return nil, fmt.Errorf("%s: %w: %s", s.Path, ErrSkipped, skip.Reason)
+	} else if err != nil {
+		return nil, fmt.Errorf("%s: %w", s.Path, err)
+	}
+
+	files := []processor.FileContent{<-results}
+	if s.cfg.NormalizeEOL {
+		collector.Normalize(files, true, 0)
+	}
+	if s.cfg.StripLicense {
+		collector.StripLicenses(files)
+	}
+	if s.cfg.Outline {
+		collector.Outline(files)
+	}
+	return io.NopCloser(strings.NewReader(files[0].Content)), nil
+}
+
+// toFiles converts the processor's results to Files
+func toFiles(results []processor.FileContent) []File {
+	files := make([]File, len(results))
+	for i, r := range results {
+		files[i] = File{Path: r.Path, Content: r.Content, Reason: r.Origin.String(), Notes: r.Notes}
+	}
+	return files
+}
+
+// WriteTo writes the bundle in the format of Options.Format, followed in the
+// plain format by the list of files left out, as the fcopy command copies it
+func (b Bundle) WriteTo(w io.Writer) (int64, error) {
+	cw := &countingWriter{w: w}
+	if err := output.Write(cw, b.files, b.cfg); err != nil {
+		return cw.n, err
+	}
+	if err := output.WriteOmitted(cw, b.overSize, "--max-total-size", b.cfg); err != nil {
+		return cw.n, err
+	}
+	err := output.WriteOmitted(cw, b.overTokens, "--max-tokens", b.cfg)
+	return cw.n, err
+}
+
+// String returns the bundle as WriteTo writes it
+func (b Bundle) String() string {
+	var s strings.Builder
+	b.WriteTo(&s)
+	return s.String()
+}
+
+// Tokens estimates the number of tokens of the bundle as WriteTo writes it
+func (b Bundle) Tokens() int {
+	return tokens.Count(b.String())
+}
+
+// countingWriter counts the bytes written through it
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
@@ -0,0 +1,154 @@
+package tests
+
+import (
+	"fcopy/internal/apply"
+	"fcopy/internal/config"
+	"fcopy/internal/output"
+	"fcopy/internal/processor"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// TestParseApplyInput checks that fcopy apply finds files in the plain
+// format, fenced or not, and in Markdown code blocks named in different ways
+func TestParseApplyInput(t *testing.T) {
+	testCases := []struct {
+		name string
+		text string
+		want []apply.File
+	}{
+		{
+			"plain format",
+			"Sure:\n-- a.go --\npackage a\n\n\n-- b/c.txt (lines 1-2) --\nx\n\n-- omitted by --max-tokens (1 files) --\nd.go (10 bytes)\n\n",
+			[]apply.File{{Path: "a.go", Content: "package a\n"}, {Path: "b/c.txt", Content: "x", Notes: []string{"lines 1-2"}}},
+		},
+		{
+			"plain format in a code block",
+			"```\n-- a.go --\npackage a\n\n-- b.go --\npackage b\n```\n",
+			[]apply.File{{Path: "a.go", Content: "package a"}, {Path: "b.go", Content: "package b"}},
+		},
+		{
+			"code blocks",
+			"### `src/app.ts`\n```ts\nexport {}\n```\n\n```go path=main.go\npackage main\n```\n\n```py\n# file: tool.py\nprint(1)\n```\n\n```\nno name\n```\n",
+			[]apply.File{
+				{Path: "src/app.ts", Content: "export {}\n"},
+				{Path: "main.go", Content: "package main\n"},
+				{Path: "tool.py", Content: "print(1)\n"},
+			},
+		},
+	}
+	for _, tc := range testCases {
+		if got := apply.Parse(tc.text); !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("%s: parsed %+v, want %+v", tc.name, got, tc.want)
+		}
+	}
+}
+
+// TestPlanApply checks which parsed files fcopy apply would write
+func TestPlanApply(t *testing.T) {
+	root := t.TempDir()
+	if err := os.WriteFile(filepath.Join(root, "same.go"), []byte("package same\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(root, "old.go"), []byte("package old\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	changes, skipped := apply.Plan([]apply.File{
+		{Path: "same.go", Content: "package same"},
+		{Path: "old.go", Content: "package changed"},
+		{Path: "new/new.go", Content: "package new\n"},
+		{Path: "../escape.go", Content: "x"},
+		{Path: "big.go", Content: "x", Notes: []string{"truncated from 900 lines, 40000 bytes"}},
+	}, root)
+
+	if len(changes) != 2 || changes[0].New != "package changed\n" || changes[1].Exists {
+		t.Errorf("planned changes %+v", changes)
+	}
+	if len(skipped) != 3 {
+		t.Errorf("skipped %+v, want same.go, ../escape.go and big.go", skipped)
+	}
+
+	if err := apply.Write(changes); err != nil {
+		t.Fatal(err)
+	}
+	if data, err := os.ReadFile(filepath.Join(root, "new", "new.go")); err != nil || string(data) != "package new\n" {
+		t.Errorf("new file holds %q, %v", data, err)
+	}
+}
+
+// TestPlanApplySymlinks checks that fcopy apply doesn't write outside the
+// root through symlinks, nor through a symlink inside it
+func TestPlanApplySymlinks(t *testing.T) {
+	dir := t.TempDir()
+	root, outside := filepath.Join(dir, "root"), filepath.Join(dir, "outside")
+	os.MkdirAll(filepath.Join(root, "real"), 0755)
+	os.MkdirAll(outside, 0755)
+	if err := os.WriteFile(filepath.Join(outside, "victim.txt"), []byte("safe\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	for link, target := range map[string]string{
+		"out":      outside,
+		"file.txt": filepath.Join(outside, "victim.txt"),
+		"inside":   filepath.Join(root, "real"),
+	} {
+		if err := os.Symlink(target, filepath.Join(root, link)); err != nil {
+			t.Skipf("can't create symlinks: %v", err)
+		}
+	}
+
+	changes, skipped := apply.Plan([]apply.File{
+		{Path: "out/victim.txt", Content: "pwned\n"},
+		{Path: "out/new/created.txt", Content: "pwned\n"},
+		{Path: "file.txt", Content: "pwned\n"},
+		{Path: "inside/ok.txt", Content: "fine\n"},
+	}, root)
+
+	if len(changes) != 1 || changes[0].Name != "inside/ok.txt" {
+		t.Errorf("planned changes %+v, want only inside/ok.txt", changes)
+	}
+	if len(skipped) != 3 {
+		t.Errorf("skipped %+v, want the paths through symlinks leaving root", skipped)
+	}
+	if err := apply.Write(changes); err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := os.ReadFile(filepath.Join(outside, "victim.txt")); string(data) != "safe\n" {
+		t.Errorf("file outside root was overwritten with %q", data)
+	}
+}
+
+// TestBundleRoundTrip checks that --format bundle parses back to the exact
+// files, even with content that looks like delimiters or lacks a final
+// newline, and that edits show up against the checksums
+func TestBundleRoundTrip(t *testing.T) {
+	files := []processor.FileContent{
+		{Path: "a.txt", Content: "<<<end>>>\n\\<<<file path=\"x\">>>\n-- b.txt --\n"},
+		{Path: "dir/with \"quotes\".md", Content: "no newline"},
+		{Path: "empty", Content: ""},
+		{Path: "big.go", Content: "head\n", Notes: []string{"truncated from 9 lines, 90 bytes"}},
+	}
+	var b strings.Builder
+	if err := output.Write(&b, files, &config.Config{Format: "bundle"}); err != nil {
+		t.Fatal(err)
+	}
+
+	parsed := apply.Parse("Here you go:\n```\n" + b.String() + "```\n")
+	if len(parsed) != len(files) {
+		t.Fatalf("parsed %d files, want %d:\n%s", len(parsed), len(files), b.String())
+	}
+	for i, f := range parsed {
+		if f.Path != files[i].Path || f.Content != files[i].Content || !f.Verbatim || !slices.Equal(f.Notes, files[i].Notes) {
+			t.Errorf("file %d parsed as %+v, want %+v", i, f, files[i])
+		}
+	}
+
+	edited := strings.Replace(b.String(), "no newline", "edited", 1)
+	if parsed := apply.Parse(edited); parsed[1].Verbatim || parsed[1].Content != "edited" {
+		t.Errorf("edited file parsed as %+v", parsed[1])
+	}
+}
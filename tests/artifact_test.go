@@ -0,0 +1,59 @@
+package tests
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// TestOwnArtifactsSkipped runs fcopy over a directory holding its debug log,
+// its .fcopy directory and the output, manifest and log of an earlier run, and
+// checks that none of them are collected, even with --hidden and --no-ignore,
+// while build output stays out by default
+func TestOwnArtifactsSkipped(t *testing.T) {
+	dir := cliDir(t, map[string]string{
+		"main.go":              "package main\n",
+		"fcopy_debug.log":      "debug\n",
+		"sub/fcopy_debug.log":  "debug\n",
+		".fcopy/cache.json":    "{}\n",
+		"sub/.fcopy/note.md":   "note\n",
+		"context.md":           "-- main.go --\nold output\n",
+		"manifest.json":        "{}\n",
+		"run.txt":              "log\n",
+		"dist/bundle.js":       "console.log(1)\n",
+		"build/output/app.txt": "built\n",
+	})
+
+	testCases := []struct {
+		name   string
+		args   []string
+		copied []string
+	}{
+		{"defaults", nil, []string{"main.go"}},
+		{"hidden and no ignore", []string{"--hidden", "--no-ignore"}, []string{"build/output/app.txt", "dist/bundle.js", "main.go"}},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			args := append([]string{"--output", "context.md", "--manifest", "manifest.json", "--log-file", "run.txt"}, tc.args...)
+			out, code := runFcopy(t, dir, "", append(args, ".")...)
+			if code != 0 {
+				t.Fatalf("got exit code %d:\n%s", code, out)
+			}
+			copied, err := os.ReadFile(filepath.Join(dir, "context.md"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			var headers []string
+			for _, line := range strings.Split(string(copied), "\n") {
+				if strings.HasPrefix(line, "-- ") && strings.HasSuffix(line, " --") {
+					headers = append(headers, strings.TrimSuffix(strings.TrimPrefix(line, "-- "), " --"))
+				}
+			}
+			if !slices.Equal(headers, tc.copied) {
+				t.Errorf("Expected only %v to be copied, got %v", tc.copied, headers)
+			}
+		})
+	}
+}
@@ -0,0 +1,87 @@
+package tests
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fcopy/internal/config"
+	"fcopy/internal/processor"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestUndecodableContent checks both sides of the line between a text file
+// with invalid UTF-8 repaired and one taken for binary, which is more than a
+// tenth of its bytes replaced, and what each --binary mode makes of the latter
+func TestUndecodableContent(t *testing.T) {
+	dir := t.TempDir()
+	mostlyText := []byte(strings.Repeat("a", 90) + strings.Repeat("\xff", 10))
+	undecodable := []byte(strings.Repeat("a", 89) + strings.Repeat("\xff", 11))
+	png := []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR")
+	for name, data := range map[string][]byte{"text.dat": mostlyText, "blob.dat": undecodable, "pixel.png": png} {
+		if err := os.WriteFile(filepath.Join(dir, name), data, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	testCases := []struct {
+		name       string
+		file       string
+		discovered bool
+		binary     string
+		limit      int64
+		content    string
+		note       string
+		skipped    string
+	}{
+		{"a tenth replaced", "text.dat", true, "placeholder", 0,
+			strings.Repeat("a", 90) + strings.Repeat("�", 10), "10 invalid UTF-8 sequences replaced", ""},
+		{"over a tenth found while walking", "blob.dat", true, "placeholder", 0, "", "", "binary content"},
+		{"over a tenth with --binary skip", "blob.dat", false, "skip", 0, "", "", "binary content"},
+		{"over a tenth as a placeholder", "blob.dat", false, "placeholder", 0, "[binary skipped: ", "", ""},
+		{"over a tenth as base64", "blob.dat", false, "base64", 1024,
+			base64.StdEncoding.EncodeToString(undecodable)[:76] + "\n", "base64, ", ""},
+		{"binary extension as base64", "pixel.png", false, "base64", 1024,
+			base64.StdEncoding.EncodeToString(png), "base64, image/png, 16 bytes", ""},
+		{"over a tenth over the base64 limit", "blob.dat", false, "base64", 50, "[binary skipped: ", "", ""},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			path := filepath.Join(dir, tc.file)
+			info, err := os.Stat(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			cfg := &config.Config{MaxFileSize: 1024 * 1024, Encoding: "utf-8", Binary: tc.binary, Base64Limit: tc.limit}
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+
+			results := make(chan processor.FileContent, 1)
+			err = processor.ProcessSingleFile(ctx, path, info, processor.Origin{Discovered: tc.discovered}, cfg, results)
+			var skip *processor.SkipError
+			if tc.skipped != "" {
+				if !errors.As(err, &skip) || skip.Reason != tc.skipped {
+					t.Fatalf("Expected to be skipped as %q, got %v", tc.skipped, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Failed to process file: %v", err)
+			}
+			result := <-results
+			if !strings.HasPrefix(result.Content, tc.content) {
+				t.Errorf("Expected content starting with %q, got %q", tc.content, result.Content)
+			}
+			if notes := strings.Join(result.Notes, "; "); !strings.HasPrefix(notes, tc.note) || (tc.note == "") != (notes == "") {
+				t.Errorf("Expected notes starting with %q, got %q", tc.note, notes)
+			}
+			if strings.HasPrefix(tc.content, "[binary") && !strings.HasSuffix(result.Content, fmt.Sprintf(", %d bytes]", len(undecodable))) {
+				t.Errorf("Expected the size in the placeholder, got %q", result.Content)
+			}
+		})
+	}
+}
@@ -0,0 +1,82 @@
+package tests
+
+import (
+	"fcopy/internal/bookmark"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+// TestBookmarks checks that saved bookmarks expand to their paths, and to
+// paths inside them, after a round trip through the bookmarks file
+func TestBookmarks(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "bookmarks.json")
+	marks, err := bookmark.Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	api, web := filepath.Join(dir, "internal", "api"), filepath.Join(dir, "web", "api")
+	if err := marks.Add("api", api, web, api); err != nil {
+		t.Fatal(err)
+	}
+	if err := marks.Add("a/b", dir); err == nil {
+		t.Error("a bookmark name with a slash was accepted")
+	}
+	if err := marks.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	if marks, err = bookmark.Load(path); err != nil {
+		t.Fatal(err)
+	}
+	if got, ok, err := marks.Expand("@api"); !ok || err != nil || !slices.Equal(got, []string{api, web}) {
+		t.Errorf("@api expands to %v, %v, %v", got, ok, err)
+	}
+	if got, _, _ := marks.Expand("@api/users"); !slices.Equal(got, []string{filepath.Join(api, "users"), filepath.Join(web, "users")}) {
+		t.Errorf("@api/users expands to %v", got)
+	}
+	if _, ok, err := marks.Expand("@web"); !ok || err == nil {
+		t.Errorf("unknown @web gave %v, %v", ok, err)
+	}
+	if _, ok, _ := marks.Expand("src/api"); ok {
+		t.Error("src/api was taken for a bookmark")
+	}
+
+	if !marks.Remove("api") || marks.Remove("api") {
+		t.Error("removing api twice didn't succeed exactly once")
+	}
+}
+
+// TestExpandSet checks that the globs of a named set match files at any
+// depth below their literal prefix, leaving out skipped directories
+func TestExpandSet(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"internal/a.go", "internal/x/b.go", "internal/vendor/c.go", "cmd/server/main.go", "cmd/cli/main.go", "cmd/cli/flags.go"} {
+		path := filepath.Join(dir, name)
+		os.MkdirAll(filepath.Dir(path), 0755)
+		if err := os.WriteFile(path, nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	skip := func(path string, isDir bool) bool { return isDir && filepath.Base(path) == "vendor" }
+	got, err := bookmark.ExpandSet([]string{
+		filepath.Join(dir, "internal", "**"),
+		filepath.Join(dir, "cmd", "*", "main.go"),
+		filepath.Join(dir, "README.md"),
+	}, skip)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var rels []string
+	for _, path := range got {
+		rel, _ := filepath.Rel(dir, path)
+		rels = append(rels, filepath.ToSlash(rel))
+	}
+	want := []string{"internal/a.go", "internal/x/b.go", "cmd/cli/main.go", "cmd/server/main.go", "README.md"}
+	if !slices.Equal(rels, want) {
+		t.Errorf("set expands to %q, want %q", rels, want)
+	}
+}
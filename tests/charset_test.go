@@ -0,0 +1,32 @@
+package tests
+
+import (
+	"fcopy/internal/charset"
+	"testing"
+)
+
+// TestToUTF8 checks which encodings are detected and how text in them is
+// transcoded, and that UTF-8 with a stray invalid byte is left to be
+// repaired rather than taken for Windows-1252
+func TestToUTF8(t *testing.T) {
+	testCases := []struct {
+		name string
+		data string
+		want string
+		from string
+	}{
+		{"utf-8", "café\n", "café\n", ""},
+		{"utf-16le with bom", "\xff\xfeh\x00i\x00", "hi", "UTF-16LE"},
+		{"utf-16be without bom", "\x00h\x00e\x00l\x00l\x00o\x00 \x00t\x00h\x00e\x00r\x00e", "hello there", "UTF-16BE"},
+		{"shift-jis", "\x93\xfa\x96\x7b\x8c\xea", "日本語", "Shift_JIS"},
+		{"latin-1", "caf\xe9 au lait", "café au lait", "Windows-1252"},
+		{"latin-1 forming shift-jis pairs", "Gr\xfc\xdfe f\xfcr M\xfcller", "Grüße für Müller", "Windows-1252"},
+		{"utf-8 with a stray byte", "caf\xc3\xa9 na\xc3\xafve r\xc3\xa9sum\xc3\xa9 stray \xff", "caf\xc3\xa9 na\xc3\xafve r\xc3\xa9sum\xc3\xa9 stray \xff", ""},
+	}
+	for _, tc := range testCases {
+		got, from := charset.ToUTF8([]byte(tc.data), "auto")
+		if string(got) != tc.want || from != tc.from {
+			t.Errorf("%s: got %q from %q, want %q from %q", tc.name, got, from, tc.want, tc.from)
+		}
+	}
+}
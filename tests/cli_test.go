@@ -0,0 +1,176 @@
+package tests
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// cliBinary is the fcopy binary built once for the tests that run the command
+var cliBinary struct {
+	once sync.Once
+	dir  string
+	path string
+	err  error
+}
+
+// TestMain removes the fcopy binary built by runFcopy once the tests are done
+func TestMain(m *testing.M) {
+	code := m.Run()
+	if cliBinary.dir != "" {
+		os.RemoveAll(cliBinary.dir)
+	}
+	os.Exit(code)
+}
+
+// runFcopy runs the fcopy command in dir with stdin as its input and returns
+// its combined output and exit code. It gets a home, config and cache
+// directory of its own, so the user's config, bookmarks and index can't leak
+// in; HOME is available to the test as <dir>/../home.
+func runFcopy(t *testing.T, dir, stdin string, args ...string) (string, int) {
+	t.Helper()
+
+	cliBinary.once.Do(func() {
+		cliBinary.dir, cliBinary.err = os.MkdirTemp("", "fcopy-cli")
+		if cliBinary.err != nil {
+			return
+		}
+		cliBinary.path = filepath.Join(cliBinary.dir, "fcopy")
+		if runtime.GOOS == "windows" {
+			cliBinary.path += ".exe"
+		}
+		out, err := exec.Command("go", "build", "-o", cliBinary.path, "fcopy/cmd/fcopy").CombinedOutput()
+		if err != nil {
+			cliBinary.err = errors.New(err.Error() + "\n" + string(out))
+		}
+	})
+	if cliBinary.err != nil {
+		t.Fatalf("Failed to build fcopy: %v", cliBinary.err)
+	}
+
+	home := filepath.Join(filepath.Dir(dir), "home")
+	cmd := exec.Command(cliBinary.path, args...)
+	cmd.Dir = dir
+	cmd.Stdin = strings.NewReader(stdin)
+	cmd.Env = append(os.Environ(), "HOME="+home, "USERPROFILE="+home,
+		"XDG_CONFIG_HOME="+filepath.Join(home, ".config"),
+		"XDG_CACHE_HOME="+filepath.Join(home, ".cache"),
+		"XDG_DATA_HOME="+filepath.Join(home, ".local", "share"))
+	var out bytes.Buffer
+	cmd.Stdout, cmd.Stderr = &out, &out
+	err := cmd.Run()
+	var exit *exec.ExitError
+	if err != nil && !errors.As(err, &exit) {
+		t.Fatalf("Failed to run fcopy %v: %v", args, err)
+	}
+	return out.String(), cmd.ProcessState.ExitCode()
+}
+
+// cliDir creates a directory for runFcopy with the given files, which may
+// be in subdirectories
+func cliDir(t *testing.T, files map[string]string) string {
+	t.Helper()
+
+	dir := filepath.Join(t.TempDir(), "work")
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	return dir
+}
+
+// TestExitCodes checks the exit codes scripts rely on: 2 when nothing could
+// be copied, 4 when --strict can't settle on a match and 1 when
+// --fail-on-error meets a file it can't read
+func TestExitCodes(t *testing.T) {
+	files := map[string]string{
+		"main.go":           "package main\n",
+		"handler_users.go":  "package main\n",
+		"handler_orders.go": "package main\n",
+	}
+	testCases := []struct {
+		name    string
+		args    []string
+		code    int
+		output  string
+		written bool
+	}{
+		{"copied", []string{"main.go"}, 0, "Wrote content from 1 files", true},
+		{"missing path", []string{"nosuchfile.txt"}, 2, "No valid paths to process.", false},
+		{"strict unmatched", []string{"--strict", "nosuchfile.txt"}, 4, "no unambiguous match was found", false},
+		{"strict ambiguous", []string{"--strict", "handler.go"}, 4, "handler_orders.go", false},
+		// A path below a regular file can't be stated, whoever runs the test
+		{"unreadable with fail-on-error", []string{"--fail-on-error", "main.go", "main.go/child"}, 1, "Nothing was copied as 1 errors occurred", false},
+		{"unreadable without fail-on-error", []string{"main.go", "main.go/child"}, 1, "Wrote content from 1 files", true},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			dir := cliDir(t, files)
+			out, code := runFcopy(t, dir, "", append([]string{"--output", "out.txt"}, tc.args...)...)
+			if code != tc.code || !strings.Contains(out, tc.output) {
+				t.Errorf("got exit code %d, want %d with %q in the output:\n%s", code, tc.code, tc.output, out)
+			}
+			if _, err := os.Stat(filepath.Join(dir, "out.txt")); (err == nil) != tc.written {
+				t.Errorf("got out.txt written %v, want %v", err == nil, tc.written)
+			}
+		})
+	}
+}
+
+// TestShadowedSubcommand checks that a file named like a subcommand is still
+// copied, as before the subcommands existed, unless a flag after the name
+// asks for the subcommand
+func TestShadowedSubcommand(t *testing.T) {
+	testCases := []struct {
+		name   string
+		files  map[string]string
+		args   []string
+		copied string
+		output string
+	}{
+		{"doctor file", map[string]string{"doctor": "checkup notes\n"}, []string{"doctor"},
+			"-- doctor --\ncheckup notes\n", `which is also a subcommand; run "fcopy doctor --"`},
+		{"version file", map[string]string{"version": "1.2.3\n"}, []string{"version"},
+			"-- version --\n1.2.3\n", "which is also a subcommand"},
+		{"doctor file and another path", map[string]string{"doctor": "checkup notes\n", "notes.txt": "more\n"}, []string{"doctor", "notes.txt"},
+			"-- doctor --\ncheckup notes\n", "which is also a subcommand"},
+		{"doctor subcommand with a file", map[string]string{"doctor": "checkup notes\n"}, []string{"doctor", "--"},
+			"", "config options:"},
+		{"doctor subcommand", nil, []string{"doctor"}, "", "config options:"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			dir := cliDir(t, tc.files)
+			// --output after the name would ask for the subcommand, so it
+			// comes from the user's defaults
+			config := filepath.Join(filepath.Dir(dir), "home", ".config", "fcopy", "config.yaml")
+			os.MkdirAll(filepath.Dir(config), 0755)
+			if err := os.WriteFile(config, []byte("defaults:\n  output: out.txt\n"), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			out, _ := runFcopy(t, dir, "", tc.args...)
+			if !strings.Contains(out, tc.output) {
+				t.Errorf("got output without %q:\n%s", tc.output, out)
+			}
+			copied, _ := os.ReadFile(filepath.Join(dir, "out.txt"))
+			if !strings.HasPrefix(string(copied), tc.copied) || (tc.copied == "") != (len(copied) == 0) {
+				t.Errorf("got %q copied, want it to start with %q", copied, tc.copied)
+			}
+		})
+	}
+}
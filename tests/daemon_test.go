@@ -0,0 +1,123 @@
+package tests
+
+import (
+	"context"
+	"fcopy/internal/config"
+	"fcopy/internal/finder"
+	"fcopy/internal/index"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// TestDaemonServesSearches checks that fuzzy searches go through a running
+// daemon, which notices files created after it started
+func TestDaemonServesSearches(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	t.Setenv("HOME", t.TempDir())
+	dir := t.TempDir()
+	os.MkdirAll(filepath.Join(dir, "pkg"), 0755)
+	os.WriteFile(filepath.Join(dir, "go.mod"), nil, 0644)
+	os.WriteFile(filepath.Join(dir, "pkg", "old.go"), nil, 0644)
+	cfg := &config.Config{SearchDepth: 5, Workers: 2}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- finder.ServeDaemon(ctx, dir, cfg) }()
+	defer func() {
+		cancel()
+		if err := <-done; err != nil {
+			t.Errorf("daemon failed: %v", err)
+		}
+	}()
+
+	socket, _ := index.SocketPath(dir)
+	for start := time.Now(); ; time.Sleep(10 * time.Millisecond) {
+		if conn, err := net.Dial("unix", socket); err == nil {
+			conn.Close()
+			break
+		}
+		if time.Since(start) > 5*time.Second {
+			t.Fatal("daemon didn't start listening")
+		}
+	}
+
+	os.WriteFile(filepath.Join(dir, "pkg", "created.go"), nil, 0644)
+	for start := time.Now(); ; time.Sleep(10 * time.Millisecond) {
+		matches := finder.NewSearcher(cfg).Matches(dir, "created.go")
+		if len(matches) > 0 && matches[0].Name == "created.go" {
+			if matches[0].Path != filepath.Join(dir, "pkg", "created.go") {
+				t.Errorf("match is reported at %s", matches[0].Path)
+			}
+			break
+		}
+		if time.Since(start) > 5*time.Second {
+			t.Fatalf("daemon never listed created.go: %+v", matches)
+		}
+	}
+}
+
+// TestDaemonScheduler checks that interactive scans start ahead of queued
+// background ones, and that background scans never take the last slot
+func TestDaemonScheduler(t *testing.T) {
+	started := make(chan bool, 4)
+	acquire := func(s *finder.Scheduler, interactive bool) {
+		go func() {
+			s.Acquire(interactive)
+			started <- interactive
+		}()
+		time.Sleep(20 * time.Millisecond) // Queue in this order
+	}
+	next := func() (bool, bool) {
+		select {
+		case interactive := <-started:
+			return interactive, true
+		case <-time.After(100 * time.Millisecond):
+			return false, false
+		}
+	}
+
+	// With every slot busy, a search queued after a background scan gets
+	// the next free slot
+	s := finder.NewScheduler(4)
+	s.Acquire(false)
+	s.Acquire(false)
+	s.Acquire(true)
+	s.Acquire(true)
+	acquire(s, false)
+	acquire(s, true)
+	if _, ok := next(); ok {
+		t.Fatal("a scan started without a free slot")
+	}
+	s.Release(true)
+	if interactive, ok := next(); !ok || !interactive {
+		t.Fatalf("the freed slot went to a background scan (started %v)", ok)
+	}
+	s.Release(true)
+	if interactive, ok := next(); !ok || interactive {
+		t.Fatalf("the queued background scan didn't start (started %v)", ok)
+	}
+
+	// Background scans leave the last slot free for searches
+	s = finder.NewScheduler(3)
+	s.Acquire(false)
+	s.Acquire(false)
+	acquire(s, false)
+	if _, ok := next(); ok {
+		t.Fatal("a background scan took the last slot")
+	}
+	acquire(s, true)
+	if interactive, ok := next(); !ok || !interactive {
+		t.Fatal("a search didn't get the last slot")
+	}
+	s.Release(true)
+	if _, ok := next(); ok {
+		t.Fatal("a background scan took the last slot")
+	}
+	s.Release(false)
+	if interactive, ok := next(); !ok || interactive {
+		t.Fatal("the background scan didn't start once another finished")
+	}
+}
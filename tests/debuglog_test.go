@@ -0,0 +1,76 @@
+package tests
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+// TestDebugLog checks that fcopy writes no debug log unless asked, that
+// --debug writes it to the user cache directory and that --log-file moves it
+// and turns it on, appending across runs
+func TestDebugLog(t *testing.T) {
+	files := map[string]string{"main.go": "package main\n"}
+	// runFcopy's XDG_CACHE_HOME, or the cache directory macOS uses instead
+	cacheLog := func(dir string) string {
+		if runtime.GOOS == "darwin" {
+			return filepath.Join(filepath.Dir(dir), "home", "Library", "Caches", "fcopy", "debug.log")
+		}
+		return filepath.Join(filepath.Dir(dir), "home", ".cache", "fcopy", "debug.log")
+	}
+	included := `Included main.go via explicit path (from "main.go")`
+
+	t.Run("off by default", func(t *testing.T) {
+		dir := cliDir(t, files)
+		if out, code := runFcopy(t, dir, "", "--output", "out.txt", "main.go"); code != 0 {
+			t.Fatalf("got exit code %d:\n%s", code, out)
+		}
+		for _, path := range []string{filepath.Join(dir, "fcopy_debug.log"), cacheLog(dir)} {
+			if _, err := os.Stat(path); err == nil {
+				t.Errorf("Expected no debug log, found %s", path)
+			}
+		}
+	})
+
+	t.Run("debug", func(t *testing.T) {
+		if runtime.GOOS == "windows" {
+			t.Skip("the cache directory is under LocalAppData on Windows")
+		}
+		dir := cliDir(t, files)
+		if out, code := runFcopy(t, dir, "", "--output", "out.txt", "--debug", "main.go"); code != 0 {
+			t.Fatalf("got exit code %d:\n%s", code, out)
+		}
+		if data, err := os.ReadFile(cacheLog(dir)); err != nil || !strings.Contains(string(data), included) {
+			t.Errorf("Expected the debug log in the cache directory to say %q, got %q, %v", included, data, err)
+		}
+		if _, err := os.Stat(filepath.Join(dir, "fcopy_debug.log")); err == nil {
+			t.Error("Expected no debug log in the current directory")
+		}
+	})
+
+	t.Run("log file", func(t *testing.T) {
+		dir := cliDir(t, files)
+		for run := 0; run < 2; run++ {
+			if out, code := runFcopy(t, dir, "", "--output", "out.txt", "--log-file", "run.log", "main.go"); code != 0 {
+				t.Fatalf("got exit code %d:\n%s", code, out)
+			}
+		}
+		data, err := os.ReadFile(filepath.Join(dir, "run.log"))
+		if err != nil || strings.Count(string(data), included) != 2 {
+			t.Errorf("Expected both runs logged to run.log, got %q, %v", data, err)
+		}
+		if _, err := os.Stat(cacheLog(dir)); err == nil {
+			t.Error("Expected no debug log in the cache directory with --log-file")
+		}
+	})
+
+	t.Run("log file that can't be created", func(t *testing.T) {
+		dir := cliDir(t, files)
+		out, code := runFcopy(t, dir, "", "--output", "out.txt", "--log-file", filepath.Join("missing", "run.log"), "main.go")
+		if code != 0 || !strings.Contains(out, "Warning: Could not create debug log file") {
+			t.Errorf("Expected a warning and the copy to go ahead, got %d:\n%s", code, out)
+		}
+	})
+}
@@ -0,0 +1,141 @@
+package tests
+
+import (
+	"context"
+	"fcopy/internal/collector"
+	"fcopy/internal/config"
+	"fcopy/internal/processor"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+// TestOverlappingArguments ensures a file reached through several arguments
+// is only emitted once
+func TestOverlappingArguments(t *testing.T) {
+	tempDir := t.TempDir()
+	srcDir := filepath.Join(tempDir, "src")
+	if err := os.MkdirAll(srcDir, 0755); err != nil {
+		t.Fatalf("Failed to create directory: %v", err)
+	}
+	mainFile := filepath.Join(srcDir, "main.go")
+	if err := os.WriteFile(mainFile, []byte("package main"), 0644); err != nil {
+		t.Fatalf("Failed to create file: %v", err)
+	}
+
+	cfg := &config.Config{MaxFileSize: 1024 * 1024, Workers: 2}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	results := make(chan processor.FileContent, 10)
+	tracker := &processor.Tracker{}
+	var wg sync.WaitGroup
+	for _, path := range []string{srcDir, mainFile, filepath.Join(srcDir, ".", "main.go")} {
+		wg.Add(1)
+		go func(p string) {
+			defer wg.Done()
+			processor.ProcessPath(ctx, p, processor.Origin{Arg: p, Rule: processor.RuleExplicit}, cfg, results, tracker)
+		}(path)
+	}
+	wg.Wait()
+	close(results)
+
+	count := 0
+	for range results {
+		count++
+	}
+	if count != 1 {
+		t.Errorf("Expected main.go to be emitted once, got %d", count)
+	}
+	if tracker.Processed.Load() != 1 {
+		t.Errorf("Expected 1 processed file, got %d", tracker.Processed.Load())
+	}
+}
+
+// TestHardLinksAcrossRoots ensures hard links to one file found under
+// different roots are emitted once, with the other links recorded as aliases
+func TestHardLinksAcrossRoots(t *testing.T) {
+	tempDir := t.TempDir()
+	rootA := filepath.Join(tempDir, "a")
+	rootB := filepath.Join(tempDir, "b")
+	for _, dir := range []string{rootA, rootB} {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatalf("Failed to create directory: %v", err)
+		}
+	}
+	original := filepath.Join(rootA, "lib.js")
+	if err := os.WriteFile(original, []byte("module.exports = {}"), 0644); err != nil {
+		t.Fatalf("Failed to create file: %v", err)
+	}
+	if err := os.Link(original, filepath.Join(rootB, "lib.js")); err != nil {
+		t.Skipf("Hard links not supported: %v", err)
+	}
+
+	cfg := &config.Config{MaxFileSize: 1024 * 1024, Workers: 2}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	results := make(chan processor.FileContent, 10)
+	tracker := &processor.Tracker{}
+	for _, root := range []string{rootA, rootB} {
+		processor.ProcessPath(ctx, root, processor.Origin{Arg: root, Rule: processor.RuleExplicit}, cfg, results, tracker)
+	}
+	close(results)
+
+	var emitted []string
+	for result := range results {
+		emitted = append(emitted, result.Path)
+	}
+	if len(emitted) != 1 {
+		t.Fatalf("Expected one emitted file, got %v", emitted)
+	}
+	if aliases := tracker.Aliases(emitted[0]); len(aliases) != 1 {
+		t.Errorf("Expected one alias for %s, got %v", emitted[0], aliases)
+	}
+}
+
+// TestOverlappingArgumentsAreStable ensures a file named by overlapping
+// arguments keeps the origin of the earliest argument, however the workers
+// race, so output order and reasons don't change between runs
+func TestOverlappingArgumentsAreStable(t *testing.T) {
+	dir := t.TempDir()
+	for i := 0; i < 30; i++ {
+		if err := os.WriteFile(filepath.Join(dir, fmt.Sprintf("f%02d.go", i)), []byte("package x"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	file := filepath.Join(dir, "f29.go")
+
+	for _, tc := range []struct {
+		args []string
+		rule string
+		last string // Path last in output order
+	}{
+		{[]string{dir, file}, processor.RuleExplicit + " > " + processor.RuleDirectory, file},
+		{[]string{file, dir}, processor.RuleExplicit, filepath.Join(dir, "f28.go")},
+	} {
+		for run := 0; run < 20; run++ {
+			cfg := &config.Config{MaxFileSize: 1024 * 1024, Workers: 8}
+			origins := make([]processor.Origin, len(tc.args))
+			for i, arg := range tc.args {
+				origins[i] = processor.Origin{Arg: arg, Rule: processor.RuleExplicit}
+			}
+			tracker := &processor.Tracker{}
+			files := collector.Finish(collector.Collect(context.Background(), tc.args, origins, cfg, tracker), cfg)
+			if len(files) != 30 || tracker.Processed.Load() != 30 {
+				t.Fatalf("%v: got %d files, %d processed", tc.args, len(files), tracker.Processed.Load())
+			}
+			for _, f := range files {
+				if f.Path == file && f.Origin.Rule != tc.rule {
+					t.Fatalf("%v run %d: f29.go came from %q, want %q", tc.args, run, f.Origin.Rule, tc.rule)
+				}
+			}
+			if files[len(files)-1].Path != tc.last {
+				t.Fatalf("%v run %d: %s is last, want %s", tc.args, run, files[len(files)-1].Path, tc.last)
+			}
+		}
+	}
+}
@@ -0,0 +1,114 @@
+package tests
+
+import (
+	"fcopy/internal/deps"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestFollowGoImports checks that --follow-imports pulls in packages of the
+// same module, level by level, and nothing from outside it
+func TestFollowGoImports(t *testing.T) {
+	root := t.TempDir()
+	files := map[string]string{
+		"go.mod":             "module example.com/app\n\ngo 1.22\n",
+		"cmd/main.go":        "package main\n\nimport (\n\t\"fmt\"\n\t\"example.com/app/handler\"\n)\n",
+		"handler/handler.go": "package handler\n\nimport \"example.com/app/model\"\n",
+		"handler/h_test.go":  "package handler\n",
+		"model/model.go":     "package model\n\nimport \"github.com/other/lib\"\n",
+		"unused/unused.go":   "package unused\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	main := filepath.Join(root, "cmd", "main.go")
+	got := deps.Follow([]string{main}, 1)
+	if len(got) != 1 || got[0].Path != filepath.Join(root, "handler", "handler.go") || got[0].Import != "example.com/app/handler" {
+		t.Errorf("depth 1 followed %+v", got)
+	}
+
+	got = deps.Follow([]string{main}, 2)
+	if len(got) != 2 || got[1].Path != filepath.Join(root, "model", "model.go") || got[1].Depth != 2 {
+		t.Errorf("depth 2 followed %+v", got)
+	}
+}
+
+// TestFollowScriptImports checks relative imports, tsconfig path aliases,
+// index files and .js specifiers of TypeScript sources
+func TestFollowScriptImports(t *testing.T) {
+	root := t.TempDir()
+	files := map[string]string{
+		"tsconfig.json": `{
+  // comments and trailing commas are allowed
+  "compilerOptions": {"baseUrl": ".", "paths": {"@/*": ["src/*"],},},
+}`,
+		"src/app.ts":                "import { h } from './handler';\nimport type { User } from \"@/models/user\";\nimport React from 'react';\nconst lazy = import('./lazy.js');\n",
+		"src/handler/index.ts":      "const util = require('../util');\n",
+		"src/models/user.ts":        "export interface User {}\n",
+		"src/lazy.ts":               "export {}\n",
+		"src/util.js":               "import '../node_modules/pkg/index.js';\n",
+		"node_modules/pkg/index.js": "",
+	}
+	for name, content := range files {
+		path := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	got := make(map[string]int)
+	for _, m := range deps.Follow([]string{filepath.Join(root, "src", "app.ts")}, 3) {
+		rel, _ := filepath.Rel(root, m.Path)
+		got[filepath.ToSlash(rel)] = m.Depth
+	}
+	want := map[string]int{"src/handler/index.ts": 1, "src/models/user.ts": 1, "src/lazy.ts": 1, "src/util.js": 2}
+	if len(got) != len(want) {
+		t.Errorf("followed %v, want %v", got, want)
+	}
+	for path, depth := range want {
+		if got[path] != depth {
+			t.Errorf("%s followed at depth %d, want %d", path, got[path], depth)
+		}
+	}
+}
+
+// TestFollowScriptImportsStayInProject checks that imports leaving the
+// project, through relative paths or aliases, and imports of files that
+// aren't scripts are ignored
+func TestFollowScriptImportsStayInProject(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"secret_outside.txt": "TOP SECRET\n",
+		"outside.ts":         "export {}\n",
+		"repo/package.json":  "{}\n",
+		"repo/tsconfig.json": `{"compilerOptions": {"baseUrl": ".", "paths": {"~/*": ["../*"]}}}`,
+		"repo/src/index.ts":  "import x from '../../secret_outside.txt';\nimport o from '../../outside';\nimport a from '~/outside';\nimport './style.css';\nimport { y } from './local';\n",
+		"repo/src/style.css": "body {}\n",
+		"repo/src/local.ts":  "export const y = 1\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	got := deps.Follow([]string{filepath.Join(dir, "repo", "src", "index.ts")}, 2)
+	if len(got) != 1 || got[0].Path != filepath.Join(dir, "repo", "src", "local.ts") {
+		t.Errorf("followed %+v, want only src/local.ts", got)
+	}
+}
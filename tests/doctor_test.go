@@ -0,0 +1,123 @@
+package tests
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+// runDoctor runs "fcopy doctor" in dir and returns the status of each check
+// by name, along with the whole output and the exit code
+func runDoctor(t *testing.T, dir string) (map[string]string, string, int) {
+	t.Helper()
+	out, code := runFcopy(t, dir, "", "doctor")
+	statuses := make(map[string]string)
+	for _, line := range strings.Split(out, "\n") {
+		if !strings.HasPrefix(line, "[") {
+			continue
+		}
+		status, rest, _ := strings.Cut(strings.TrimPrefix(line, "["), "] ")
+		name, _, _ := strings.Cut(rest, ": ")
+		statuses[name] = strings.TrimSpace(status)
+	}
+	// The clipboard depends on the machine, but any failure must fail the run
+	failed := strings.Contains(out, "[fail]")
+	if (failed && code != 1) || (!failed && code != 0) {
+		t.Errorf("Expected exit code 1 only with a failed check, got %d:\n%s", code, out)
+	}
+	return statuses, out, code
+}
+
+// TestDoctor checks the config, index and git checks of fcopy doctor, and
+// that each problem it finds comes with a fix
+func TestDoctor(t *testing.T) {
+	userConfig := func(dir, content string) {
+		path := filepath.Join(filepath.Dir(dir), "home", ".config", "fcopy", "config.yaml")
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	t.Run("healthy", func(t *testing.T) {
+		dir := cliDir(t, map[string]string{"main.go": "package main\n"})
+		statuses, out, _ := runDoctor(t, dir)
+		for _, name := range []string{"config", "config options", "index"} {
+			if statuses[name] != "ok" {
+				t.Errorf("Expected the %s check to pass, got:\n%s", name, out)
+			}
+		}
+		if _, ok := statuses["clipboard"]; !ok {
+			t.Errorf("Expected a clipboard check, got:\n%s", out)
+		}
+		if _, ok := statuses["git"]; !ok {
+			t.Errorf("Expected a git check, got:\n%s", out)
+		}
+		if !strings.Contains(out, "(not found; defaults apply)") {
+			t.Errorf("Expected the missing config file to be reported, got:\n%s", out)
+		}
+	})
+
+	t.Run("invalid config file", func(t *testing.T) {
+		dir := cliDir(t, nil)
+		userConfig(dir, "defaults: [\n")
+		statuses, out, code := runDoctor(t, dir)
+		if statuses["config"] != "fail" || code != 1 || !strings.Contains(out, "invalid config file") {
+			t.Errorf("Expected the config check to fail, got %d:\n%s", code, out)
+		}
+		if !strings.Contains(out, "fix: correct the file") {
+			t.Errorf("Expected a fix for the config file, got:\n%s", out)
+		}
+	})
+
+	t.Run("invalid option", func(t *testing.T) {
+		dir := cliDir(t, nil)
+		userConfig(dir, "defaults:\n  max-files: lots\n")
+		statuses, out, code := runDoctor(t, dir)
+		if statuses["config"] != "ok" || statuses["config options"] != "fail" || code != 1 {
+			t.Errorf("Expected only the config options check to fail, got %d:\n%s", code, out)
+		}
+		if !strings.Contains(out, `invalid value "lots" for max-files`) {
+			t.Errorf("Expected the bad value to be named, got:\n%s", out)
+		}
+	})
+
+	t.Run("index", func(t *testing.T) {
+		if runtime.GOOS == "windows" {
+			t.Skip("the cache directory is under LocalAppData on Windows")
+		}
+		dir := cliDir(t, map[string]string{"src/main.go": "package main\n"})
+		if out, code := runFcopy(t, dir, "", "index"); code != 0 {
+			t.Fatalf("got exit code %d:\n%s", code, out)
+		}
+		statuses, out, _ := runDoctor(t, dir)
+		if statuses["index"] != "ok" || !strings.Contains(out, "directories of "+dir+" indexed in") {
+			t.Errorf("Expected the index to be found, got:\n%s", out)
+		}
+
+		// runFcopy's XDG_CACHE_HOME, or the cache directory macOS uses instead
+		cache := filepath.Join(filepath.Dir(dir), "home", ".cache")
+		if runtime.GOOS == "darwin" {
+			cache = filepath.Join(filepath.Dir(dir), "home", "Library", "Caches")
+		}
+		indexes, _ := filepath.Glob(filepath.Join(cache, "fcopy", "index", "*.json"))
+		if len(indexes) != 1 {
+			t.Fatalf("Expected one index file, got %v", indexes)
+		}
+		if err := os.WriteFile(indexes[0], []byte("{not json"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		statuses, out, _ = runDoctor(t, dir)
+		if statuses["index"] != "warn" || !strings.Contains(out, "fix: run fcopy index to rebuild it") {
+			t.Errorf("Expected a warning that the index is corrupt, got:\n%s", out)
+		}
+	})
+
+	if out, code := runFcopy(t, cliDir(t, nil), "", "doctor", "extra"); code != 1 || !strings.Contains(out, "Usage: fcopy doctor") {
+		t.Errorf("Expected usage and exit code 1 for an extra argument, got %d:\n%s", code, out)
+	}
+}
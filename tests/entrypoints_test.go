@@ -0,0 +1,78 @@
+package tests
+
+import (
+	"fcopy/internal/entrypoints"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// TestDetectEntrypoints checks which files Detect finds in projects of a few
+// languages, and that entry points come before routing and config
+func TestDetectEntrypoints(t *testing.T) {
+	testCases := []struct {
+		name  string
+		files []string
+		want  []string
+	}{
+		{"go", []string{"main.go", "cmd/tool/main.go", "cmd/tool/flags.go", "internal/api/routes.go", "internal/api/api.go", "go.mod", "Dockerfile", "README.md"},
+			[]string{"main.go", "cmd/tool/main.go", "go.mod", "Dockerfile"}},
+		{"go routes", []string{"cmd/server/main.go", "api/routes.go", "go.mod"},
+			[]string{"cmd/server/main.go", "api/routes.go", "go.mod"}},
+		{"typescript", []string{"src/index.ts", "src/routes/index.ts", "src/util.ts", "package.json", "tsconfig.json", "vite.config.ts"},
+			[]string{"src/index.ts", "src/routes/index.ts", "package.json", "tsconfig.json", "vite.config.ts"}},
+		{"django", []string{"manage.py", "site/wsgi.py", "site/urls.py", "site/models.py", "requirements.txt"},
+			[]string{"manage.py", "site/wsgi.py", "site/urls.py", "requirements.txt"}},
+		{"dotnet", []string{"Api/Program.cs", "Api/Api.csproj", "App.sln"},
+			[]string{"Api/Program.cs", "App.sln"}},
+		{"nothing", []string{"notes.txt"}, nil},
+	}
+	for _, tc := range testCases {
+		files := make(map[string]string)
+		for _, file := range tc.files {
+			files[file] = "x\n"
+		}
+		dir := cliDir(t, files)
+		var got []string
+		for _, match := range entrypoints.Detect(dir) {
+			rel, _ := filepath.Rel(dir, match.Path)
+			got = append(got, filepath.ToSlash(rel))
+		}
+		if !slices.Equal(got, tc.want) {
+			t.Errorf("%s: expected %v, got %v", tc.name, tc.want, got)
+		}
+	}
+}
+
+// TestEntrypointsCopy runs --entrypoints from the command line and checks
+// that the files it detects are copied in order after the paths given
+// alongside
+func TestEntrypointsCopy(t *testing.T) {
+	dir := cliDir(t, map[string]string{
+		"cmd/server/main.go": "package main\n",
+		"api/routes.go":      "package api\n",
+		"api/api.go":         "package api\n",
+		"go.mod":             "module example.com/app\n",
+		"README.md":          "# App\n",
+	})
+	out, code := runFcopy(t, dir, "", "--output", "out.txt", "--entrypoints", "README.md")
+	if code != 0 {
+		t.Fatalf("got exit code %d:\n%s", code, out)
+	}
+	copied, err := os.ReadFile(filepath.Join(dir, "out.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var headers []string
+	for _, line := range strings.Split(string(copied), "\n") {
+		if strings.HasPrefix(line, "-- ") {
+			headers = append(headers, line)
+		}
+	}
+	want := []string{"-- README.md --", "-- cmd/server/main.go --", "-- api/routes.go --", "-- go.mod --"}
+	if !slices.Equal(headers, want) {
+		t.Errorf("Expected the headers %q, got %q", want, headers)
+	}
+}
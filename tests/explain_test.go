@@ -0,0 +1,48 @@
+package tests
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestExplain runs --explain over files each rule applies to and checks
+// the verdicts printed for them, and that nothing is copied
+func TestExplain(t *testing.T) {
+	dir := cliDir(t, map[string]string{
+		"src/main.go":         "package main\n",
+		"src/main_test.go":    "package main\n",
+		"src/gen.go":          "// Code generated by x. DO NOT EDIT.\npackage main\n",
+		"node_modules/x/i.js": "module.exports = 1\n",
+		"logo.png":            "\x89PNG",
+		".env":                "TOKEN=x\n",
+		"big.txt":             strings.Repeat("a", 200) + "\n",
+	})
+
+	out, code := runFcopy(t, dir, "", "--output", "out.txt", "--explain", "--no-tests", "--max-size", "100",
+		"src/main.go", "src/main_test.go", "src/gen.go", "node_modules/x/i.js", "logo.png", ".env", "big.txt",
+		"node_modules", "src", "nosuch.go")
+	if code != 0 {
+		t.Fatalf("got exit code %d:\n%s", code, out)
+	}
+	for _, want := range []string{
+		"src/main.go:\n  given as an argument: copied\n  found while walking: copied\n",
+		"src/main_test.go:\n  given as an argument: copied\n  found while walking: skipped: test file (--no-tests)\n",
+		"src/gen.go:\n  given as an argument: copied\n  found while walking: skipped: generated file\n",
+		"node_modules/x/i.js:\n  given as an argument: copied\n  found while walking: skipped: inside node_modules: ignored directory name \"node_modules\" (use --no-ignore to include it)\n",
+		"logo.png:\n  given as an argument: copied\n  found while walking: skipped: binary file\n",
+		".env:\n  given as an argument: copied\n  found while walking: skipped: hidden file (use --hidden to include it)\n",
+		"big.txt:\n  given as an argument: skipped: file too large (size: 201 bytes), over --max-size 100 (use --truncate to include part of it)\n",
+		"node_modules:\n  given as an argument: walked\n  inside a walked directory: skipped: inside node_modules: ",
+		"src:\n  given as an argument: walked\n  inside a walked directory: walked\n",
+		"nosuch.go: stat nosuch.go: ",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("Expected the explanation to contain %q, got:\n%s", want, out)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(dir, "out.txt")); err == nil {
+		t.Error("Expected --explain not to copy anything")
+	}
+}
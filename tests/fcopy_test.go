@@ -0,0 +1,96 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"fcopy/pkg/fcopy"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestCopier checks that the library gathers files as the command does and
+// returns them rather than printing them
+func TestCopier(t *testing.T) {
+	dir := t.TempDir()
+	for name, content := range map[string]string{
+		"src/handler.go":      "package src\n",
+		"src/handler_test.go": "package src\n",
+		"README.md":           "# Demo\n",
+	} {
+		path := filepath.Join(dir, name)
+		os.MkdirAll(filepath.Dir(path), 0755)
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	copier := fcopy.NewCopier(fcopy.Options{Dir: dir, NoTests: true, Fuzzy: true})
+	bundle, err := copier.Run(context.Background(), []string{"src", "REDME.md"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var paths []string
+	for _, f := range bundle.Files {
+		rel, _ := filepath.Rel(dir, f.Path)
+		paths = append(paths, filepath.ToSlash(rel))
+	}
+	if strings.Join(paths, " ") != "src/handler.go README.md" {
+		t.Errorf("gathered %v, want src/handler.go and README.md", paths)
+	}
+	if text := bundle.String(); !strings.Contains(text, "handler.go --\npackage src") || !strings.Contains(text, "# Demo") {
+		t.Errorf("bundle is formatted as\n%s", text)
+	}
+
+	if _, err := fcopy.NewCopier(fcopy.Options{Dir: dir}).Run(context.Background(), []string{"REDME.md"}); err == nil {
+		t.Error("a missing path without Fuzzy isn't an error")
+	}
+}
+
+// TestCopierSegments checks that segments are listed without reading their
+// files, which are read and filtered only when opened
+func TestCopierSegments(t *testing.T) {
+	dir := t.TempDir()
+	for name, content := range map[string]string{
+		"a.txt":     "first\r\n",
+		"b.txt":     "second\n",
+		"image.png": "binary",
+		"gen.go":    "// Code generated by stringer. DO NOT EDIT.\npackage gen\n",
+	} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	segments, err := fcopy.NewCopier(fcopy.Options{Dir: dir, NormalizeEOL: true}).Segments(context.Background(), []string{"."})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Content isn't read, so the generated file is only left out when opened
+	var names []string
+	for _, s := range segments {
+		names = append(names, filepath.Base(s.Path))
+	}
+	if strings.Join(names, " ") != "a.txt b.txt gen.go" {
+		t.Fatalf("got segments %q, want a.txt, b.txt and gen.go", names)
+	}
+	if _, err := segments[2].Open(); !errors.Is(err, fcopy.ErrSkipped) {
+		t.Errorf("opening the generated file gave %v, want ErrSkipped", err)
+	}
+
+	// Content is read as of opening, not of listing
+	os.WriteFile(filepath.Join(dir, "b.txt"), []byte("changed\n"), 0644)
+	for i, want := range []string{"first\n", "changed\n"} {
+		r, err := segments[i].Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, err := io.ReadAll(r)
+		r.Close()
+		if err != nil || string(data) != want {
+			t.Errorf("%s has %q, %v, want %q", segments[i].Path, data, err, want)
+		}
+	}
+}
@@ -0,0 +1,97 @@
+package tests
+
+import (
+	"fcopy/internal/utils"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// TestReadFileList checks how newline- and NUL-separated lists are split,
+// and which entries are skipped
+func TestReadFileList(t *testing.T) {
+	testCases := []struct {
+		name string
+		data string
+		nul  bool
+		want []string
+	}{
+		{"lines", "main.go\ninternal/utils/path.go\n", false, []string{"main.go", "internal/utils/path.go"}},
+		{"no final newline", "a.go\nb.go", false, []string{"a.go", "b.go"}},
+		{"crlf", "a.go\r\nb.go\r\n", false, []string{"a.go", "b.go"}},
+		{"blank lines", "\na.go\n  \n\nb.go\n\n", false, []string{"a.go", "b.go"}},
+		{"comments", "# from rg -l TODO\na.go\n  # b.go is done\nc.go\n", false, []string{"a.go", "c.go"}},
+		{"spaces in names", "docs/read me.md\n", false, []string{"docs/read me.md"}},
+		{"nul", "a.go\x00dir/b c.go\x00", true, []string{"a.go", "dir/b c.go"}},
+		{"nul without a final nul", "a.go\x00b.go", true, []string{"a.go", "b.go"}},
+		{"nul keeps newlines and #", "a\nb.go\x00#c.go\x00\x00", true, []string{"a\nb.go", "#c.go"}},
+		{"empty", "", false, nil},
+	}
+	for _, tc := range testCases {
+		path := filepath.Join(t.TempDir(), "list.txt")
+		if err := os.WriteFile(path, []byte(tc.data), 0644); err != nil {
+			t.Fatal(err)
+		}
+		got, err := utils.ReadFileList(path, tc.nul)
+		if err != nil || !slices.Equal(got, tc.want) {
+			t.Errorf("%s: got %q, %v, want %q", tc.name, got, err, tc.want)
+		}
+	}
+
+	if _, err := utils.ReadFileList(filepath.Join(t.TempDir(), "missing.txt"), false); err == nil {
+		t.Error("Expected an error for a missing list")
+	}
+}
+
+// TestFilesFrom runs --files-from over stdin and a list file, with and
+// without -0, and checks that relative entries are taken from --cwd
+func TestFilesFrom(t *testing.T) {
+	files := map[string]string{
+		"project/a.go":       "package a\n",
+		"project/b c.go":     "package b\n",
+		"project/skipped.go": "package skipped\n",
+	}
+	testCases := []struct {
+		name  string
+		stdin string
+		list  string
+		args  []string
+		want  []string
+	}{
+		{"stdin", "project/a.go\n\n# project/skipped.go\nproject/b c.go\n", "",
+			[]string{"--files-from", "-"}, []string{"project/a.go", "project/b c.go"}},
+		{"stdin nul", "project/a.go\x00project/b c.go\x00", "",
+			[]string{"--files-from", "-", "-0"}, []string{"project/a.go", "project/b c.go"}},
+		{"list file with cwd", "", "a.go\n# skipped.go\n\nb c.go\n",
+			[]string{"--files-from", "list.txt", "--cwd", "project"}, []string{"project/a.go", "project/b c.go"}},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			dir := cliDir(t, files)
+			if tc.list != "" {
+				if err := os.WriteFile(filepath.Join(dir, "list.txt"), []byte(tc.list), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			out, code := runFcopy(t, dir, tc.stdin, append([]string{"--output", "out.txt"}, tc.args...)...)
+			if code != 0 {
+				t.Fatalf("got exit code %d:\n%s", code, out)
+			}
+			copied, err := os.ReadFile(filepath.Join(dir, "out.txt"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for _, line := range strings.Split(string(copied), "\n") {
+				if strings.HasPrefix(line, "-- ") && strings.HasSuffix(line, " --") {
+					got = append(got, filepath.ToSlash(strings.TrimPrefix(strings.TrimSuffix(line, " --"), "-- ")))
+				}
+			}
+			if !slices.Equal(got, tc.want) {
+				t.Errorf("got %q copied, want %q", got, tc.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,551 @@
+package tests
+
+import (
+	"fcopy/internal/config"
+	"fcopy/internal/finder"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/mattn/go-isatty"
+)
+
+// TestSubsequenceScore checks that abbreviations match and that consecutive
+// runs and word starts score higher than scattered characters
+func TestSubsequenceScore(t *testing.T) {
+	if _, ok := finder.SubsequenceScore("prcssr", "processor.go"); !ok {
+		t.Error("prcssr doesn't match processor.go")
+	}
+	if _, ok := finder.SubsequenceScore("rp", "processor.go"); ok {
+		t.Error("rp matches processor.go out of order")
+	}
+
+	better := []struct{ query, good, bad string }{
+		{"proc", "proc_utils.go", "pxrxoxc.go"},
+		{"cfg", "config_file.go", "xcxfxg.go"},
+		{"tf", "test_file.go", "stuff.go"},
+	}
+	for _, c := range better {
+		good, _ := finder.SubsequenceScore(c.query, c.good)
+		bad, _ := finder.SubsequenceScore(c.query, c.bad)
+		if good <= bad {
+			t.Errorf("%q scores %d on %q, not above %d on %q", c.query, good, c.good, bad, c.bad)
+		}
+	}
+}
+
+// TestFindAbbreviation checks that an abbreviation finds the file Levenshtein
+// distance alone would miss
+func TestFindAbbreviation(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"internal/proc/processor.go", "internal/printer.go", "README.md"} {
+		path := filepath.Join(dir, name)
+		os.MkdirAll(filepath.Dir(path), 0755)
+		if err := os.WriteFile(path, nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	cfg := &config.Config{SearchDepth: 3}
+	matches := finder.FindRecursiveMatches(dir, "prcssr", 0, cfg)
+	if len(matches) == 0 || matches[0].Name != "processor.go" {
+		t.Fatalf("best matches for prcssr are %+v", matches)
+	}
+}
+
+// TestFindByPath checks that queries are matched against relative paths, so
+// same-named files in different directories can be told apart
+func TestFindByPath(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"internal/config/config.go", "internal/processor/processor.go", "cmd/processor/processor.go", "docs/proc.md"} {
+		path := filepath.Join(dir, name)
+		os.MkdirAll(filepath.Dir(path), 0755)
+		if err := os.WriteFile(path, nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	cfg := &config.Config{SearchDepth: 3}
+	cases := []struct{ query, want string }{
+		{"icfg", "internal/config"},
+		{"cmd/proc", "cmd/processor"},
+		{"int/proc", "internal/processor"},
+		{"cmd/proc/proc", "cmd/processor/processor.go"},
+		{"cmd/processor/processor.go", "cmd/processor/processor.go"},
+	}
+	for _, c := range cases {
+		matches := finder.FindRecursiveMatches(dir, c.query, 0, cfg)
+		if len(matches) == 0 {
+			t.Errorf("nothing matches %q", c.query)
+			continue
+		}
+		if got, _ := filepath.Rel(dir, matches[0].Path); filepath.ToSlash(got) != c.want {
+			t.Errorf("best match for %q is %s, want %s", c.query, got, c.want)
+		}
+	}
+}
+
+// TestFindHonorsIgnoreFiles checks that fuzzy search skips paths excluded by
+// .gitignore and .fcopyignore files, including re-included ones and nested
+// files, unless --no-ignore is set
+func TestFindHonorsIgnoreFiles(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		".git/HEAD":                     "",
+		".gitignore":                    "generated-out/\n*.report\n!keep.report\n",
+		".fcopyignore":                  "/report_scratch.md\n",
+		"generated-out/report.go":       "",
+		"src/report.go":                 "",
+		"src/daily.report":              "",
+		"src/keep.report":               "",
+		"src/.gitignore":                "legacy_report.go\n",
+		"src/legacy_report.go":          "",
+		"report_scratch.md":             "",
+		"docs/nested/report_scratch.md": "",
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		os.MkdirAll(filepath.Dir(path), 0755)
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	find := func(cfg *config.Config) []string {
+		var paths []string
+		for _, m := range finder.FindRecursiveMatches(dir, "report", 0, cfg) {
+			rel, _ := filepath.Rel(dir, m.Path)
+			paths = append(paths, filepath.ToSlash(rel))
+		}
+		slices.Sort(paths)
+		return paths
+	}
+
+	want := []string{"docs/nested/report_scratch.md", "src/keep.report", "src/report.go"}
+	if got := find(&config.Config{SearchDepth: 3}); !slices.Equal(got, want) {
+		t.Errorf("found %q, want %q", got, want)
+	}
+	if got := find(&config.Config{SearchDepth: 3, NoIgnore: true}); !slices.Contains(got, "generated-out/report.go") {
+		t.Errorf("--no-ignore found %q", got)
+	}
+}
+
+// TestSearcherSharesScan checks that a Searcher scans a directory once for
+// all the paths resolved under it
+func TestSearcherSharesScan(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "alpha.go"), nil, 0644)
+
+	searcher := finder.NewSearcher(&config.Config{SearchDepth: 3, Workers: 4, NoIndex: true})
+	if matches := searcher.Matches(dir, "alpha"); len(matches) != 1 {
+		t.Fatalf("matches for alpha are %+v", matches)
+	}
+
+	// A file created after the scan isn't seen by the same searcher
+	os.WriteFile(filepath.Join(dir, "beta.go"), nil, 0644)
+	for _, m := range searcher.Matches(dir, "beta.go") {
+		if m.Name == "beta.go" {
+			t.Errorf("directory was scanned again")
+		}
+	}
+	if matches := finder.NewSearcher(&config.Config{SearchDepth: 3, NoIndex: true}).Matches(dir, "beta.go"); len(matches) == 0 || matches[0].Name != "beta.go" {
+		t.Errorf("matches for beta.go are %+v", matches)
+	}
+}
+
+// TestFindCamelCaseAbbreviation checks that hitting the word starts of a
+// camelCase name beats a plain substring hit
+func TestFindCamelCaseAbbreviation(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"src/UserProfileController.ts", "src/cupcake.ts", "src/upcoming_events.ts"} {
+		path := filepath.Join(dir, name)
+		os.MkdirAll(filepath.Dir(path), 0755)
+		if err := os.WriteFile(path, nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	matches := finder.FindRecursiveMatches(dir, "upc", 0, &config.Config{SearchDepth: 3})
+	if len(matches) == 0 || matches[0].Name != "UserProfileController.ts" {
+		t.Fatalf("best matches for upc are %+v", matches)
+	}
+
+	hump, _ := finder.SubsequenceScore("hs", "HTTPServer.go")
+	flat, _ := finder.SubsequenceScore("hs", "httpserver.go")
+	if hump <= flat {
+		t.Errorf("hs scores %d on HTTPServer.go, not above %d on httpserver.go", hump, flat)
+	}
+}
+
+// TestFindMultiTerm checks that every space-separated term of a query must
+// match a segment of the path
+func TestFindMultiTerm(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{
+		"api/user/handler_test.go",
+		"api/user/handler.go",
+		"api/order/handler_test.go",
+		"web/user_test.go",
+	} {
+		path := filepath.Join(dir, name)
+		os.MkdirAll(filepath.Dir(path), 0755)
+		if err := os.WriteFile(path, nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var paths []string
+	for _, m := range finder.FindRecursiveMatches(dir, "user handler test", 0, &config.Config{SearchDepth: 3}) {
+		rel, _ := filepath.Rel(dir, m.Path)
+		paths = append(paths, filepath.ToSlash(rel))
+	}
+	if want := []string{"api/user/handler_test.go"}; !slices.Equal(paths, want) {
+		t.Errorf("user handler test matches %q, want %q", paths, want)
+	}
+
+	matches := finder.FindRecursiveMatches(dir, "ordr hndlr", 0, &config.Config{SearchDepth: 3})
+	if len(matches) == 0 || matches[0].Path != filepath.Join(dir, "api", "order", "handler_test.go") {
+		t.Errorf("best matches for ordr hndlr are %+v", matches)
+	}
+}
+
+// TestFindExtensionHint checks that ".ext" terms and ":ext" suffixes only
+// match files with that extension, compared without it
+func TestFindExtensionHint(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"api/handler.go", "api/handler.ts", "api/handler/README.md", "web/user_handler.ts"} {
+		path := filepath.Join(dir, name)
+		os.MkdirAll(filepath.Dir(path), 0755)
+		if err := os.WriteFile(path, nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	cfg := &config.Config{SearchDepth: 3}
+
+	for _, query := range []string{"handler .go", "handler:go", "hndlr:GO"} {
+		matches := finder.FindRecursiveMatches(dir, query, 0, cfg)
+		if len(matches) != 1 || matches[0].Path != filepath.Join(dir, "api", "handler.go") {
+			t.Errorf("matches for %q are %+v", query, matches)
+		}
+	}
+	if matches := finder.FindRecursiveMatches(dir, "handler:go", 0, cfg); matches[0].Score != 0 {
+		t.Errorf("handler:go scores %d on handler.go", matches[0].Score)
+	}
+
+	var paths []string
+	for _, m := range finder.FindRecursiveMatches(dir, "web handler .ts", 0, cfg) {
+		rel, _ := filepath.Rel(dir, m.Path)
+		paths = append(paths, filepath.ToSlash(rel))
+	}
+	if want := []string{"web/user_handler.ts"}; !slices.Equal(paths, want) {
+		t.Errorf("web handler .ts matches %q, want %q", paths, want)
+	}
+}
+
+// TestParseSelection checks that several matches can be picked at the
+// prompt with comma-separated numbers
+func TestParseSelection(t *testing.T) {
+	cases := []struct {
+		input string
+		want  []int
+		ok    bool
+	}{
+		{"2\n", []int{2}, true},
+		{"1, 3\n", []int{1, 3}, true},
+		{"3,1,3\n", []int{3, 1}, true},
+		{"0\n", nil, true},
+		{"4\n", nil, false},
+		{"1,x\n", nil, false},
+		{"\n", nil, false},
+	}
+	for _, c := range cases {
+		got, ok := finder.ParseSelection(c.input, 3)
+		if ok != c.ok || !slices.Equal(got, c.want) {
+			t.Errorf("ParseSelection(%q) = %v, %v, want %v, %v", c.input, got, ok, c.want, c.ok)
+		}
+	}
+}
+
+// TestFindNonInteractive checks that --first, --select and --strict resolve
+// ambiguous matches without reading stdin
+func TestFindNonInteractive(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"src/handler.go", "src/handler_test.go", "src/util.go", "src/util_test.go"} {
+		path := filepath.Join(dir, name)
+		os.MkdirAll(filepath.Dir(path), 0755)
+		if err := os.WriteFile(path, nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	find := func(cfg config.Config, query string) ([]string, bool) {
+		cfg.SearchDepth, cfg.MaxMatches, cfg.NonInteractive, cfg.NoIndex, cfg.NoFrecency = 3, 5, true, true, true
+		return finder.NewSearcher(&cfg).FindPath(filepath.Join(dir, query))
+	}
+	handler := filepath.Join(dir, "src", "handler.go")
+	handlerTest := filepath.Join(dir, "src", "handler_test.go")
+
+	if got, ok := find(config.Config{}, "handlr"); ok {
+		t.Errorf("--strict resolved ambiguous handlr to %v", got)
+	}
+	if got, _ := find(config.Config{}, "util.go"); !slices.Equal(got, []string{filepath.Join(dir, "src", "util.go")}) {
+		t.Errorf("--strict resolved util.go, the only exact match, to %v", got)
+	}
+	if got, _ := find(config.Config{PickFirst: true}, "handlr"); !slices.Equal(got, []string{handler}) {
+		t.Errorf("--first resolved handlr to %v", got)
+	}
+	if got, _ := find(config.Config{Select: 2}, "handlr"); !slices.Equal(got, []string{handlerTest}) {
+		t.Errorf("--select 2 resolved handlr to %v", got)
+	}
+	if got, ok := find(config.Config{Select: 3}, "handlr"); ok {
+		t.Errorf("--select 3 resolved handlr, which has 2 candidates, to %v", got)
+	}
+}
+
+// TestCandidates checks that the candidates fcopy find prints are the ones
+// the prompt would offer, best first
+func TestCandidates(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"src/handler.go", "src/handler_test.go", "README.md"} {
+		path := filepath.Join(dir, name)
+		os.MkdirAll(filepath.Dir(path), 0755)
+		if err := os.WriteFile(path, nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	cfg := &config.Config{SearchDepth: 3, NoIndex: true, NoFrecency: true}
+	var paths []string
+	for _, m := range finder.NewSearcher(cfg).Candidates(filepath.Join(dir, "handler")) {
+		rel, _ := filepath.Rel(dir, m.Path)
+		paths = append(paths, filepath.ToSlash(rel))
+	}
+	if want := []string{"src/handler.go", "src/handler_test.go"}; !slices.Equal(paths, want) {
+		t.Errorf("candidates for handler are %q, want %q", paths, want)
+	}
+}
+
+// TestFindWithoutTerminal checks that a good enough best match is taken
+// rather than prompted for when stdin isn't a terminal, and that ambiguous
+// queries give up instead of waiting for an answer
+func TestFindWithoutTerminal(t *testing.T) {
+	if isatty.IsTerminal(os.Stdin.Fd()) {
+		t.Skip("stdin is a terminal")
+	}
+	dir := t.TempDir()
+	for _, name := range []string{"src/handler.go", "src/handler_test.go"} {
+		path := filepath.Join(dir, name)
+		os.MkdirAll(filepath.Dir(path), 0755)
+		if err := os.WriteFile(path, nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	cfg := &config.Config{SearchDepth: 3, MaxMatches: 5, NoIndex: true, NoFrecency: true}
+	if got, ok := finder.NewSearcher(cfg).FindPath(filepath.Join(dir, "handlr")); ok {
+		t.Errorf("ambiguous handlr resolved to %v", got)
+	}
+	want := []string{filepath.Join(dir, "src", "handler.go")}
+	if got, _ := finder.NewSearcher(cfg).FindPath(filepath.Join(dir, "handler.g")); !slices.Equal(got, want) {
+		t.Errorf("handler.g resolved to %v, want %v", got, want)
+	}
+}
+
+// TestFindInContents checks that with SearchContent a name no file is named
+// after finds the file defining it first
+func TestFindInContents(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"pkg/limits.go": "package pkg\n\ntype RateLimiter struct{}\n",
+		"pkg/server.go": "package pkg\n\nvar limiter RateLimiter\n",
+		"pkg/other.go":  "package pkg\n\nvar ratelimiter int\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		os.MkdirAll(filepath.Dir(path), 0755)
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	cfg := &config.Config{SearchDepth: 3, NoIndex: true, NoFrecency: true}
+	if matches := finder.NewSearcher(cfg).Candidates(filepath.Join(dir, "RateLimiter")); len(matches) != 0 {
+		t.Errorf("without SearchContent RateLimiter matches %+v", matches)
+	}
+
+	cfg.SearchContent = true
+	var paths []string
+	for _, m := range finder.NewSearcher(cfg).Candidates(filepath.Join(dir, "RateLimiter")) {
+		rel, _ := filepath.Rel(dir, m.Path)
+		paths = append(paths, filepath.ToSlash(rel))
+	}
+	if want := []string{"pkg/limits.go", "pkg/server.go"}; !slices.Equal(paths, want) {
+		t.Errorf("RateLimiter matches %q, want %q", paths, want)
+	}
+}
+
+// TestFindFromProjectRoot checks that bare names are looked for from the
+// project root, whichever directory of the project fcopy runs in
+func TestFindFromProjectRoot(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"go.mod", "internal/config/config.go", "cmd/tool/main.go"} {
+		path := filepath.Join(dir, name)
+		os.MkdirAll(filepath.Dir(path), 0755)
+		if err := os.WriteFile(path, nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	t.Chdir(filepath.Join(dir, "cmd", "tool"))
+
+	cfg := &config.Config{SearchDepth: 5, NoIndex: true, NoFrecency: true}
+	matches := finder.NewSearcher(cfg).Candidates("config.go")
+	if want := filepath.Join("..", "..", "internal", "config", "config.go"); len(matches) == 0 || matches[0].Path != want {
+		t.Errorf("config.go from cmd/tool matches %+v, want %s first", matches, want)
+	}
+
+	cfg.NoRoot = true
+	for _, m := range finder.NewSearcher(cfg).Candidates("config.go") {
+		if m.Name == "config.go" {
+			t.Errorf("with NoRoot config.go was found outside cmd/tool: %s", m.Path)
+		}
+	}
+}
+
+// TestFindRestrictions checks that --only-files, --only-dirs and
+// --find-exclude narrow fuzzy matches
+func TestFindRestrictions(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"api/routes.go", "web/api/client.ts", "web/api.ts", "docs/api.md"} {
+		path := filepath.Join(dir, name)
+		os.MkdirAll(filepath.Dir(path), 0755)
+		if err := os.WriteFile(path, nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	find := func(cfg *config.Config) []string {
+		cfg.SearchDepth = 3
+		var paths []string
+		for _, m := range finder.FindRecursiveMatches(dir, "api", 0, cfg) {
+			if m.MatchType == "exact" || m.MatchType == "substring" {
+				rel, _ := filepath.Rel(dir, m.Path)
+				paths = append(paths, filepath.ToSlash(rel))
+			}
+		}
+		slices.Sort(paths)
+		return paths
+	}
+
+	if got, want := find(&config.Config{OnlyDirs: true}), []string{"api", "web/api"}; !slices.Equal(got, want) {
+		t.Errorf("--only-dirs matches %q, want %q", got, want)
+	}
+	if got, want := find(&config.Config{OnlyFiles: true}), []string{"docs/api.md", "web/api.ts"}; !slices.Equal(got, want) {
+		t.Errorf("--only-files matches %q, want %q", got, want)
+	}
+	exclude := config.RepeatedFlag{"web/", "*.md", "!docs/api.md"}
+	if got, want := find(&config.Config{FindExclude: exclude}), []string{"api", "docs/api.md"}; !slices.Equal(got, want) {
+		t.Errorf("--find-exclude matches %q, want %q", got, want)
+	}
+}
+
+// TestFindDirectoryAboveContents checks that the files of a directory
+// matching the query don't outrank the directory itself
+func TestFindDirectoryAboveContents(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"processor/binary.go", "processor/lines.go"} {
+		path := filepath.Join(dir, name)
+		os.MkdirAll(filepath.Dir(path), 0755)
+		if err := os.WriteFile(path, nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	matches := finder.FindRecursiveMatches(dir, "proc", 0, &config.Config{SearchDepth: 3})
+	if len(matches) == 0 || matches[0].Name != "processor" {
+		t.Errorf("proc matched %v first, want the processor directory", matches)
+	}
+}
+
+// TestFindTieBreakByGitActivity checks that of two equally good matches the
+// one changed by a more recent commit comes first
+func TestFindTieBreakByGitActivity(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git isn't installed")
+	}
+	dir := t.TempDir()
+	git := func(date string, args ...string) {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		cmd.Env = append(os.Environ(), "GIT_AUTHOR_DATE="+date, "GIT_COMMITTER_DATE="+date,
+			"GIT_AUTHOR_NAME=t", "GIT_AUTHOR_EMAIL=t@example.com", "GIT_COMMITTER_NAME=t", "GIT_COMMITTER_EMAIL=t@example.com")
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+	}
+	write := func(name, content string) {
+		path := filepath.Join(dir, name)
+		os.MkdirAll(filepath.Dir(path), 0755)
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	git("", "init", "-q")
+	write("legacy/routes.go", "package legacy\n")
+	write("web/routes.go", "package web\n")
+	git("2020-01-01T00:00:00Z", "add", ".")
+	git("2020-01-01T00:00:00Z", "commit", "-qm", "initial")
+	write("web/routes.go", "package web\n\n// Routes\n")
+	git("2024-01-01T00:00:00Z", "commit", "-qam", "update")
+
+	first := func(cfg *config.Config) string {
+		cfg.SearchDepth, cfg.MaxMatches, cfg.NoFrecency, cfg.NoIndex, cfg.NoRoot = 3, 10, true, true, true
+		matches := finder.NewSearcher(cfg).Candidates(filepath.Join(dir, "routes.go"))
+		if len(matches) != 2 {
+			t.Fatalf("routes.go matched %v, want both files", matches)
+		}
+		rel, _ := filepath.Rel(dir, matches[0].Path)
+		return filepath.ToSlash(rel)
+	}
+	if got := first(&config.Config{}); got != "web/routes.go" {
+		t.Errorf("routes.go matched %s first, want the recently changed web/routes.go", got)
+	}
+	if got := first(&config.Config{NoGitActivity: true}); got != "legacy/routes.go" {
+		t.Errorf("with --no-git-activity routes.go matched %s first, want legacy/routes.go by name", got)
+	}
+}
+
+// TestFindCase checks --case: smart case only tells README from readme when
+// the query has upper case letters
+func TestFindCase(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"README.md", "docs/readme.txt"} {
+		path := filepath.Join(dir, name)
+		os.MkdirAll(filepath.Dir(path), 0755)
+		if err := os.WriteFile(path, nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	names := func(query, mode string) []string {
+		var names []string
+		for _, m := range finder.FindRecursiveMatches(dir, query, 0, &config.Config{SearchDepth: 3, Case: mode}) {
+			names = append(names, m.Name)
+		}
+		slices.Sort(names)
+		return names
+	}
+
+	both := []string{"README.md", "readme.txt"}
+	for _, c := range []struct {
+		query, mode string
+		want        []string
+	}{
+		{"README", "smart", []string{"README.md"}},
+		{"readme", "smart", both},
+		{"README", "insensitive", both},
+		{"readme", "sensitive", []string{"readme.txt"}},
+	} {
+		if got := names(c.query, c.mode); !slices.Equal(got, c.want) {
+			t.Errorf("%s with --case %s matched %q, want %q", c.query, c.mode, got, c.want)
+		}
+	}
+}
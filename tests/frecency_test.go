@@ -0,0 +1,120 @@
+package tests
+
+import (
+	"fcopy/internal/config"
+	"fcopy/internal/finder"
+	"fcopy/internal/frecency"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+)
+
+// TestFrecencyScore checks that picks count more the more recent they are
+// and that old ranks are aged away
+func TestFrecencyScore(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	t.Setenv("HOME", t.TempDir())
+	root := t.TempDir()
+	h, err := frecency.Open(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Now()
+	path := filepath.Join(root, "main.go")
+	h.Record(path, now.Add(-48*time.Hour))
+	h.Record(path, now.Add(-48*time.Hour))
+	if got := h.Score(path, now); got != 1 {
+		t.Errorf("two picks two days ago score %v", got)
+	}
+	h.Record(path, now)
+	if got := h.Score(path, now); got != 12 {
+		t.Errorf("three picks, the last just now, score %v", got)
+	}
+	if got := h.Score(filepath.Join(root, "other.go"), now); got != 0 {
+		t.Errorf("a path never picked scores %v", got)
+	}
+
+	for i := 0; i < frecency.MaxTotal; i++ {
+		h.Record(filepath.Join(root, "busy.go"), now)
+	}
+	if len(h.Visits) != 2 || h.Visits["busy.go"].Rank >= frecency.MaxTotal {
+		t.Errorf("ranks weren't aged: %+v", h.Visits["busy.go"])
+	}
+}
+
+// TestFrecencyAutoSelect checks that a habitual pick becomes the
+// auto-selected match, even over an exact one
+func TestFrecencyAutoSelect(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	t.Setenv("HOME", t.TempDir())
+	dir := t.TempDir()
+	for _, name := range []string{"go.mod", "internal/config/config.go", "config.json"} {
+		path := filepath.Join(dir, name)
+		os.MkdirAll(filepath.Dir(path), 0755)
+		if err := os.WriteFile(path, nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	cfg := &config.Config{SearchDepth: 5, MaxMatches: 5, AutoSelect: true, NoIndex: true}
+	query := filepath.Join(dir, "config")
+
+	h, _ := frecency.Open(dir)
+	want := filepath.Join(dir, "internal", "config", "config.go")
+	for i := 0; i < 3; i++ {
+		h.Record(want, time.Now())
+	}
+	h.Save()
+
+	cfg.NoFrecency = true
+	if got, _ := finder.NewSearcher(cfg).FindPath(query); !slices.Equal(got, []string{filepath.Join(dir, "internal", "config")}) {
+		t.Errorf("with --no-frecency %s was picked", got)
+	}
+	cfg.NoFrecency = false
+	if got, _ := finder.NewSearcher(cfg).FindPath(query); !slices.Equal(got, []string{want}) {
+		t.Errorf("with history %s was picked, want %s", got, want)
+	}
+	if h, _ = frecency.Open(dir); h.Visits["internal/config/config.go"].Rank != 4 {
+		t.Errorf("the auto-selected pick wasn't recorded: %+v", h.Visits)
+	}
+}
+
+// TestQueryCache checks that a query resolves to the paths picked for it
+// before without searching again, unless the cache is bypassed
+func TestQueryCache(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	t.Setenv("HOME", t.TempDir())
+	dir := t.TempDir()
+	for _, name := range []string{"go.mod", "src/handler.go", "src/handler_test.go"} {
+		path := filepath.Join(dir, name)
+		os.MkdirAll(filepath.Dir(path), 0755)
+		if err := os.WriteFile(path, nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	query := filepath.Join(dir, "hndlr")
+	want := []string{filepath.Join(dir, "src", "handler_test.go")}
+
+	cfg := &config.Config{SearchDepth: 3, MaxMatches: 5, NoIndex: true, NonInteractive: true, Select: 2}
+	if got, _ := finder.NewSearcher(cfg).FindPath(query); !slices.Equal(got, want) {
+		t.Fatalf("--select 2 picked %v, want %v", got, want)
+	}
+
+	// Strict on its own would give up on the ambiguous query
+	cfg.Select = 0
+	if got, _ := finder.NewSearcher(cfg).FindPath(query); !slices.Equal(got, want) {
+		t.Errorf("the second run resolved to %v, want %v as before", got, want)
+	}
+	cfg.NoQueryCache = true
+	if got, ok := finder.NewSearcher(cfg).FindPath(query); ok {
+		t.Errorf("with NoQueryCache the ambiguous query resolved to %v", got)
+	}
+
+	cfg.NoQueryCache = false
+	os.Remove(want[0])
+	if got, ok := finder.NewSearcher(cfg).FindPath(query); ok && slices.Equal(got, want) {
+		t.Errorf("a removed pick was reused")
+	}
+}
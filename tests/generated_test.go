@@ -0,0 +1,57 @@
+package tests
+
+import (
+	"fcopy/internal/config"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+// TestGeneratedFiles checks that files marked linguist-generated in
+// .gitattributes, in the root or a deeper directory, and files with a
+// generated-code header are skipped while walking, unless named explicitly
+// or --include-generated is set
+func TestGeneratedFiles(t *testing.T) {
+	dir := cliDir(t, map[string]string{
+		".gitattributes":           "*.pb.go linguist-generated\nmocks/** linguist-generated=true\nmocks/fake.go -linguist-generated\n",
+		"api/service.pb.go":        "package api\n",
+		"api/service.go":           "package api\n",
+		"api/.gitattributes":       "service.go linguist-generated\n",
+		"mocks/store.go":           "package mocks\n",
+		"mocks/fake.go":            "package mocks\n",
+		"internal/enum_string.go":  "// Code generated by \"stringer -type=Kind\"; DO NOT EDIT.\n\npackage internal\n",
+		"internal/handwritten.go":  "package internal\n\n// Not generated; says DO NOT EDIT only in passing.\n",
+		"internal/types.pb.go.txt": "not matched by *.pb.go\n",
+	})
+	if err := os.Mkdir(filepath.Join(dir, ".git"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := &config.Config{MaxFileSize: 1024 * 1024, Workers: 2}
+	kept, skipped := collectFiles(t, cfg, dir, dir)
+	want := []string{"internal/handwritten.go", "internal/types.pb.go.txt", "mocks/fake.go"}
+	if !slices.Equal(kept, want) {
+		t.Errorf("Expected %v to be kept, got %v", want, kept)
+	}
+	for _, path := range []string{"api/service.pb.go", "api/service.go", "mocks/store.go"} {
+		if reason := skipped[path]; reason != "marked linguist-generated in .gitattributes" {
+			t.Errorf("Expected %s to be skipped by .gitattributes, got %q", path, reason)
+		}
+	}
+	if reason := skipped["internal/enum_string.go"]; reason != "generated file" {
+		t.Errorf("Expected internal/enum_string.go to be skipped by its header, got %q", reason)
+	}
+
+	// Asked for by name, generated files are copied anyway
+	explicit := []string{filepath.Join(dir, "api", "service.pb.go"), filepath.Join(dir, "internal", "enum_string.go")}
+	if kept, _ := collectFiles(t, cfg, dir, explicit...); !slices.Equal(kept, []string{"api/service.pb.go", "internal/enum_string.go"}) {
+		t.Errorf("Expected the files given explicitly to be kept, got %v", kept)
+	}
+
+	cfg.IncludeGenerated = true
+	kept, _ = collectFiles(t, cfg, dir, dir)
+	if len(kept) != 7 {
+		t.Errorf("Expected all 7 files with --include-generated, got %v", kept)
+	}
+}
@@ -0,0 +1,70 @@
+package tests
+
+import (
+	"fcopy/internal/config"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+// TestGitOnly checks that --git-only copies exactly the files git tracks
+// under a directory, in place of the built-in ignore lists, leaving out
+// untracked, ignored and deleted files
+func TestGitOnly(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git isn't installed")
+	}
+	dir := cliDir(t, map[string]string{
+		".gitignore":         "*.env\n",
+		"main.go":            "package main\n",
+		"main_test.go":       "package main\n",
+		"dist/bundle.js":     "console.log(1)\n",
+		"internal/x/x.go":    "package x\n",
+		"internal/x/gone.go": "package x\n",
+	})
+	git := func(args ...string) {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		cmd.Env = append(os.Environ(), "GIT_AUTHOR_NAME=t", "GIT_AUTHOR_EMAIL=t@example.com",
+			"GIT_COMMITTER_NAME=t", "GIT_COMMITTER_EMAIL=t@example.com")
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+	}
+	git("init", "-q")
+	git("add", ".")
+	git("commit", "-qm", "initial")
+	os.Remove(filepath.Join(dir, "internal", "x", "gone.go"))
+	os.WriteFile(filepath.Join(dir, "notes.txt"), []byte("untracked\n"), 0644)
+	os.WriteFile(filepath.Join(dir, "secret.env"), []byte("TOKEN=x\n"), 0644)
+
+	testCases := []struct {
+		name  string
+		setup func(cfg *config.Config)
+		path  string
+		want  []string
+	}{
+		{"walk", nil, dir, []string{"internal/x/x.go", "main.go", "main_test.go", "notes.txt", "secret.env"}},
+		{"git only", func(cfg *config.Config) { cfg.GitOnly = true }, dir,
+			[]string{".gitignore", "dist/bundle.js", "internal/x/x.go", "main.go", "main_test.go"}},
+		{"git only with no tests", func(cfg *config.Config) { cfg.GitOnly, cfg.NoTests = true, true }, dir,
+			[]string{".gitignore", "dist/bundle.js", "internal/x/x.go", "main.go"}},
+		{"git only in a subdirectory", func(cfg *config.Config) { cfg.GitOnly = true }, filepath.Join(dir, "internal"),
+			[]string{"internal/x/x.go"}},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg := &config.Config{MaxFileSize: 1024 * 1024, Workers: 2}
+			if tc.setup != nil {
+				tc.setup(cfg)
+			}
+			got, _ := collectFiles(t, cfg, dir, tc.path)
+			slices.Sort(got)
+			if !slices.Equal(got, tc.want) {
+				t.Errorf("Expected %v, got %v", tc.want, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,49 @@
+package tests
+
+import (
+	"fcopy/internal/gitutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestDiffWithCwd checks that --changed with --format diff finds the diffs
+// of files listed under --cwd, whose paths are relative to the current
+// directory rather than to the repository
+func TestDiffWithCwd(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git isn't installed")
+	}
+	dir := t.TempDir()
+	repo := filepath.Join(dir, "repo")
+	os.MkdirAll(filepath.Join(repo, "sub"), 0755)
+	git := func(args ...string) {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = repo
+		cmd.Env = append(os.Environ(), "GIT_AUTHOR_NAME=t", "GIT_AUTHOR_EMAIL=t@example.com",
+			"GIT_COMMITTER_NAME=t", "GIT_COMMITTER_EMAIL=t@example.com")
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+	}
+	file := filepath.Join(repo, "sub", "main.go")
+	git("init", "-q")
+	os.WriteFile(file, []byte("package main\n"), 0644)
+	git("add", ".")
+	git("commit", "-qm", "initial")
+	os.WriteFile(file, []byte("package main\n\nfunc main() {}\n"), 0644)
+
+	// As with --cwd repo/sub, run from the parent of the repository
+	t.Chdir(dir)
+	cwd := filepath.Join("repo", "sub")
+	changed, err := gitutil.ChangedFiles(cwd, "HEAD")
+	if err != nil || len(changed) != 1 {
+		t.Fatalf("got changed files %q, %v", changed, err)
+	}
+	patch, err := gitutil.Diff(cwd, "HEAD", filepath.Join(cwd, changed[0]))
+	if err != nil || !strings.Contains(patch, "+func main() {}") {
+		t.Errorf("got patch %q, %v", patch, err)
+	}
+}
@@ -0,0 +1,226 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"fcopy/internal/collector"
+	"fcopy/internal/config"
+	"fcopy/internal/diff"
+	"fcopy/internal/output"
+	"fcopy/internal/processor"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// Set UPDATE_GOLDEN=1 to rewrite the golden files from the current output
+var updateGolden = os.Getenv("UPDATE_GOLDEN") != ""
+
+// goldenTree is the fixture tree the golden tests run over
+var goldenTree = filepath.Join("testdata", "golden", "tree")
+
+// goldenDir holds the golden files; it is absolute so that tests which
+// change directory can still find them
+var goldenDir, _ = filepath.Abs(filepath.Join("testdata", "golden"))
+
+// TestGoldenOutputs runs the engine over the fixture tree and compares the
+// rendered output of every formatter byte for byte with its golden file
+func TestGoldenOutputs(t *testing.T) {
+	testCases := []struct {
+		name  string
+		paths []string
+		setup func(cfg *config.Config)
+	}{
+		{"plain", []string{goldenTree}, nil},
+		{"plain_argument_order", []string{
+			filepath.Join(goldenTree, "main.go"),
+			filepath.Join(goldenTree, "docs"),
+		}, nil},
+		{"plain_dedupe_content", []string{goldenTree}, func(cfg *config.Config) {
+			cfg.DedupeContent = true
+		}},
+		{"plain_diff_similar", []string{goldenTree}, func(cfg *config.Config) {
+			cfg.DiffSimilar = true
+		}},
+		{"plain_no_transcoding", []string{filepath.Join(goldenTree, "docs")}, func(cfg *config.Config) {
+			cfg.Encoding = "utf-8"
+		}},
+		{"plain_normalize_eol", []string{filepath.Join(goldenTree, "docs")}, func(cfg *config.Config) {
+			cfg.NormalizeEOL = true
+			cfg.ExpandTabs = 4
+		}},
+		{"plain_notebook_markdown", []string{filepath.Join(goldenTree, "notebooks")}, func(cfg *config.Config) {
+			cfg.NotebookMarkdown = true
+		}},
+		{"plain_max_tokens", []string{goldenTree}, func(cfg *config.Config) {
+			cfg.MaxTokens = 150
+		}},
+		{"plain_max_total_size", []string{goldenTree}, func(cfg *config.Config) {
+			cfg.MaxTotalSize = 400
+		}},
+		{"jsonl", []string{goldenTree}, func(cfg *config.Config) {
+			cfg.Format = "jsonl"
+		}},
+		{"jsonl_sample", []string{goldenTree}, func(cfg *config.Config) {
+			cfg.Format = "jsonl"
+			cfg.Sample = 3
+			cfg.PerDirQuota = 1
+			cfg.Seed = 42
+		}},
+		{"bundle", []string{goldenTree}, func(cfg *config.Config) {
+			cfg.Format = "bundle"
+		}},
+		{"plain_outline", []string{goldenTree}, func(cfg *config.Config) {
+			cfg.Outline = true
+		}},
+		{"repomix", []string{goldenTree}, func(cfg *config.Config) {
+			cfg.Format = "repomix"
+		}},
+		{"cat", []string{goldenTree}, func(cfg *config.Config) {
+			cfg.Format = "cat"
+		}},
+		{"cat_nul", []string{goldenTree}, func(cfg *config.Config) {
+			cfg.Format = "cat"
+			cfg.Separator = `\0`
+		}},
+		{"cat_dedupe_content", []string{goldenTree}, func(cfg *config.Config) {
+			cfg.Format = "cat"
+			cfg.DedupeContent = true
+		}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg := &config.Config{
+				MaxFileSize: 1024 * 1024,
+				Timeout:     5 * time.Second,
+				Workers:     2,
+				Format:      "plain",
+				Separator:   `\n`,
+				Similarity:  0.9,
+			}
+			if tc.setup != nil {
+				tc.setup(cfg)
+			}
+			assertGolden(t, tc.name, runEngine(t, cfg, tc.paths...))
+		})
+	}
+}
+
+// TestGoldenDiff runs --format diff over a repository with a modified file,
+// a changed file without a diff and an untouched file listed explicitly, and
+// compares the output with its golden file
+func TestGoldenDiff(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git isn't installed")
+	}
+	dir := t.TempDir()
+	git := func(args ...string) {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		cmd.Env = append(os.Environ(), "GIT_AUTHOR_NAME=t", "GIT_AUTHOR_EMAIL=t@example.com",
+			"GIT_COMMITTER_NAME=t", "GIT_COMMITTER_EMAIL=t@example.com")
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+	}
+	write := func(name, content string) {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	git("init", "-q")
+	write("main.go", "package main\n\nfunc main() {\n\tprintln(\"hello\")\n}\n")
+	write("README.md", "# Demo\n")
+	git("add", ".")
+	git("commit", "-qm", "initial")
+	write("main.go", "package main\n\nfunc main() {\n\tprintln(\"hello, world\")\n}\n")
+	write("notes.txt", "not committed yet\n")
+
+	// Relative paths keep the headers of the patches free of the temp dir
+	t.Chdir(dir)
+	cfg := &config.Config{
+		MaxFileSize: 1024 * 1024,
+		Timeout:     5 * time.Second,
+		Workers:     2,
+		Format:      "diff",
+		Separator:   `\n`,
+		Changed:     "HEAD",
+	}
+	paths := []string{"main.go", "notes.txt", "README.md"}
+	origins := []processor.Origin{
+		{Arg: "main.go", Rule: processor.RuleChanged},
+		{Arg: "notes.txt", Rule: processor.RuleChanged},
+		{Arg: "README.md", Rule: processor.RuleExplicit},
+	}
+	assertGolden(t, "diff", render(t, cfg, paths, origins))
+}
+
+// runEngine processes paths like the CLI does and returns the rendered output
+func runEngine(t *testing.T, cfg *config.Config, paths ...string) []byte {
+	t.Helper()
+
+	origins := make([]processor.Origin, len(paths))
+	for i, path := range paths {
+		origins[i] = processor.Origin{Arg: path, Rule: processor.RuleExplicit}
+	}
+	return render(t, cfg, paths, origins)
+}
+
+// render collects paths with their origins through the same collector steps
+// as runCopy, including the diffs of --format diff, and formats the result
+func render(t *testing.T, cfg *config.Config, paths []string, origins []processor.Origin) []byte {
+	t.Helper()
+
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.Timeout)
+	defer cancel()
+
+	tracker := &processor.Tracker{}
+	files := collector.Collect(ctx, paths, origins, cfg, tracker)
+	if cfg.Format == "diff" {
+		collector.Diffs(files, ".", cfg.Changed, tracker)
+	}
+	if errors := tracker.Errors.Load(); errors != 0 {
+		t.Fatalf("Expected 0 errors, got %d", errors)
+	}
+
+	files = collector.Finish(files, cfg)
+	kept, omitted := collector.Budget(files, cfg.MaxTotalSize, collector.Size)
+	kept, overTokens := collector.Budget(kept, int64(cfg.MaxTokens), collector.Tokens)
+	var out bytes.Buffer
+	if err := output.Write(&out, kept, cfg); err != nil {
+		t.Fatalf("Failed to format output: %v", err)
+	}
+	if err := output.WriteOmitted(&out, omitted, "--max-total-size", cfg); err != nil {
+		t.Fatalf("Failed to format output: %v", err)
+	}
+	if err := output.WriteOmitted(&out, overTokens, "--max-tokens", cfg); err != nil {
+		t.Fatalf("Failed to format output: %v", err)
+	}
+	return out.Bytes()
+}
+
+// assertGolden compares got with testdata/golden/<name>.golden, or rewrites
+// the golden file when UPDATE_GOLDEN is set
+func assertGolden(t *testing.T, name string, got []byte) {
+	t.Helper()
+
+	path := filepath.Join(goldenDir, name+".golden")
+	if updateGolden {
+		if err := os.WriteFile(path, got, 0644); err != nil {
+			t.Fatalf("Failed to update golden file %s: %v", path, err)
+		}
+		return
+	}
+
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Failed to read golden file %s (run with UPDATE_GOLDEN=1 to create it): %v", path, err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("Output differs from %s (run with UPDATE_GOLDEN=1 to accept):\n%s",
+			path, diff.Unified("want", "got", string(want), string(got), 3))
+	}
+}
@@ -0,0 +1,47 @@
+package tests
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestHexdumpBinaries checks that --hexdump-binaries copies binary files
+// found while walking as an xxd-style dump cut at --hexdump-limit, and that
+// they are left out without it
+func TestHexdumpBinaries(t *testing.T) {
+	dir := cliDir(t, map[string]string{
+		"main.go":      "package main\n",
+		"img/logo.png": "\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR\x00\x00\x00\x10",
+		"img/tiny.gif": "GIF89a",
+	})
+
+	out, code := runFcopy(t, dir, "", "--output", "out.txt", "--hexdump-binaries", "--hexdump-limit", "16", ".")
+	if code != 0 {
+		t.Fatalf("got exit code %d:\n%s", code, out)
+	}
+	copied, err := os.ReadFile(filepath.Join(dir, "out.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"-- img/logo.png (hex dump of first 16 of 20 bytes) --\n" +
+			"00000000: 8950 4e47 0d0a 1a0a 0000 000d 4948 4452  .PNG........IHDR\n",
+		"-- img/tiny.gif (hex dump, 6 bytes) --\n" +
+			"00000000: 4749 4638 3961                           GIF89a\n",
+		"-- main.go --\npackage main\n",
+	} {
+		if !strings.Contains(string(copied), want) {
+			t.Errorf("Expected the output to contain %q, got:\n%s", want, copied)
+		}
+	}
+
+	out, code = runFcopy(t, dir, "", "--output", "out.txt", ".")
+	if code != 0 {
+		t.Fatalf("got exit code %d:\n%s", code, out)
+	}
+	if copied, _ := os.ReadFile(filepath.Join(dir, "out.txt")); strings.Contains(string(copied), "img/") {
+		t.Errorf("Expected binary files to be left out without --hexdump-binaries, got:\n%s", copied)
+	}
+}
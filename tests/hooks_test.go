@@ -0,0 +1,132 @@
+package tests
+
+import (
+	"fcopy/internal/hooks"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestHooks checks that post-copy hooks get the bundle on stdin and in
+// FCOPY_BUNDLE, and that failing pre-copy hooks are reported
+func TestHooks(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("hooks run through sh here")
+	}
+	dir := t.TempDir()
+	bundle := filepath.Join(dir, "bundle.txt")
+	if err := os.WriteFile(bundle, []byte("-- a.go --\npackage a\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	out := filepath.Join(dir, "out.txt")
+
+	info := hooks.Info{Files: 1, BundlePath: bundle}
+	if err := hooks.Run(`cat > "`+out+`"; cat "$FCOPY_BUNDLE" >> "`+out+`"`, info, 5*time.Second); err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := os.ReadFile(out); string(data) != "-- a.go --\npackage a\n-- a.go --\npackage a\n" {
+		t.Errorf("hook wrote %q", data)
+	}
+
+	if err := hooks.RunPre("true", 5*time.Second); err != nil {
+		t.Errorf("succeeding pre-copy hook failed: %v", err)
+	}
+	if err := hooks.RunPre("echo not ready; exit 3", 5*time.Second); err == nil {
+		t.Errorf("failing pre-copy hook succeeded")
+	}
+}
+
+// TestPostCopyHooks runs fcopy with --post-copy and config file hooks and
+// checks what the hooks get, the order they run in, and that failing or
+// slow ones are reported without undoing the copy
+func TestPostCopyHooks(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("hooks run through sh here")
+	}
+	setup := func(t *testing.T, hooks string) string {
+		dir := cliDir(t, map[string]string{"main.go": "package main\n", "util.go": "package main\n"})
+		if hooks != "" {
+			config := filepath.Join(filepath.Dir(dir), "home", ".config", "fcopy", "config.yaml")
+			os.MkdirAll(filepath.Dir(config), 0755)
+			if err := os.WriteFile(config, []byte(hooks), 0644); err != nil {
+				t.Fatal(err)
+			}
+		}
+		return dir
+	}
+	read := func(t *testing.T, dir, name string) string {
+		data, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			t.Fatalf("Expected the hook to write %s: %v", name, err)
+		}
+		return string(data)
+	}
+
+	t.Run("environment", func(t *testing.T) {
+		dir := setup(t, "")
+		command := `cat > stdin.txt; cp "$FCOPY_BUNDLE" bundle.txt; cp "$FCOPY_MANIFEST" manifest.json; ` +
+			`echo "$FCOPY_BUNDLE $FCOPY_FILES $FCOPY_BYTES" > env.txt`
+		out, code := runFcopy(t, dir, "", "--output", "out.txt", "--post-copy", command, "main.go", "util.go")
+		if code != 0 {
+			t.Fatalf("got exit code %d:\n%s", code, out)
+		}
+		copied := read(t, dir, "out.txt")
+		if stdin, bundle := read(t, dir, "stdin.txt"), read(t, dir, "bundle.txt"); stdin != copied || bundle != copied {
+			t.Errorf("Expected the hook to get the output on stdin and in FCOPY_BUNDLE, got %q and %q", stdin, bundle)
+		}
+		if env, want := read(t, dir, "env.txt"), fmt.Sprintf("out.txt 2 %d\n", len(copied)); env != want {
+			t.Errorf("Expected the environment %q, got %q", want, env)
+		}
+		if manifest := read(t, dir, "manifest.json"); !strings.Contains(manifest, `"main.go"`) || !strings.Contains(manifest, `"util.go"`) {
+			t.Errorf("Expected the manifest to list both files, got %s", manifest)
+		}
+	})
+
+	t.Run("order and failures", func(t *testing.T) {
+		dir := setup(t, "hooks:\n  pre:\n    - echo pre >> order.txt\n  post:\n    - echo config >> order.txt\n")
+		out, code := runFcopy(t, dir, "", "--output", "out.txt",
+			"--post-copy", "echo flag >> order.txt", "--post-copy", "echo broken; exit 3", "main.go")
+		if code != 0 {
+			t.Fatalf("got exit code %d:\n%s", code, out)
+		}
+		if order := read(t, dir, "order.txt"); order != "pre\nflag\nconfig\n" {
+			t.Errorf("Expected pre, --post-copy and config hooks in that order, got %q", order)
+		}
+		if !strings.Contains(out, `Post-copy hook "echo broken; exit 3" failed`) || !strings.Contains(out, "broken") {
+			t.Errorf("Expected the failing hook to be reported with its output, got:\n%s", out)
+		}
+	})
+
+	t.Run("timeout", func(t *testing.T) {
+		dir := setup(t, "")
+		out, _ := runFcopy(t, dir, "", "--output", "out.txt", "--hook-timeout", "200ms", "--post-copy", "sleep 5", "main.go")
+		if !strings.Contains(out, "timed out after 200ms") {
+			t.Errorf("Expected the slow hook to time out, got:\n%s", out)
+		}
+	})
+
+	t.Run("failing pre-copy hook", func(t *testing.T) {
+		dir := setup(t, "hooks:\n  pre:\n    - exit 2\n")
+		out, code := runFcopy(t, dir, "", "--output", "out.txt", "--post-copy", "touch post.txt", "main.go")
+		if code != 1 || !strings.Contains(out, "nothing was copied") {
+			t.Errorf("Expected the failing pre-copy hook to stop the copy, got %d:\n%s", code, out)
+		}
+		for _, name := range []string{"out.txt", "post.txt"} {
+			if _, err := os.Stat(filepath.Join(dir, name)); err == nil {
+				t.Errorf("Expected no %s after the pre-copy hook failed", name)
+			}
+		}
+	})
+
+	t.Run("dry run", func(t *testing.T) {
+		dir := setup(t, "")
+		runFcopy(t, dir, "", "--dry-run", "--post-copy", "touch post.txt", "main.go")
+		if _, err := os.Stat(filepath.Join(dir, "post.txt")); err == nil {
+			t.Error("Expected hooks not to run with --dry-run")
+		}
+	})
+}
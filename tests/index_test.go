@@ -0,0 +1,62 @@
+package tests
+
+import (
+	"fcopy/internal/config"
+	"fcopy/internal/finder"
+	"fcopy/internal/index"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// TestIndexRefresh checks that the fuzzy search index only reads directories
+// that changed since it was saved, and forgets removed ones
+func TestIndexRefresh(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	t.Setenv("HOME", t.TempDir())
+	dir := t.TempDir()
+	for _, name := range []string{"go.mod", "pkg/a/a.go", "pkg/b/b.go"} {
+		path := filepath.Join(dir, name)
+		os.MkdirAll(filepath.Dir(path), 0755)
+		if err := os.WriteFile(path, nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	cfg := &config.Config{SearchDepth: 5, Workers: 2}
+
+	x, count, err := finder.BuildIndex(dir, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count != 6 || x.Reads() != 4 {
+		t.Fatalf("first build listed %d entries reading %d directories", count, x.Reads())
+	}
+
+	x, _, _ = finder.BuildIndex(dir, cfg)
+	if x.Reads() != 0 {
+		t.Errorf("unchanged tree read %d directories", x.Reads())
+	}
+
+	// Make sure the modification time of pkg visibly changes
+	time.Sleep(10 * time.Millisecond)
+	os.RemoveAll(filepath.Join(dir, "pkg", "b"))
+	os.WriteFile(filepath.Join(dir, "pkg", "c.go"), nil, 0644)
+	x, count, _ = finder.BuildIndex(dir, cfg)
+	if count != 5 || x.Reads() != 1 {
+		t.Errorf("refresh listed %d entries reading %d directories", count, x.Reads())
+	}
+	if _, ok := x.Dirs["pkg/b"]; ok {
+		t.Error("removed directory pkg/b is still indexed")
+	}
+
+	// Searches go through the saved index
+	reopened, _ := index.Open(dir)
+	if len(reopened.Dirs) != 3 {
+		t.Errorf("saved index has %d directories", len(reopened.Dirs))
+	}
+	matches := finder.NewSearcher(cfg).Matches(dir, "c.go")
+	if len(matches) == 0 || matches[0].Name != "c.go" {
+		t.Errorf("matches for c.go are %+v", matches)
+	}
+}
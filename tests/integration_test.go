@@ -9,7 +9,6 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
-	"sync/atomic"
 	"testing"
 	"time"
 )
@@ -106,11 +105,10 @@ func TestIntegration(t *testing.T) {
 		defer cancel()
 
 		results := make(chan processor.FileContent, 10)
-		processed := &atomic.Int64{}
-		errors := &atomic.Int64{}
+		tracker := &processor.Tracker{}
 
 		// Process the entire directory
-		go processor.ProcessDirectory(ctx, tempDir, cfg, results, processed, errors)
+		go processor.ProcessDirectory(ctx, tempDir, cfg, results, tracker)
 
 		// Count and verify results
 		foundFiles := make(map[string]bool)
@@ -152,11 +150,11 @@ func TestIntegration(t *testing.T) {
 		}
 
 		// Check final counters
-		if processed.Load() != int64(expectedCount) {
-			t.Errorf("Expected %d processed files, got %d", expectedCount, processed.Load())
+		if tracker.Processed.Load() != int64(expectedCount) {
+			t.Errorf("Expected %d processed files, got %d", expectedCount, tracker.Processed.Load())
 		}
-		if errors.Load() != 0 {
-			t.Errorf("Expected 0 errors, got %d", errors.Load())
+		if tracker.Errors.Load() != 0 {
+			t.Errorf("Expected 0 errors, got %d", tracker.Errors.Load())
 		}
 	})
 }
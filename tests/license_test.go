@@ -0,0 +1,57 @@
+package tests
+
+import (
+	"fcopy/internal/processor"
+	"testing"
+)
+
+// TestStripLicense checks which leading comment blocks --strip-license
+// removes and which it must leave alone
+func TestStripLicense(t *testing.T) {
+	testCases := []struct {
+		name    string
+		content string
+		want    string
+		removed int
+	}{
+		{
+			"go line comments",
+			"// Copyright 2024 Acme Corp.\n// Licensed under the Apache License, Version 2.0.\n\npackage main\n",
+			"package main\n", 3,
+		},
+		{
+			"block comment",
+			"/*\n * Copyright (c) Acme\n * All rights reserved.\n */\n\nimport x from 'x'\n",
+			"import x from 'x'\n", 5,
+		},
+		{
+			"shebang kept",
+			"#!/usr/bin/env python3\n# SPDX-License-Identifier: MIT\n\nprint('hi')\n",
+			"#!/usr/bin/env python3\nprint('hi')\n", 2,
+		},
+		{
+			"build constraint kept",
+			"// Copyright 2024 Acme\n\n//go:build linux\n\npackage main\n",
+			"//go:build linux\n\npackage main\n", 2,
+		},
+		{
+			"package comment kept",
+			"// Package license checks that dependencies are licensed correctly.\npackage license\n",
+			"// Package license checks that dependencies are licensed correctly.\npackage license\n", 0,
+		},
+		{
+			"other comments kept",
+			"// main is the entry point\n\nfunc main() {}\n",
+			"// main is the entry point\n\nfunc main() {}\n", 0,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, removed := processor.StripLicense(tc.content)
+			if got != tc.want || removed != tc.removed {
+				t.Errorf("Expected %q with %d lines removed, got %q with %d", tc.want, tc.removed, got, removed)
+			}
+		})
+	}
+}
@@ -0,0 +1,55 @@
+package tests
+
+import (
+	"context"
+	"fcopy/internal/collector"
+	"fcopy/internal/config"
+	"fcopy/internal/processor"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+)
+
+// TestMaxFiles ensures --max-files counts only files that make it into the
+// output and keeps the first files found, however the workers race
+func TestMaxFiles(t *testing.T) {
+	dir := t.TempDir()
+	for i := 0; i < 20; i++ {
+		if err := os.WriteFile(filepath.Join(dir, fmt.Sprintf("a%02d.png", i)), []byte("x"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	var want []string
+	for i := 0; i < 8; i++ {
+		path := filepath.Join(dir, fmt.Sprintf("b%d.txt", i))
+		if err := os.WriteFile(path, []byte("text"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		want = append(want, path)
+	}
+	want = want[:5]
+
+	for run := 0; run < 10; run++ {
+		cfg := &config.Config{MaxFileSize: 1024 * 1024, Workers: 4, MaxFiles: 5}
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		tracker := &processor.Tracker{}
+		origins := []processor.Origin{{Arg: dir, Rule: processor.RuleExplicit}}
+		files := collector.Collect(ctx, []string{dir}, origins, cfg, tracker)
+		cancel()
+
+		collector.Sort(files)
+		var got []string
+		for _, f := range files {
+			got = append(got, f.Path)
+		}
+		if !slices.Equal(got, want) {
+			t.Fatalf("run %d: got %q, want %q", run, got, want)
+		}
+		if !tracker.LimitReached() || tracker.Processed.Load() != 5 || tracker.Errors.Load() != 0 {
+			t.Errorf("run %d: limit reached %v, %d processed, %d errors", run, tracker.LimitReached(), tracker.Processed.Load(), tracker.Errors.Load())
+		}
+	}
+}
@@ -0,0 +1,77 @@
+package tests
+
+import (
+	"context"
+	"fcopy/internal/config"
+	"fcopy/internal/processor"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestMaxLineLength checks that --max-line-length skips files found while
+// walking that have a longer line, and cuts such lines short, on a rune
+// boundary and with a marker, in files given explicitly
+func TestMaxLineLength(t *testing.T) {
+	blob := "data:image/png;base64," + strings.Repeat("QUFB", 30)
+	dir := cliDir(t, map[string]string{
+		"short.go":     "package main\n\nfunc main() {}\n",
+		"embed.go":     "package main\n\nvar logo = \"" + blob + "\"\n",
+		"at-limit.txt": strings.Repeat("x", 40) + "\n",
+	})
+
+	cfg := &config.Config{MaxFileSize: 1024 * 1024, Workers: 2, MaxLineLength: 40}
+	kept, skipped := collectFiles(t, cfg, dir, dir)
+	if want := []string{"at-limit.txt", "short.go"}; !slices.Equal(kept, want) {
+		t.Errorf("Expected %v to be kept, got %v", want, kept)
+	}
+	if want := "line of 155 bytes exceeds --max-line-length 40"; skipped["embed.go"] != want {
+		t.Errorf("Expected embed.go to be skipped with %q, got %q", want, skipped["embed.go"])
+	}
+
+	cfg.MaxLineLength = 0
+	if kept, _ := collectFiles(t, cfg, dir, dir); len(kept) != 3 {
+		t.Errorf("Expected --max-line-length 0 to keep all files, got %v", kept)
+	}
+
+	testCases := []struct {
+		name    string
+		content string
+		max     int
+		want    string
+		note    string
+	}{
+		{"one line", "short\n" + strings.Repeat("a", 12) + "\nend\n", 10,
+			"short\naaaaaaaaaa... (2 more characters)\nend\n", "1 line cut at 10 bytes"},
+		{"several lines", strings.Repeat("b", 11) + "\n" + strings.Repeat("c", 20), 10,
+			"bbbbbbbbbb... (1 more characters)\ncccccccccc... (10 more characters)", "2 lines cut at 10 bytes"},
+		{"rune boundary", "héllo wörld", 7, "héllo ... (5 more characters)", "1 line cut at 7 bytes"},
+		{"crlf", strings.Repeat("d", 12) + "\r\n", 11, "ddddddddddd... (2 more characters)\n", "1 line cut at 11 bytes"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "file.txt")
+			if err := os.WriteFile(path, []byte(tc.content), 0644); err != nil {
+				t.Fatal(err)
+			}
+			info, err := os.Stat(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			cfg := &config.Config{MaxFileSize: 1024 * 1024, MaxLineLength: tc.max}
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			results := make(chan processor.FileContent, 1)
+			if err := processor.ProcessSingleFile(ctx, path, info, processor.Origin{}, cfg, results); err != nil {
+				t.Fatalf("Failed to process file: %v", err)
+			}
+			result := <-results
+			if result.Content != tc.want || !slices.Equal(result.Notes, []string{tc.note}) {
+				t.Errorf("Expected %q with note %q, got %q with %v", tc.want, tc.note, result.Content, result.Notes)
+			}
+		})
+	}
+}
@@ -0,0 +1,44 @@
+package tests
+
+import (
+	"fcopy/internal/entrypoints"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestProjectMeta checks that --with-meta finds the manifests and README of
+// the project above the working directory, skipping a README that is too big
+func TestProjectMeta(t *testing.T) {
+	root := t.TempDir()
+	sub := filepath.Join(root, "internal", "app")
+	if err := os.MkdirAll(sub, 0755); err != nil {
+		t.Fatal(err)
+	}
+	for name, content := range map[string]string{
+		"go.mod":       "module example.com/app\n",
+		"package.json": "{}\n",
+		"README.md":    "# App\n",
+	} {
+		if err := os.WriteFile(filepath.Join(root, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var names []string
+	for _, m := range entrypoints.Meta(sub) {
+		names = append(names, filepath.Base(m.Path))
+	}
+	if strings.Join(names, " ") != "go.mod package.json README.md" {
+		t.Errorf("found %v", names)
+	}
+
+	big := strings.Repeat("x", entrypoints.MaxReadmeSize+1)
+	if err := os.WriteFile(filepath.Join(root, "README.md"), []byte(big), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if got := entrypoints.Meta(sub); len(got) != 2 {
+		t.Errorf("found %v with an oversized README", got)
+	}
+}
@@ -0,0 +1,77 @@
+package tests
+
+import (
+	"context"
+	"fcopy/internal/collector"
+	"fcopy/internal/config"
+	"fcopy/internal/processor"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+// collectFiles runs the collector over paths given as arguments, as runCopy
+// does, and returns the files kept, sorted, as paths relative to dir, and the
+// reasons files were skipped by the same relative paths
+func collectFiles(t *testing.T, cfg *config.Config, dir string, paths ...string) ([]string, map[string]string) {
+	t.Helper()
+
+	origins := make([]processor.Origin, len(paths))
+	for i, path := range paths {
+		origins[i] = processor.Origin{Arg: path, Rule: processor.RuleExplicit}
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	tracker := &processor.Tracker{}
+	files := collector.Collect(ctx, paths, origins, cfg, tracker)
+	if errors := tracker.Errors.Load(); errors != 0 {
+		t.Fatalf("Expected 0 errors, got %d", errors)
+	}
+
+	rel := func(path string) string {
+		r, err := filepath.Rel(dir, path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return filepath.ToSlash(r)
+	}
+	var kept []string
+	for _, f := range collector.Finish(files, cfg) {
+		kept = append(kept, rel(f.Path))
+	}
+	skipped := make(map[string]string)
+	for _, skip := range tracker.Skips() {
+		skipped[rel(skip.Path)] = skip.Reason
+	}
+	return kept, skipped
+}
+
+// TestMinifiedFiles checks that bundles found while walking are skipped by
+// their line lengths whatever their name, while small files, ordinary code
+// and bundles asked for by name are kept
+func TestMinifiedFiles(t *testing.T) {
+	statement := "var a=function(b){return b*2};"
+	dense := strings.Repeat(strings.Repeat(statement, 10)+"\n", 20)
+	ordinary := strings.Repeat("function double(b) {\n  return b * 2\n}\n\n", 100)
+	dir := cliDir(t, map[string]string{
+		"assets/main.js":   "/*! bundle */\n" + strings.Repeat(statement, 400) + "\n",
+		"assets/vendor.js": dense,
+		"assets/small.js":  strings.Repeat(statement, 30),
+		"src/app.js":       ordinary,
+		"src/long-tail.js": ordinary + strings.Repeat("x", len(ordinary)+10*1024) + "\n",
+		"assets/chunk.js":  "/*! chunk */\n" + strings.Repeat(statement, 400) + "\n",
+	})
+
+	cfg := &config.Config{MaxFileSize: 1024 * 1024, Workers: 2}
+	kept, skipped := collectFiles(t, cfg, dir, filepath.Join(dir, "assets", "chunk.js"), dir)
+	if want := []string{"assets/chunk.js", "assets/small.js", "src/app.js"}; !slices.Equal(kept, want) {
+		t.Errorf("Expected %v to be kept, got %v", want, kept)
+	}
+	for _, path := range []string{"assets/main.js", "assets/vendor.js", "src/long-tail.js"} {
+		if reason := skipped[path]; reason != "looks minified or bundled" {
+			t.Errorf("Expected %s to be skipped as minified, got %q", path, reason)
+		}
+	}
+}
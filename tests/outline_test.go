@@ -0,0 +1,85 @@
+package tests
+
+import (
+	"fcopy/internal/outline"
+	"slices"
+	"testing"
+)
+
+// TestGoOutline checks that --outline keeps declarations and doc comments
+// and drops function bodies, constants and variables
+func TestGoOutline(t *testing.T) {
+	src := `// Package shapes draws shapes
+package shapes
+
+import "math"
+
+const scale = 2
+
+var cache = map[string]float64{"unit": 1}
+
+// Circle is round
+type Circle struct {
+	R float64 // radius
+}
+
+// Area returns the area
+func (c Circle) Area() float64 {
+	return math.Pi * c.R * c.R * scale
+}
+
+func helper(x int) (int, error) {
+	if x < 0 {
+		return 0, nil
+	}
+	return x, nil
+}
+`
+	want := `// Package shapes draws shapes
+package shapes
+
+import "math"
+
+// Circle is round
+type Circle struct {
+	R float64 // radius
+}
+
+// Area returns the area
+func (c Circle) Area() float64 { ... }
+
+func helper(x int) (int, error) { ... }
+`
+	got, ok := outline.Outline("shapes.go", src)
+	if !ok || got != want {
+		t.Errorf("outline:\n%s\nwant:\n%s", got, want)
+	}
+
+	if _, ok := outline.Outline("broken.go", "package x\nfunc {"); ok {
+		t.Errorf("unparsable file was outlined")
+	}
+	if _, ok := outline.Outline("notes.txt", "text"); ok {
+		t.Errorf("unsupported language was outlined")
+	}
+}
+
+// TestFindGoSymbol checks that --symbol finds functions, methods by
+// qualified name and types together with their doc comments
+func TestFindGoSymbol(t *testing.T) {
+	src := "package p\n\n// T is a thing\ntype T struct{}\n\n// Run runs T\nfunc (t *T) Run() {\n}\n\nfunc Run() {}\n"
+	testCases := []struct {
+		name string
+		want []outline.Definition
+	}{
+		{"T", []outline.Definition{{Name: "T", Start: 3, End: 4}}},
+		{"Run", []outline.Definition{{Name: "T.Run", Start: 6, End: 8}, {Name: "Run", Start: 10, End: 10}}},
+		{"T.Run", []outline.Definition{{Name: "T.Run", Start: 6, End: 8}}},
+		{"Missing", nil},
+	}
+	for _, tc := range testCases {
+		got, ok := outline.Find("p.go", src, tc.name)
+		if !ok || !slices.Equal(got, tc.want) {
+			t.Errorf("Find(%s) = %v, want %v", tc.name, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,75 @@
+//go:build cgo
+
+package tests
+
+import (
+	"fcopy/internal/outline"
+	"slices"
+	"testing"
+)
+
+// TestTreeSitterOutline checks --outline for the languages parsed with
+// tree-sitter
+func TestTreeSitterOutline(t *testing.T) {
+	testCases := []struct {
+		path string
+		src  string
+		want string
+	}{
+		{
+			"svc.ts",
+			"import { db } from './db';\nimport type { User } from './user';\n\nconst limit = 5;\n\n/** Finds a user */\nexport function find(id: string): User {\n  return db.get(id);\n}\n\nexport const double = (n: number) => n * 2;\n\nexport class Service {\n  private n = 1;\n  run(): void {\n    console.log(this.n);\n  }\n}\n\nconsole.log(limit);\n",
+			"import { db } from './db';\nimport type { User } from './user';\n\n/** Finds a user */\nexport function find(id: string): User { ... }\n\nexport const double = (n: number) => n * 2;\n\nexport class Service {\n  private n = 1;\n  run(): void { ... }\n}\n",
+		},
+		{
+			"util.py",
+			"import os\n\nLIMIT = 3\n\n# Adds numbers\n@cache\ndef add(a, b):\n    \"\"\"Return a + b.\"\"\"\n    return a + b\n\nclass Box:\n    size = 1\n    def open(self):\n        return os.sep\n",
+			"import os\n\n# Adds numbers\n@cache\ndef add(a, b):\n    \"\"\"Return a + b.\"\"\"\n    ...\n\nclass Box:\n    size = 1\n    def open(self):\n        ...\n",
+		},
+		{
+			"point.rs",
+			"use std::fmt;\n\nconst ORIGIN: i32 = 0;\n\n/// A point\n#[derive(Debug)]\npub struct Point { x: i32 }\n\nimpl Point {\n    pub fn new() -> Self {\n        Point { x: ORIGIN }\n    }\n}\n",
+			"use std::fmt;\n\n/// A point\n#[derive(Debug)]\npub struct Point { x: i32 }\n\nimpl Point {\n    pub fn new() -> Self { ... }\n}\n",
+		},
+		{
+			"App.java",
+			"package app;\n\nimport java.util.List;\n\n/** The app */\npublic class App {\n    private int n = 1;\n    public App() {\n        n = 2;\n    }\n    @Override\n    public String toString() {\n        return \"app\";\n    }\n}\n",
+			"package app;\n\nimport java.util.List;\n\n/** The app */\npublic class App {\n    private int n = 1;\n    public App() { ... }\n    @Override\n    public String toString() { ... }\n}\n",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.path, func(t *testing.T) {
+			got, ok := outline.Outline(tc.path, tc.src)
+			if !ok || got != tc.want {
+				t.Errorf("outline:\n%s\nwant:\n%s", got, tc.want)
+			}
+		})
+	}
+
+	if _, ok := outline.Outline("broken.ts", "function ( {"); ok {
+		t.Errorf("unparsable file was outlined")
+	}
+}
+
+// TestFindTreeSitterSymbol checks --symbol for classes, methods and
+// exported arrow functions in TypeScript and Python
+func TestFindTreeSitterSymbol(t *testing.T) {
+	ts := "// Greets\nexport const greet = (n: string) => {\n  return n;\n};\n\nexport class Svc {\n  /** Runs */\n  run(): void {}\n}\n"
+	py := "class Box:\n    @property\n    def size(self):\n        return 1\n"
+	testCases := []struct {
+		path, src, name string
+		want            []outline.Definition
+	}{
+		{"a.ts", ts, "greet", []outline.Definition{{Name: "greet", Start: 1, End: 4}}},
+		{"a.ts", ts, "Svc.run", []outline.Definition{{Name: "Svc.run", Start: 7, End: 8}}},
+		{"a.ts", ts, "Svc", []outline.Definition{{Name: "Svc", Start: 6, End: 9}}},
+		{"a.py", py, "size", []outline.Definition{{Name: "Box.size", Start: 2, End: 4}}},
+	}
+	for _, tc := range testCases {
+		got, ok := outline.Find(tc.path, tc.src, tc.name)
+		if !ok || !slices.Equal(got, tc.want) {
+			t.Errorf("Find(%s, %s) = %v, want %v", tc.path, tc.name, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,56 @@
+package tests
+
+import (
+	"fcopy/internal/utils"
+	"os/user"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+// TestExpandPath checks the quote stripping, home and variable expansion
+// and cwd joining path arguments go through, and that what can't be
+// expanded is left as typed
+func TestExpandPath(t *testing.T) {
+	home := filepath.Join(t.TempDir(), "home")
+	t.Setenv("HOME", home)
+	if runtime.GOOS == "windows" {
+		t.Setenv("USERPROFILE", home)
+	}
+	t.Setenv("FCOPY_TEST_DIR", filepath.Join(home, "src"))
+	t.Setenv("FCOPY_TEST_TILDE", "~/notes")
+	cwd := filepath.Join(t.TempDir(), "work")
+
+	type testCase struct {
+		name string
+		path string
+		cwd  string
+		want string
+	}
+	testCases := []testCase{
+		{"double quotes", `"main.go"`, "", "main.go"},
+		{"single quotes", `'docs/a b.md'`, "", filepath.FromSlash("docs/a b.md")},
+		{"empty after quotes", `""`, cwd, ""},
+		{"tilde", "~", "", home},
+		{"tilde path", "~/src/app", cwd, filepath.Join(home, "src", "app")},
+		{"variable", "$FCOPY_TEST_DIR/main.go", "", filepath.Join(home, "src", "main.go")},
+		{"braced variable", "${FCOPY_TEST_DIR}x/main.go", "", filepath.Join(home, "srcx", "main.go")},
+		{"variable holding a tilde", "$FCOPY_TEST_TILDE/todo.md", "", filepath.Join(home, "notes", "todo.md")},
+		{"unset variable", "$FCOPY_TEST_UNSET/main.go", "", filepath.FromSlash("$FCOPY_TEST_UNSET/main.go")},
+		{"unknown user", "~nosuchuserfcopy/main.go", "", filepath.FromSlash("~nosuchuserfcopy/main.go")},
+		{"relative to cwd", "internal/../cmd", cwd, filepath.Join(cwd, "cmd")},
+		{"cwd with a variable", "main.go", "$FCOPY_TEST_DIR", filepath.Join(home, "src", "main.go")},
+		{"absolute ignores cwd", home, cwd, home},
+	}
+	// Windows account names like DOMAIN\user can't follow a ~
+	if u, err := user.Current(); err == nil && u.HomeDir != "" && u.Username != "" && !strings.ContainsAny(u.Username, `/\`) {
+		testCases = append(testCases, testCase{"tilde user", "~" + u.Username + "/main.go", "", filepath.Join(u.HomeDir, "main.go")})
+	}
+
+	for _, tc := range testCases {
+		if got := utils.ExpandPath(tc.path, tc.cwd); got != tc.want {
+			t.Errorf("%s: ExpandPath(%q, %q) = %q, want %q", tc.name, tc.path, tc.cwd, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,84 @@
+package tests
+
+import (
+	"bytes"
+	"errors"
+	"fcopy/internal/finder"
+	"fcopy/internal/picker"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// TestPickerFilters checks that typing narrows the list and Enter picks the
+// best remaining candidate
+func TestPickerFilters(t *testing.T) {
+	candidates := []picker.Candidate{
+		{Path: "internal/alpha.go"},
+		{Path: "internal/beta.go"},
+		{Path: "cmd/gamma.go"},
+	}
+	filter := func(query, path string) (int, bool) {
+		score, ok := finder.SubsequenceScore(query, path)
+		return -score, ok
+	}
+
+	chosen, err := picker.Pick(candidates, picker.Options{
+		Filter: filter,
+		In:     strings.NewReader("gma\r"),
+		Out:    &bytes.Buffer{},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(chosen, []int{2}) {
+		t.Errorf("typing gma picked %v, want [2]", chosen)
+	}
+
+	chosen, err = picker.Pick(candidates, picker.Options{
+		Multi: true,
+		In:    strings.NewReader("  \r"),
+		Out:   &bytes.Buffer{},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(chosen, []int{0, 1}) {
+		t.Errorf("toggling twice picked %v, want [0 1]", chosen)
+	}
+}
+
+// TestPickerFzf checks that fzf's selection maps back to candidates, using a
+// stand-in fzf that picks the second and third lines
+func TestPickerFzf(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the stand-in fzf is a shell script")
+	}
+	candidates := []picker.Candidate{
+		{Path: "a.go"},
+		{Path: "same.go"},
+		{Path: "same.go"},
+	}
+
+	t.Setenv("PATH", t.TempDir())
+	if _, err := picker.Fzf(candidates, picker.Options{}); !errors.Is(err, picker.ErrNoFzf) {
+		t.Errorf("without fzf got %v, want ErrNoFzf", err)
+	}
+
+	bin := t.TempDir()
+	script := "#!/bin/sh\nsed -n 2,3p\n"
+	if err := os.WriteFile(filepath.Join(bin, "fzf"), []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", bin+string(os.PathListSeparator)+"/usr/bin"+string(os.PathListSeparator)+"/bin")
+	chosen, err := picker.Fzf(candidates, picker.Options{Multi: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(chosen, []int{1, 2}) {
+		t.Errorf("fzf picked %v, want [1 2]", chosen)
+	}
+}
@@ -0,0 +1,174 @@
+package tests
+
+import (
+	"fcopy/internal/config"
+	"flag"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+// TestPresets checks that a preset fills in flags from the config file
+// without overriding the ones given on the command line
+func TestPresets(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.yaml")
+	data := "presets:\n  review:\n    prompt-template: review.tmpl\n    no-tests: true\n    max-tokens: 5000\n    var: [a=1, b=2]\n  broken:\n    no-such-flag: 1\n"
+	if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+	file, err := config.LoadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fs := flag.NewFlagSet("fcopy", flag.ContinueOnError)
+	template := fs.String("prompt-template", "", "")
+	noTests := fs.Bool("no-tests", false, "")
+	maxTokens := fs.Int("max-tokens", 0, "")
+	var vars config.RepeatedFlag
+	fs.Var(&vars, "var", "")
+	if err := fs.Parse([]string{"--max-tokens", "100"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := file.ApplyPreset(fs, "review"); err != nil {
+		t.Fatal(err)
+	}
+	if *template != filepath.Join(dir, "review.tmpl") || !*noTests || *maxTokens != 100 || len(vars) != 2 {
+		t.Errorf("got prompt-template=%s no-tests=%v max-tokens=%d var=%q", *template, *noTests, *maxTokens, vars)
+	}
+
+	if err := file.ApplyPreset(fs, "broken"); err == nil {
+		t.Errorf("preset with an unknown option was applied")
+	}
+	if err := file.ApplyPreset(fs, "missing"); err == nil {
+		t.Errorf("unknown preset was applied")
+	}
+	if empty, err := config.LoadFile(filepath.Join(dir, "none.yaml")); err != nil || len(empty.Presets) != 0 {
+		t.Errorf("missing config file gave %v, %v", empty, err)
+	}
+}
+
+// TestConfigPrecedence checks that flags win over FCOPY_* variables, which
+// win over the defaults of the config file
+func TestConfigPrecedence(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	data := "defaults:\n  workers: 3\n  hidden: true\n  max-size: 10\nignore:\n  dirs: [precedence-generated]\n"
+	if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+	file, err := config.LoadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("FCOPY_WORKERS", "7")
+	t.Setenv("FCOPY_MAX_SIZE", "20")
+
+	fs := flag.NewFlagSet("fcopy", flag.ContinueOnError)
+	workers := fs.Int("workers", 1, "")
+	hidden := fs.Bool("hidden", false, "")
+	maxSize := fs.Int64("max-size", 0, "")
+	if err := fs.Parse([]string{"--max-size", "30"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := config.ApplyEnv(fs); err != nil {
+		t.Fatal(err)
+	}
+	if err := file.ApplyDefaults(fs); err != nil {
+		t.Fatal(err)
+	}
+	if *workers != 7 || !*hidden || *maxSize != 30 {
+		t.Errorf("got workers=%d hidden=%v max-size=%d, want 7, true and 30", *workers, *hidden, *maxSize)
+	}
+
+	file.AddIgnores()
+	defer delete(config.IgnoreDirs, "precedence-generated")
+	if !config.IgnoreDirs["precedence-generated"] {
+		t.Errorf("ignore.dirs wasn't added to the ignored directories")
+	}
+
+	t.Setenv("FCOPY_WORKERS", "many")
+	fs = flag.NewFlagSet("fcopy", flag.ContinueOnError)
+	fs.Int("workers", 1, "")
+	if err := config.ApplyEnv(fs); err == nil {
+		t.Errorf("invalid FCOPY_WORKERS was accepted")
+	}
+}
+
+// TestProjectConfig checks that a project's .fcopy.yaml is merged over the
+// user config and can set only filter, format and limit options
+func TestProjectConfig(t *testing.T) {
+	dir := t.TempDir()
+	user := filepath.Join(dir, "config.yaml")
+	data := "defaults:\n  workers: 3\n  format: cat\nsets:\n  api: [api/]\n  docs: [docs/]\nignore:\n  dirs: [user-generated]\n"
+	if err := os.WriteFile(user, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+	project := filepath.Join(dir, "repo", config.ProjectConfigName)
+	os.MkdirAll(filepath.Dir(project), 0755)
+	data = "defaults:\n  format: jsonl\n  max-tokens: 5000\nsets:\n  api: [server/]\nignore:\n  dirs: [project-generated]\n"
+	if err := os.WriteFile(project, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	file, err := config.LoadFile(user)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := file.MergeProject(project); err != nil {
+		t.Fatal(err)
+	}
+	if file.Project != project {
+		t.Errorf("project config is %q, want %q", file.Project, project)
+	}
+	if file.Sets["api"][0] != "server/" || file.Sets["docs"][0] != "docs/" || len(file.Ignore.Dirs) != 2 {
+		t.Errorf("merged sets %v and ignored directories %v", file.Sets, file.Ignore.Dirs)
+	}
+
+	fs := flag.NewFlagSet("fcopy", flag.ContinueOnError)
+	workers := fs.Int("workers", 1, "")
+	format := fs.String("format", "plain", "")
+	maxTokens := fs.Int("max-tokens", 0, "")
+	if err := file.ApplyDefaults(fs); err != nil {
+		t.Fatal(err)
+	}
+	if *workers != 3 || *format != "jsonl" || *maxTokens != 5000 {
+		t.Errorf("got workers=%d format=%s max-tokens=%d", *workers, *format, *maxTokens)
+	}
+
+	if err := os.WriteFile(project, []byte("presets:\n  ship:\n    post-copy: curl example.com\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	file, _ = config.LoadFile(user)
+	if err := file.MergeProject(project); err == nil {
+		t.Errorf("project config set --post-copy")
+	}
+	for _, option := range []string{"log-file: /tmp/victim.txt", "debug: true", "bridge-addr: 0.0.0.0:80",
+		"prompt-template: /etc/passwd", "files-from: /etc/passwd", "cwd: /", "follow-symlinks: true", "follow-imports: true", "import-depth: 5"} {
+		if err := os.WriteFile(project, []byte("defaults:\n  "+option+"\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		file, _ = config.LoadFile(user)
+		if err := file.MergeProject(project); err == nil {
+			t.Errorf("project config set %s", option)
+		}
+	}
+	if err := file.MergeProject(filepath.Join(dir, "none", config.ProjectConfigName)); err != nil || file.Project != "" {
+		t.Errorf("missing project config gave %q, %v", file.Project, err)
+	}
+}
+
+// TestOptsArgs checks that FCOPY_OPTS is split into arguments as a shell
+// would split it
+func TestOptsArgs(t *testing.T) {
+	args, err := config.OptsArgs(` --no-tests  --var 'focus=error handling' --separator "\n" a\ b `)
+	want := []string{"--no-tests", "--var", "focus=error handling", "--separator", `\n`, "a b"}
+	if err != nil || !slices.Equal(args, want) {
+		t.Errorf("got %q, %v, want %q", args, err, want)
+	}
+	if _, err := config.OptsArgs(`--var 'unterminated`); err == nil {
+		t.Errorf("unterminated quote was accepted")
+	}
+}
@@ -0,0 +1,34 @@
+package tests
+
+import (
+	"fcopy/internal/processor"
+	"fcopy/internal/relevance"
+	"slices"
+	"testing"
+)
+
+// TestRelevanceRanking checks that --relevant-to puts matching files first,
+// drops unrelated walked files and keeps explicit ones
+func TestRelevanceRanking(t *testing.T) {
+	if got := relevance.Terms("HTTPServer rate_limiting RateLimiters"); !slices.Equal(got, []string{"http", "serv", "rate", "limit", "rate", "limit"}) {
+		t.Errorf("Terms split into %q", got)
+	}
+
+	walked := processor.Origin{Discovered: true}
+	files := []processor.FileContent{
+		{Path: "README.md", Content: "A tool for copying files.", Origin: walked},
+		{Path: "server.go", Content: "func serve() { handle(requests) }", Origin: walked},
+		{Path: "middleware/ratelimit.go", Content: "// RateLimiter limits requests per client\ntype RateLimiter struct{}", Origin: walked},
+		{Path: "limits.go", Content: "const maxRate = 10 // rate limit", Origin: walked},
+		{Path: "notes.txt", Content: "unrelated"},
+	}
+
+	var paths []string
+	for _, f := range relevance.Rank(files, "rate limiting middleware") {
+		paths = append(paths, f.Path)
+	}
+	want := []string{"middleware/ratelimit.go", "limits.go", "notes.txt"}
+	if !slices.Equal(paths, want) {
+		t.Errorf("ranked %q, want %q", paths, want)
+	}
+}
@@ -0,0 +1,91 @@
+package tests
+
+import (
+	"fcopy/internal/processor"
+	"fcopy/internal/review"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// TestReview checks the toggles of the review prompt: numbers and ranges
+// flip files, a and n select all or none, Enter copies and q or the end of
+// the input aborts
+func TestReview(t *testing.T) {
+	files := []processor.FileContent{
+		{Path: "a.go", Content: "package a\n"},
+		{Path: "b.go", Content: "package b\n"},
+		{Path: "c.go", Content: "package c\n"},
+		{Path: "d.go", Content: "package d\n"},
+	}
+	testCases := []struct {
+		input string
+		want  []string
+		ok    bool
+	}{
+		{"\n", []string{"a.go", "b.go", "c.go", "d.go"}, true},
+		{"2\n\n", []string{"a.go", "c.go", "d.go"}, true},
+		{"1 3-4\n\n", []string{"b.go"}, true},
+		{"2-\n\n", []string{"a.go"}, true},
+		{"n\n2\n\n", []string{"b.go"}, true},
+		{"n\na\n\n", []string{"a.go", "b.go", "c.go", "d.go"}, true},
+		{"2\n2\n9\nx\n\n", []string{"a.go", "b.go", "c.go", "d.go"}, true},
+		{"1\nq\n", nil, false},
+		{"1\n", nil, false},
+	}
+	for _, tc := range testCases {
+		var out strings.Builder
+		kept, ok := review.Run(files, strings.NewReader(tc.input), &out)
+		var got []string
+		for _, f := range kept {
+			got = append(got, f.Path)
+		}
+		if ok != tc.ok || !slices.Equal(got, tc.want) {
+			t.Errorf("input %q: got %v, %v, want %v, %v", tc.input, got, ok, tc.want, tc.ok)
+		}
+	}
+
+	var out strings.Builder
+	review.Run(files, strings.NewReader("x\n2\n\n"), &out)
+	for _, want := range []string{
+		"[x] 1. a.go (10 bytes, ",
+		"Selected 4 of 4 files (40 bytes, ",
+		"Invalid selection. Please try again.",
+		"[ ] 2. b.go (10 bytes, ",
+		"Selected 3 of 4 files (30 bytes, ",
+	} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("Expected the prompt to show %q, got:\n%s", want, out.String())
+		}
+	}
+}
+
+// TestReviewCopy runs --review from the command line and checks that only
+// the files left selected are copied, and nothing when the review is aborted
+func TestReviewCopy(t *testing.T) {
+	files := map[string]string{"a.go": "package a\n", "b.go": "package b\n", "c.go": "package c\n"}
+
+	dir := cliDir(t, files)
+	out, code := runFcopy(t, dir, "2\n\n", "--output", "out.txt", "--review", "a.go", "b.go", "c.go")
+	if code != 0 {
+		t.Fatalf("got exit code %d:\n%s", code, out)
+	}
+	copied, err := os.ReadFile(filepath.Join(dir, "out.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "-- a.go --\npackage a\n\n\n-- c.go --\npackage c\n"; !strings.HasPrefix(string(copied), want) || strings.Contains(string(copied), "b.go") {
+		t.Errorf("Expected only a.go and c.go to be copied, got %q", copied)
+	}
+
+	dir = cliDir(t, files)
+	out, code = runFcopy(t, dir, "q\n", "--output", "out.txt", "--review", "a.go", "b.go")
+	if code != 1 || !strings.Contains(out, "Aborted, nothing was copied.") {
+		t.Errorf("Expected the aborted review to exit with 1, got %d:\n%s", code, out)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "out.txt")); err == nil {
+		t.Error("Expected nothing to be written after aborting the review")
+	}
+}
@@ -0,0 +1,58 @@
+package tests
+
+import (
+	"fcopy/internal/config"
+	"fcopy/internal/finder"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestScoringConfig checks that the fuzzy section of the config file
+// overrides only the settings it names, and that the finder uses them
+func TestScoringConfig(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.yaml")
+	data := "fuzzy:\n  auto-select-max: 3\n  distance-max: 1\n"
+	if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+	file, err := config.LoadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defaults := config.DefaultScoring()
+	if file.Fuzzy.DistanceMin != defaults.DistanceMin || file.Fuzzy.Substring != defaults.Substring {
+		t.Errorf("settings missing from the file lost their defaults: %+v", file.Fuzzy)
+	}
+	if got := defaults.AutoSelectThreshold(40); got != 10 {
+		t.Errorf("default auto-select threshold for 40 characters is %d, want 10", got)
+	}
+	if got := file.Fuzzy.AutoSelectThreshold(40); got != 3 {
+		t.Errorf("capped auto-select threshold for 40 characters is %d, want 3", got)
+	}
+	if got := defaults.DistanceThreshold(9); got != 6 {
+		t.Errorf("default distance threshold for 9 characters is %d, want 6", got)
+	}
+
+	// "confgi" is two edits from "config" and no subsequence of it
+	tree := t.TempDir()
+	if err := os.WriteFile(filepath.Join(tree, "config"), nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if matches := finder.FindRecursiveMatches(tree, "confgi", 0, &config.Config{SearchDepth: 1}); len(matches) != 1 {
+		t.Errorf("with the default distance confgi matches %+v", matches)
+	}
+	cfg := &config.Config{SearchDepth: 1, Scoring: &file.Fuzzy}
+	if matches := finder.FindRecursiveMatches(tree, "confgi", 0, cfg); len(matches) != 0 {
+		t.Errorf("with distance-max 1 confgi matches %+v", matches)
+	}
+
+	if err := os.WriteFile(path, []byte("fuzzy:\n  distance-ratio: -1\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := config.LoadFile(path); err == nil {
+		t.Error("a negative distance-ratio was accepted")
+	}
+}
@@ -0,0 +1,85 @@
+package tests
+
+import (
+	"fcopy/internal/processor"
+	"fcopy/internal/selection"
+	"os"
+	"reflect"
+	"runtime"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// TestParseEditorSelection checks how entries of the editor selection
+// protocol are split into paths and line ranges
+func TestParseEditorSelection(t *testing.T) {
+	// A file named like a path with ranges is taken whole; Windows doesn't
+	// allow colons in file names
+	t.Chdir(t.TempDir())
+	existing := selection.Entry{Path: "v1", Ranges: []processor.LineRange{{Start: 2, End: 2}}}
+	if runtime.GOOS != "windows" {
+		if err := os.WriteFile("v1:2", []byte("x\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		existing = selection.Entry{Path: "v1:2"}
+	}
+
+	testCases := []struct {
+		name  string
+		input string
+		want  []selection.Entry
+	}{
+		{"path", "internal/x.go\n", []selection.Entry{{Path: "internal/x.go"}}},
+		{"ranges", "x.go:10-20,35\n", []selection.Entry{{Path: "x.go", Ranges: []processor.LineRange{{Start: 10, End: 20}, {Start: 35, End: 35}}}}},
+		{"open range", "x.go:40-", []selection.Entry{{Path: "x.go", Ranges: []processor.LineRange{{Start: 40}}}}},
+		{"windows drive", `C:\src\x.go`, []selection.Entry{{Path: `C:\src\x.go`}}},
+		{"windows drive with ranges", `C:\src\x.go:3`, []selection.Entry{{Path: `C:\src\x.go`, Ranges: []processor.LineRange{{Start: 3, End: 3}}}}},
+		{"colon in name", "notes:draft.md", []selection.Entry{{Path: "notes:draft.md"}}},
+		{"trailing colon", "x.go:", []selection.Entry{{Path: "x.go:"}}},
+		{"existing file named like ranges", "v1:2", []selection.Entry{existing}},
+		{"missing file named like ranges", "v3:4", []selection.Entry{{Path: "v3", Ranges: []processor.LineRange{{Start: 4, End: 4}}}}},
+		{"comments and blank lines", "# from the editor\n\n  a.go  \n   \n  # b.go\nc.go:1\n", []selection.Entry{
+			{Path: "a.go"},
+			{Path: "c.go", Ranges: []processor.LineRange{{Start: 1, End: 1}}},
+		}},
+	}
+	for _, tc := range testCases {
+		got, err := selection.Parse(strings.NewReader(tc.input))
+		if err != nil || !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("%s: got %+v, %v, want %+v", tc.name, got, err, tc.want)
+		}
+	}
+
+	for _, input := range []string{"x.go:20-10", "x.go:0", "x.go:1-2-3"} {
+		if _, err := selection.Parse(strings.NewReader(input)); err == nil {
+			t.Errorf("Expected an error for %q", input)
+		}
+	}
+}
+
+// TestSelectionFromEnv checks that FromEnv reads both variables of the
+// protocol and rejects a descriptor that isn't a number or isn't open
+func TestSelectionFromEnv(t *testing.T) {
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.WriteString("b.go:5\n")
+	w.Close()
+	t.Setenv(selection.EnvSelection, "a.go\n")
+	t.Setenv(selection.EnvFD, strconv.Itoa(int(r.Fd())))
+	got, err := selection.FromEnv()
+	want := []selection.Entry{{Path: "a.go"}, {Path: "b.go", Ranges: []processor.LineRange{{Start: 5, End: 5}}}}
+	if err != nil || !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v, %v, want %+v", got, err, want)
+	}
+
+	os.Unsetenv(selection.EnvSelection)
+	for _, fd := range []string{"", "three", "-1", "987654"} {
+		t.Setenv(selection.EnvFD, fd)
+		if got, err := selection.FromEnv(); err == nil || !strings.HasPrefix(err.Error(), selection.EnvFD) {
+			t.Errorf("%s=%q: got %+v, %v, want an error", selection.EnvFD, fd, got, err)
+		}
+	}
+}
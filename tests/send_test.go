@@ -0,0 +1,49 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"fcopy/internal/send"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// TestSendChat checks the request --send makes and how replies and errors
+// of an OpenAI-compatible endpoint are read
+func TestSendChat(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Model    string
+			Messages []struct{ Role, Content string }
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Errorf("invalid request: %v", err)
+		}
+		if r.URL.Path != "/v1/chat/completions" || r.Header.Get("Authorization") != "Bearer secret" {
+			t.Errorf("got %s with Authorization %q", r.URL.Path, r.Header.Get("Authorization"))
+		}
+		if req.Model == "missing" {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"error": {"message": "model not found"}}`))
+			return
+		}
+		reply := req.Model + ": " + strings.ToUpper(req.Messages[0].Content)
+		json.NewEncoder(w).Encode(map[string]any{
+			"choices": []any{map[string]any{"message": map[string]string{"role": "assistant", "content": reply}}},
+		})
+	}))
+	defer server.Close()
+
+	opts := send.Options{BaseURL: server.URL + "/v1/", Model: "llama3", APIKey: "secret"}
+	reply, err := send.Chat(context.Background(), opts, "hello")
+	if err != nil || reply != "llama3: HELLO" {
+		t.Errorf("got %q, %v", reply, err)
+	}
+
+	opts.Model = "missing"
+	if _, err := send.Chat(context.Background(), opts, "hello"); err == nil || !strings.Contains(err.Error(), "model not found") {
+		t.Errorf("got error %v, want the endpoint's message", err)
+	}
+}
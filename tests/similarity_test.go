@@ -0,0 +1,51 @@
+package tests
+
+import (
+	"fcopy/internal/config"
+	"fcopy/internal/finder"
+	"fcopy/internal/utils"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestSimilarityUnicode checks that edit distances count characters, not
+// bytes, and that the folding variant ignores case the Unicode way
+func TestSimilarityUnicode(t *testing.T) {
+	cases := []struct {
+		a, b string
+		want int
+	}{
+		{"cafe", "café", 1},
+		{"日本語.txt", "日本.txt", 1},
+		{"🚀launch", "launch", 1},
+		{"kitten", "sitting", 3},
+	}
+	for _, c := range cases {
+		if got := utils.CalculateSimilarity(c.a, c.b); got != c.want {
+			t.Errorf("distance between %q and %q is %d, want %d", c.a, c.b, got, c.want)
+		}
+	}
+
+	if got := utils.CalculateSimilarityFold("STRASSE.md", "straße.md"); got != 0 {
+		t.Errorf("folded distance between STRASSE.md and straße.md is %d", got)
+	}
+	if got := utils.CalculateSimilarityFold("Ärger", "ärgr"); got != 1 {
+		t.Errorf("folded distance between Ärger and ärgr is %d", got)
+	}
+}
+
+// TestFindUnicodeTypo checks that a typo in a non-ASCII name is as cheap as
+// one in an ASCII name
+func TestFindUnicodeTypo(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"résumé.md", "notes.md"} {
+		if err := os.WriteFile(filepath.Join(dir, name), nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	matches := finder.FindRecursiveMatches(dir, "resume.md", 0, &config.Config{SearchDepth: 1})
+	if len(matches) == 0 || matches[0].Name != "résumé.md" || matches[0].Score != 4 {
+		t.Errorf("best matches for resume.md are %+v", matches)
+	}
+}
@@ -0,0 +1,51 @@
+package tests
+
+import (
+	"fcopy/internal/output"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestSinks checks that a file sink only creates its file once written to,
+// a buffer sink delivers on Close and a meter counts what passes through it
+func TestSinks(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "out.txt")
+	empty := &output.FileSink{Path: path}
+	if err := empty.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("unwritten file sink created %s", path)
+	}
+
+	file := &output.FileSink{Path: path}
+	meter := &output.Meter{W: file}
+	fmt.Fprint(meter, "hello ")
+	fmt.Fprint(meter, "world\n")
+	if err := file.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if data, err := os.ReadFile(path); err != nil || string(data) != "hello world\n" {
+		t.Errorf("file holds %q (%v), want %q", data, err, "hello world\n")
+	}
+	if meter.Bytes != 12 || meter.Tokens == 0 {
+		t.Errorf("meter counted %d bytes and %d tokens, want 12 bytes", meter.Bytes, meter.Tokens)
+	}
+
+	var delivered string
+	buffer := &output.BufferSink{Deliver: func(data []byte) error {
+		delivered = string(data)
+		return nil
+	}}
+	fmt.Fprint(buffer, "part one, ")
+	fmt.Fprint(buffer, "part two")
+	if delivered != "" {
+		t.Errorf("buffer sink delivered before Close")
+	}
+	buffer.Close()
+	if delivered != "part one, part two" {
+		t.Errorf("buffer sink delivered %q", delivered)
+	}
+}
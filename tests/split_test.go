@@ -0,0 +1,63 @@
+package tests
+
+import (
+	"fcopy/internal/split"
+	"strings"
+	"testing"
+)
+
+// TestSplitParts checks that --split cuts at line boundaries within the
+// budget and labels every part
+func TestSplitParts(t *testing.T) {
+	bytes := func(s string) int { return len(s) }
+	text := strings.Repeat("0123456789\n", 10)
+
+	parts := split.Split(text, 25, bytes)
+	if len(parts) != 5 {
+		t.Fatalf("got %d parts, want 5", len(parts))
+	}
+	if strings.Join(parts, "") != text {
+		t.Errorf("parts don't add up to the input")
+	}
+	for i, part := range parts {
+		if len(part) > 25 || !strings.HasSuffix(part, "\n") {
+			t.Errorf("part %d is %q, want whole lines within 25 bytes", i+1, part)
+		}
+	}
+
+	if got := split.Split(text, 1000, bytes); len(got) != 1 {
+		t.Errorf("small input split into %d parts", len(got))
+	}
+	if got := split.Split("short\n"+strings.Repeat("x", 50)+"\n", 20, bytes); len(got) != 2 {
+		t.Errorf("oversized line gave %d parts, want 2", len(got))
+	}
+
+	labeled := split.Label(parts)
+	if !strings.HasPrefix(labeled[0], "Part 1/5\n") || !strings.Contains(labeled[0], "More parts follow") {
+		t.Errorf("first part labeled %q", labeled[0])
+	}
+	if !strings.Contains(labeled[4], "This was the last part") {
+		t.Errorf("last part labeled %q", labeled[4])
+	}
+}
+
+// TestSplitOverlap checks that --overlap repeats lines only where a file
+// continues into the next part, without reaching into the previous file
+func TestSplitOverlap(t *testing.T) {
+	isHeader := func(line string) bool { return strings.HasPrefix(line, "-- ") }
+	parts := []string{"-- a --\na1\n-- b --\nb1\nb2\nb3\n", "b4\nb5\n", "-- c --\nc1\n"}
+
+	got := split.Overlap(parts, 2, isHeader)
+	want := "[Repeated from the end of part 1:]\nb2\nb3\n[End of repeated lines]\nb4\nb5\n"
+	if got[1] != want {
+		t.Errorf("second part is %q, want %q", got[1], want)
+	}
+	if got[2] != parts[2] {
+		t.Errorf("part starting with a file got overlap: %q", got[2])
+	}
+
+	got = split.Overlap(parts, 10, isHeader)
+	if !strings.HasPrefix(got[1], "[Repeated from the end of part 1:]\nb1\nb2\nb3\n") {
+		t.Errorf("overlap reached into the previous file: %q", got[1])
+	}
+}
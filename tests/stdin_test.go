@@ -0,0 +1,49 @@
+package tests
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestStdinPseudoFile checks that "-" bundles what is piped into fcopy under
+// --stdin-label, in the place of the argument among the files
+func TestStdinPseudoFile(t *testing.T) {
+	testCases := []struct {
+		name  string
+		stdin string
+		args  []string
+		want  string
+	}{
+		{"default label", "error: connection refused\n", []string{"-"},
+			"-- stdin --\nerror: connection refused\n"},
+		{"label before files", "12:00 started\n12:01 crashed\n", []string{"--stdin-label", "app.log", "-", "main.go"},
+			"-- app.log --\n12:00 started\n12:01 crashed\n\n\n-- main.go --\npackage main\n"},
+		{"label after files", "SELECT 1;\n", []string{"--stdin-label=dump.sql", "main.go", "-"},
+			"-- main.go --\npackage main\n\n\n-- dump.sql --\nSELECT 1;\n"},
+		{"legacy encoding", "\xff\xfeh\x00i\x00", []string{"-"}, "-- stdin --\nhi"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			dir := cliDir(t, map[string]string{"main.go": "package main\n"})
+			out, code := runFcopy(t, dir, tc.stdin, append([]string{"--output", "out.txt"}, tc.args...)...)
+			if code != 0 {
+				t.Fatalf("got exit code %d:\n%s", code, out)
+			}
+			copied, err := os.ReadFile(filepath.Join(dir, "out.txt"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !strings.HasPrefix(string(copied), tc.want) {
+				t.Errorf("Expected the output to start with %q, got %q", tc.want, copied)
+			}
+		})
+	}
+
+	dir := cliDir(t, map[string]string{"main.go": "package main\n"})
+	out, code := runFcopy(t, dir, "main.go\n", "--output", "out.txt", "--files-from", "-", "-")
+	if code != 1 || !strings.Contains(out, "Cannot read both content (-) and --files-from - from stdin") {
+		t.Errorf("Expected - with --files-from - to be refused, got exit code %d:\n%s", code, out)
+	}
+}
@@ -0,0 +1,79 @@
+package tests
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestQuietAndSummaryJSON checks that --quiet prints nothing but errors, and
+// that --summary-json ends the run with one line of JSON describing it
+func TestQuietAndSummaryJSON(t *testing.T) {
+	files := map[string]string{
+		"src/main.go":  "package main\n",
+		"src/util.go":  "package main\n\nfunc util() {}\n",
+		"src/logo.png": "\x89PNG",
+	}
+
+	t.Run("quiet", func(t *testing.T) {
+		dir := cliDir(t, files)
+		out, code := runFcopy(t, dir, "", "--output", "out.txt", "--quiet", "src")
+		if code != 0 || out != "" {
+			t.Errorf("Expected no output, got %d:\n%s", code, out)
+		}
+		if _, err := os.Stat(filepath.Join(dir, "out.txt")); err != nil {
+			t.Errorf("Expected the copy to be written: %v", err)
+		}
+
+		out, code = runFcopy(t, dir, "", "--output", "out.txt", "--quiet", "src/main.go", "src/main.go/child")
+		if code != 1 || !strings.Contains(out, "Error accessing src/main.go/child") || strings.Contains(out, "Wrote content") {
+			t.Errorf("Expected only the error, got %d:\n%s", code, out)
+		}
+	})
+
+	t.Run("summary json", func(t *testing.T) {
+		dir := cliDir(t, files)
+		out, code := runFcopy(t, dir, "", "--output", "out.txt", "--summary-json", "src")
+		if code != 0 {
+			t.Fatalf("got exit code %d:\n%s", code, out)
+		}
+		lines := strings.Split(strings.TrimSuffix(out, "\n"), "\n")
+		if len(lines) != 1 {
+			t.Fatalf("Expected a single line of output, got:\n%s", out)
+		}
+		var summary struct {
+			Files   int   `json:"files"`
+			Bytes   int64 `json:"bytes"`
+			Tokens  int   `json:"tokens"`
+			Copied  bool  `json:"copied"`
+			Skipped []struct {
+				Path   string `json:"path"`
+				Reason string `json:"reason"`
+			} `json:"skipped"`
+			Omitted    int    `json:"omitted"`
+			Errors     int    `json:"errors"`
+			DurationMS *int64 `json:"duration_ms"`
+		}
+		if err := json.Unmarshal([]byte(lines[0]), &summary); err != nil {
+			t.Fatalf("Expected JSON, got %q: %v", lines[0], err)
+		}
+		copied, _ := os.ReadFile(filepath.Join(dir, "out.txt"))
+		if summary.Files != 2 || summary.Bytes != int64(len(copied)) || summary.Tokens == 0 || !summary.Copied ||
+			summary.Omitted != 0 || summary.Errors != 0 || summary.DurationMS == nil {
+			t.Errorf("Unexpected summary %s for %d bytes copied", lines[0], len(copied))
+		}
+		if len(summary.Skipped) != 1 || filepath.ToSlash(summary.Skipped[0].Path) != "src/logo.png" || summary.Skipped[0].Reason != "binary file" {
+			t.Errorf("Expected src/logo.png skipped as a binary file, got %+v", summary.Skipped)
+		}
+	})
+
+	t.Run("summary json with limits", func(t *testing.T) {
+		dir := cliDir(t, files)
+		out, _ := runFcopy(t, dir, "", "--output", "out.txt", "--summary-json", "--max-files", "1", "src/main.go", "src/util.go")
+		if !strings.Contains(out, `"files":1,`) || !strings.Contains(out, `"omitted":1,`) || !strings.Contains(out, `"skipped":[]`) {
+			t.Errorf("Expected one file copied and one omitted, got:\n%s", out)
+		}
+	})
+}
@@ -0,0 +1,127 @@
+package tests
+
+import (
+	"context"
+	"fcopy/internal/config"
+	"fcopy/internal/processor"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"testing"
+	"time"
+)
+
+// TestSymlinkCycle ensures symlinked directories are skipped by default and
+// walked exactly once with --follow-symlinks, even when they form a cycle
+func TestSymlinkCycle(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("symlinks require elevated privileges on Windows")
+	}
+
+	tempDir := t.TempDir()
+	realDir := filepath.Join(tempDir, "real")
+	if err := os.MkdirAll(filepath.Join(realDir, "sub"), 0755); err != nil {
+		t.Fatalf("Failed to create directories: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(realDir, "sub", "file.txt"), []byte("content"), 0644); err != nil {
+		t.Fatalf("Failed to create file: %v", err)
+	}
+	// real/sub/loop -> real creates a cycle
+	if err := os.Symlink(realDir, filepath.Join(realDir, "sub", "loop")); err != nil {
+		t.Fatalf("Failed to create symlink: %v", err)
+	}
+
+	collect := func(follow bool) []string {
+		cfg := &config.Config{
+			MaxFileSize:    1024 * 1024,
+			Workers:        2,
+			FollowSymlinks: follow,
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		results := make(chan processor.FileContent, 10)
+		tracker := &processor.Tracker{}
+		go func() {
+			processor.ProcessDirectory(ctx, realDir, cfg, results, tracker)
+			close(results)
+		}()
+
+		var paths []string
+		for result := range results {
+			paths = append(paths, result.Path)
+		}
+		if tracker.Errors.Load() != 0 {
+			t.Errorf("Expected 0 errors, got %d", tracker.Errors.Load())
+		}
+		return paths
+	}
+
+	for _, follow := range []bool{false, true} {
+		paths := collect(follow)
+		if len(paths) != 1 {
+			t.Errorf("follow=%v: expected exactly 1 file, got %v", follow, paths)
+		}
+	}
+}
+
+// TestSymlinkedDirectory ensures --follow-symlinks walks a directory linked
+// from outside the tree under the link's path, and that directories linked
+// to each other are walked once, through whichever path is reached first
+func TestSymlinkedDirectory(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("symlinks require elevated privileges on Windows")
+	}
+
+	tempDir := t.TempDir()
+	root := filepath.Join(tempDir, "root")
+	shared := filepath.Join(tempDir, "shared")
+	for _, dir := range []string{filepath.Join(root, "a"), filepath.Join(root, "b"), shared} {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatalf("Failed to create directories: %v", err)
+		}
+	}
+	for _, file := range []string{filepath.Join(root, "main.txt"), filepath.Join(shared, "lib.txt"),
+		filepath.Join(root, "a", "a.txt"), filepath.Join(root, "b", "b.txt")} {
+		if err := os.WriteFile(file, []byte("content"), 0644); err != nil {
+			t.Fatalf("Failed to create file: %v", err)
+		}
+	}
+	// root/shared -> ../shared is a plain symlinked directory; root/a/to-b
+	// and root/b/to-a point at each other's directories
+	for link, target := range map[string]string{
+		filepath.Join(root, "shared"):    shared,
+		filepath.Join(root, "a", "to-b"): filepath.Join(root, "b"),
+		filepath.Join(root, "b", "to-a"): filepath.Join(root, "a"),
+	} {
+		if err := os.Symlink(target, link); err != nil {
+			t.Fatalf("Failed to create symlink: %v", err)
+		}
+	}
+
+	cfg := &config.Config{MaxFileSize: 1024 * 1024, Workers: 2, FollowSymlinks: true}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	results := make(chan processor.FileContent, 10)
+	tracker := &processor.Tracker{}
+	go func() {
+		processor.ProcessDirectory(ctx, root, cfg, results, tracker)
+		close(results)
+	}()
+	var paths []string
+	for result := range results {
+		rel, _ := filepath.Rel(root, result.Path)
+		paths = append(paths, filepath.ToSlash(rel))
+	}
+	slices.Sort(paths)
+
+	// The walk reaches b through a/to-b before b itself
+	want := []string{"a/a.txt", "a/to-b/b.txt", "main.txt", "shared/lib.txt"}
+	if !slices.Equal(paths, want) {
+		t.Errorf("Expected %v, got %v", want, paths)
+	}
+	if tracker.Errors.Load() != 0 || tracker.Skipped.Load() != 0 {
+		t.Errorf("Expected 0 errors and skips, got %d and %d", tracker.Errors.Load(), tracker.Skipped.Load())
+	}
+}
@@ -0,0 +1,44 @@
+package tests
+
+import (
+	"fcopy/internal/output"
+	"fcopy/internal/processor"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestPromptTemplate checks the variables --prompt-template provides and the
+// tree drawn for {{.Tree}}
+func TestPromptTemplate(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "review.tmpl")
+	if err := os.WriteFile(path, []byte("{{.Date}} {{.Count}} {{.focus}}\n{{.Tree}}{{.Files}}"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	prompt, err := output.LoadPrompt(path, []string{"focus=bugs"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	files := []processor.FileContent{{Path: "src/a/one.go"}, {Path: "src/a/two.go"}, {Path: "src/main.go"}}
+	var b strings.Builder
+	if err := prompt.Render(&b, "BUNDLE", files, time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)); err != nil {
+		t.Fatal(err)
+	}
+	want := "2024-05-01 3 bugs\nsrc/\n├── a/\n│   ├── one.go\n│   └── two.go\n└── main.go\nBUNDLE"
+	if b.String() != want {
+		t.Errorf("rendered\n%s\nwant\n%s", b.String(), want)
+	}
+
+	if _, err := output.LoadPrompt(path, []string{"Files=x"}); err == nil {
+		t.Errorf("--var Files=x was accepted")
+	}
+	if prompt, err = output.LoadPrompt(path, nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := prompt.Render(&b, "", files, time.Now()); err == nil {
+		t.Errorf("missing --var focus rendered without error")
+	}
+}
@@ -0,0 +1,35 @@
+package tests
+
+import (
+	"fcopy/internal/tokens"
+	"testing"
+)
+
+// TestModelTokenizers checks that --model presets count with the bundled
+// tiktoken encodings
+func TestModelTokenizers(t *testing.T) {
+	defer tokens.SetModel("")
+
+	testCases := []struct {
+		model string
+		text  string
+		want  int
+	}{
+		{"gpt-4", "hello world", 2},
+		{"gpt-4o", "hello world", 2},
+		{"gpt-4", "func main() {}", 4},
+	}
+
+	for _, tc := range testCases {
+		if err := tokens.SetModel(tc.model); err != nil {
+			t.Fatalf("Failed to set model %s: %v", tc.model, err)
+		}
+		if got := tokens.Count(tc.text); got != tc.want {
+			t.Errorf("Expected %d %s tokens for %q, got %d", tc.want, tc.model, tc.text, got)
+		}
+	}
+
+	if err := tokens.SetModel("gpt-2000"); err == nil {
+		t.Errorf("Expected an error for an unknown model")
+	}
+}
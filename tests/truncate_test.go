@@ -0,0 +1,60 @@
+package tests
+
+import (
+	"context"
+	"fcopy/internal/config"
+	"fcopy/internal/processor"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestTruncateOversizedFile ensures --truncate keeps the head and tail of a
+// file over --max-size with a marker for the lines left out
+func TestTruncateOversizedFile(t *testing.T) {
+	var lines []string
+	for i := 1; i <= 100; i++ {
+		lines = append(lines, fmt.Sprintf("line %d", i))
+	}
+	path := filepath.Join(t.TempDir(), "big.log.txt")
+	if err := os.WriteFile(path, []byte(strings.Join(lines, "\n")+"\n"), 0644); err != nil {
+		t.Fatalf("Failed to create file: %v", err)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("Failed to stat file: %v", err)
+	}
+
+	testCases := []struct {
+		spec string
+		want string
+	}{
+		{"head:2,tail:3", "line 1\nline 2\n... (lines 3-97 omitted)\nline 98\nline 99\nline 100\n"},
+		{"head:1", "line 1\n... (lines 2-100 omitted)\n"},
+		{"tail:1", "... (lines 1-99 omitted)\nline 100\n"},
+		{"head:60,tail:60", strings.Join(lines, "\n") + "\n"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.spec, func(t *testing.T) {
+			cfg := &config.Config{MaxFileSize: 100, Truncate: tc.spec}
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+
+			results := make(chan processor.FileContent, 1)
+			if err := processor.ProcessSingleFile(ctx, path, info, processor.Origin{}, cfg, results); err != nil {
+				t.Fatalf("Failed to process file: %v", err)
+			}
+			result := <-results
+			if result.Content != tc.want {
+				t.Errorf("Expected content %q, got %q", tc.want, result.Content)
+			}
+			if len(result.Notes) != 1 || !strings.HasPrefix(result.Notes[0], "truncated from 100 lines") {
+				t.Errorf("Expected a truncation note, got %v", result.Notes)
+			}
+		})
+	}
+}
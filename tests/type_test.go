@@ -0,0 +1,58 @@
+package tests
+
+import (
+	"fcopy/internal/classify"
+	"fcopy/internal/config"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+// TestTypeFilter checks that --type keeps only the files of the selected
+// categories found while walking, classifying by name, extension and, for
+// JSON and scripts without an extension, content
+func TestTypeFilter(t *testing.T) {
+	dir := cliDir(t, map[string]string{
+		"main.go":               "package main\n",
+		"scripts/deploy":        "#!/bin/sh\necho deploy\n",
+		"Dockerfile":            "FROM golang\n",
+		"config/app.yaml":       "port: 8080\n",
+		"config/settings.json":  "{\"debug\": true}\n",
+		"fixtures/users.json":   "[{\"id\": 1}]\n",
+		"fixtures/orders.csv":   "id,total\n1,2\n",
+		"README.md":             "# App\n",
+		"LICENSE":               "MIT\n",
+		"docs/guide.rst":        "Guide\n=====\n",
+		"notes/unclassified.qq": "???\n",
+	})
+
+	testCases := []struct {
+		types []string
+		want  []string
+	}{
+		{nil, []string{"Dockerfile", "LICENSE", "README.md", "config/app.yaml", "config/settings.json", "docs/guide.rst",
+			"fixtures/orders.csv", "fixtures/users.json", "main.go", "notes/unclassified.qq", "scripts/deploy"}},
+		{[]string{"code"}, []string{"main.go", "scripts/deploy"}},
+		{[]string{"config"}, []string{"Dockerfile", "config/app.yaml", "config/settings.json"}},
+		{[]string{"docs", "config"}, []string{"Dockerfile", "LICENSE", "README.md", "config/app.yaml", "config/settings.json", "docs/guide.rst"}},
+		{[]string{"Data"}, []string{"fixtures/orders.csv", "fixtures/users.json"}},
+	}
+	for _, tc := range testCases {
+		cfg := &config.Config{MaxFileSize: 1024 * 1024, Workers: 2, Types: tc.types}
+		got, _ := collectFiles(t, cfg, dir, dir)
+		slices.Sort(got)
+		if !slices.Equal(got, tc.want) {
+			t.Errorf("--type %v: expected %v, got %v", tc.types, tc.want, got)
+		}
+	}
+
+	// Files given by name are copied whatever their category
+	cfg := &config.Config{MaxFileSize: 1024 * 1024, Workers: 2, Types: []string{"docs"}}
+	if got, _ := collectFiles(t, cfg, dir, filepath.Join(dir, "main.go"), dir); !slices.Contains(got, "main.go") {
+		t.Errorf("Expected main.go given by name to be kept with --type docs, got %v", got)
+	}
+
+	if _, err := classify.Parse([]string{"code", "tests"}); err == nil {
+		t.Error("Expected an error for the unknown type tests")
+	}
+}
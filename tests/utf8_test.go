@@ -0,0 +1,68 @@
+package tests
+
+import (
+	"context"
+	"fcopy/internal/config"
+	"fcopy/internal/processor"
+	"fcopy/internal/utils"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+)
+
+// TestRepairUTF8 checks that every invalid sequence becomes one U+FFFD and
+// that valid text, multi-byte runes included, is left alone
+func TestRepairUTF8(t *testing.T) {
+	testCases := []struct {
+		name     string
+		data     string
+		want     string
+		replaced int
+	}{
+		{"valid", "naïve café\n", "naïve café\n", 0},
+		{"stray byte", "caf\xe9 au lait", "caf� au lait", 1},
+		{"truncated rune", "end \xe2\x82", "end ��", 2},
+		{"overlong encoding", "\xc0\xafetc", "��etc", 2},
+		{"surrogate half", "a\xed\xa0\x80b", "a���b", 3},
+		{"between valid runes", "é\xffé", "é�é", 1},
+		{"empty", "", "", 0},
+	}
+	for _, tc := range testCases {
+		got, replaced := utils.RepairUTF8([]byte(tc.data))
+		if got != tc.want || replaced != tc.replaced {
+			t.Errorf("%s: got %q with %d replaced, want %q with %d", tc.name, got, replaced, tc.want, tc.replaced)
+		}
+	}
+}
+
+// TestRepairMostlyUTF8File checks that a UTF-8 file with one bad byte keeps
+// its accents, gets the byte replaced and says so in its header notes
+func TestRepairMostlyUTF8File(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "notes.txt")
+	content := "Résumé of the café meeting: naïve ideas, déjà vu \xff and more\n"
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to create file: %v", err)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("Failed to stat file: %v", err)
+	}
+
+	cfg := &config.Config{MaxFileSize: 1024 * 1024, Encoding: "auto"}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	results := make(chan processor.FileContent, 1)
+	if err := processor.ProcessSingleFile(ctx, path, info, processor.Origin{}, cfg, results); err != nil {
+		t.Fatalf("Failed to process file: %v", err)
+	}
+	result := <-results
+	want := "Résumé of the café meeting: naïve ideas, déjà vu � and more\n"
+	if result.Content != want {
+		t.Errorf("Expected content %q, got %q", want, result.Content)
+	}
+	if !slices.Equal(result.Notes, []string{"1 invalid UTF-8 sequence replaced"}) {
+		t.Errorf("Expected only a repair note, got %v", result.Notes)
+	}
+}
@@ -0,0 +1,62 @@
+package tests
+
+import (
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+// TestVersion checks that "fcopy version" and --version print the same
+// report, that the version, commit and date come from the ldflags when given,
+// and that the go and clipboard lines describe the build
+func TestVersion(t *testing.T) {
+	dir := cliDir(t, nil)
+	out, code := runFcopy(t, dir, "", "version")
+	if code != 0 {
+		t.Fatalf("got exit code %d:\n%s", code, out)
+	}
+	if flag, _ := runFcopy(t, dir, "", "--version"); flag != out {
+		t.Errorf("Expected --version to print\n%s\ngot\n%s", out, flag)
+	}
+	lines := strings.Split(strings.TrimSuffix(out, "\n"), "\n")
+	prefixes := []string{"fcopy ", "commit:", "built:", "go:", "clipboard:"}
+	if len(lines) != len(prefixes) {
+		t.Fatalf("Expected %d lines, got:\n%s", len(prefixes), out)
+	}
+	for i, prefix := range prefixes {
+		if !strings.HasPrefix(lines[i], prefix) {
+			t.Errorf("Expected line %d to start with %q, got %q", i+1, prefix, lines[i])
+		}
+	}
+	if want := runtime.Version() + " " + runtime.GOOS + "/" + runtime.GOARCH; !strings.HasSuffix(lines[3], want) {
+		t.Errorf("Expected the go line to end with %q, got %q", want, lines[3])
+	}
+	if lines[0] == "fcopy " || lines[0] == "fcopy unknown" {
+		t.Errorf("Expected a build without ldflags to still have a version, got %q", lines[0])
+	}
+
+	if out, code := runFcopy(t, dir, "", "version", "extra"); code != 1 || !strings.Contains(out, "Usage: fcopy version") {
+		t.Errorf("Expected usage and exit code 1 for an extra argument, got %d:\n%s", code, out)
+	}
+
+	t.Run("ldflags", func(t *testing.T) {
+		binary := filepath.Join(t.TempDir(), "fcopy")
+		if runtime.GOOS == "windows" {
+			binary += ".exe"
+		}
+		ldflags := "-X fcopy/internal/cli.version=1.2.3 -X fcopy/internal/cli.commit=abc1234 -X fcopy/internal/cli.date=2026-01-02T15:04:05Z"
+		if out, err := exec.Command("go", "build", "-ldflags", ldflags, "-o", binary, "fcopy/cmd/fcopy").CombinedOutput(); err != nil {
+			t.Fatalf("Failed to build fcopy: %v\n%s", err, out)
+		}
+		out, err := exec.Command(binary, "version").CombinedOutput()
+		if err != nil {
+			t.Fatalf("Failed to run fcopy version: %v\n%s", err, out)
+		}
+		want := "fcopy 1.2.3\ncommit:    abc1234\nbuilt:     2026-01-02T15:04:05Z\n"
+		if !strings.HasPrefix(string(out), want) {
+			t.Errorf("Expected the output to start with\n%s\ngot\n%s", want, out)
+		}
+	})
+}